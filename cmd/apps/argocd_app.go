@@ -51,7 +51,7 @@ func MakeInstallArgoCD() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(ArgoCDInfoMsgInstallMsg)
+		fmt.Println(pkg.InstallMessage(ArgoCDInfoMsgInstallMsg))
 
 		return nil
 	}
@@ -85,7 +85,7 @@ func MakeInstallCertManager() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(certManagerInstallMsg)
+		fmt.Println(pkg.InstallMessage(certManagerInstallMsg))
 
 		return nil
 	}
@@ -129,12 +129,12 @@ before using the generic helm chart installer command.`,
 			return err
 		}
 
-		fmt.Println(
+		fmt.Println(pkg.InstallMessage(
 			`=======================================================================
 		chart ` + chartRepoName + ` installed.
 		=======================================================================
 
-		` + pkg.ThanksForUsing)
+		` + pkg.ThanksForUsing))
 
 		return nil
 	}
@@ -124,7 +124,7 @@ func MakeInstallConfluentPlatformKafka() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(kafkaPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(kafkaPostInstallMsg))
 
 		return nil
 	}
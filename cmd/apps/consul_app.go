@@ -114,7 +114,7 @@ func MakeInstallConsul() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(consulInstallMsg)
+		fmt.Println(pkg.InstallMessage(consulInstallMsg))
 		return nil
 	}
 
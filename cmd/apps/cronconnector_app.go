@@ -58,7 +58,7 @@ func MakeInstallCronConnector() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(cronConnectorInstallMsg)
+		fmt.Println(pkg.InstallMessage(cronConnectorInstallMsg))
 
 		return nil
 	}
@@ -102,7 +102,7 @@ schedule workloads to any Kubernetes cluster`,
 			return err
 		}
 
-		fmt.Println(crossplaneInstallMsg)
+		fmt.Println(pkg.InstallMessage(crossplaneInstallMsg))
 		return nil
 	}
 
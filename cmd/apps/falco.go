@@ -94,7 +94,7 @@ func MakeInstallFalco() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(falcoInstallMsg)
+		fmt.Println(pkg.InstallMessage(falcoInstallMsg))
 		return nil
 	}
 
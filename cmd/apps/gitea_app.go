@@ -98,7 +98,7 @@ func MakeInstallGitea() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(giteaInstallMsg)
+		fmt.Println(pkg.InstallMessage(giteaInstallMsg))
 		return nil
 	}
 
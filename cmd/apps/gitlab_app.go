@@ -84,7 +84,7 @@ func MakeInstallGitLab() *cobra.Command {
 			return err
 		}
 
-		println(gitlabInstallMsg)
+		println(pkg.InstallMessage(gitlabInstallMsg))
 		return nil
 	}
 
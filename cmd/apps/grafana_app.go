@@ -105,7 +105,7 @@ func MakeInstallGrafana() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(grafanaInstallMsg)
+		fmt.Println(pkg.InstallMessage(grafanaInstallMsg))
 
 		return nil
 	}
@@ -54,7 +54,7 @@ func MakeInstallinfluxdb() *cobra.Command {
 			return err
 		}
 
-		println(influxdbInstallMsg)
+		println(pkg.InstallMessage(influxdbInstallMsg))
 		return nil
 	}
 
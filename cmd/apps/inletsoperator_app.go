@@ -198,7 +198,7 @@ IngressController`,
 			return err
 		}
 
-		fmt.Println(inletsOperatorPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(inletsOperatorPostInstallMsg))
 
 		return nil
 	}
@@ -159,7 +159,7 @@ func MakeInstallIstio() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(istioPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(istioPostInstallMsg))
 		return nil
 	}
 
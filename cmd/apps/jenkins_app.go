@@ -102,7 +102,7 @@ func MakeInstallJenkins() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(jenkinsInstallMsg)
+		fmt.Println(pkg.InstallMessage(jenkinsInstallMsg))
 		return nil
 	}
 
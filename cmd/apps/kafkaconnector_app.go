@@ -103,7 +103,7 @@ functions when messages are received on a given topic on a Kafka broker.`,
 			return err
 		}
 
-		fmt.Println(kafkaConnectorInstallMsg)
+		fmt.Println(pkg.InstallMessage(kafkaConnectorInstallMsg))
 
 		return nil
 	}
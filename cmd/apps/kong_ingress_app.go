@@ -58,7 +58,7 @@ func MakeInstallKongIngress() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(kongIngressInstallMsg)
+		fmt.Println(pkg.InstallMessage(kongIngressInstallMsg))
 
 		return nil
 	}
@@ -99,7 +99,7 @@ func MakeInstallKubeStateMetrics() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(`=======================================================================
+		fmt.Println(pkg.InstallMessage(`=======================================================================
 =             kube-state-metrics has been installed.                  =
 =======================================================================
 
@@ -109,7 +109,7 @@ kubectl port-forward -n ` + namespace + ` service/kube-state-metrics 9000:8080 &
 # Then access via:
 http://localhost:9000/metrics
 ` + KubeStateMetricsInfoMsg + `
-` + pkg.ThanksForUsing)
+` + pkg.ThanksForUsing))
 
 		return nil
 	}
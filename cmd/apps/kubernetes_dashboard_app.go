@@ -38,7 +38,7 @@ func MakeInstallKubernetesDashboard() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(KubernetesDashboardInstallMsg)
+		fmt.Println(pkg.InstallMessage(KubernetesDashboardInstallMsg))
 
 		return nil
 	}
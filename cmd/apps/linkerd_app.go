@@ -102,7 +102,7 @@ func MakeInstallLinkerd() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(`=======================================================================
+		fmt.Println(pkg.InstallMessage(`=======================================================================
 = Linkerd has been installed.                                        =
 =======================================================================
 
@@ -114,7 +114,7 @@ func MakeInstallLinkerd() *cobra.Command {
 export PATH=$PATH:` + path.Join(userPath, "bin/") + `
 linkerd2 --help
 
-` + pkg.ThanksForUsing)
+` + pkg.ThanksForUsing))
 		return nil
 	}
 
@@ -63,7 +63,7 @@ func MakeInstallLoki() *cobra.Command {
 			return err
 		}
 
-		println(lokiInstallMsg)
+		println(pkg.InstallMessage(lokiInstallMsg))
 		return nil
 	}
 
@@ -141,7 +141,7 @@ func MakeInstallMinio() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(minioInstallMsg)
+		fmt.Println(pkg.InstallMessage(minioInstallMsg))
 		return nil
 	}
 
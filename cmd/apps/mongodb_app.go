@@ -99,7 +99,7 @@ func MakeInstallMongoDB() *cobra.Command {
 		if err != nil {
 			return fmt.Errorf("unable to mongodb chart with helm %s", err)
 		}
-		fmt.Println(mongoDBPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(mongoDBPostInstallMsg))
 		return nil
 	}
 	return command
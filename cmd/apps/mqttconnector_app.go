@@ -91,7 +91,7 @@ messages are received on a given topic on an MQTT broker.`,
 			return err
 		}
 
-		fmt.Println(mqttConnectorInstallMsg)
+		fmt.Println(pkg.InstallMessage(mqttConnectorInstallMsg))
 
 		return nil
 	}
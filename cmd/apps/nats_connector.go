@@ -49,7 +49,7 @@ func MakeInstallNATSConnector() *cobra.Command {
 			return err
 		}
 
-		println(NATSConnectorInstallMsg)
+		println(pkg.InstallMessage(NATSConnectorInstallMsg))
 		return nil
 	}
 
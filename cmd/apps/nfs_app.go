@@ -75,7 +75,7 @@ func MakeInstallNfsProvisioner() *cobra.Command {
 			return err
 		}
 
-		println(nfsClientInstallMsg)
+		println(pkg.InstallMessage(nfsClientInstallMsg))
 		return nil
 	}
 
@@ -75,7 +75,7 @@ flag and the ingress-nginx docs for more info`,
 			return err
 		}
 
-		fmt.Println(nginxIngressInstallMsg)
+		fmt.Println(pkg.InstallMessage(nginxIngressInstallMsg))
 
 		return nil
 	}
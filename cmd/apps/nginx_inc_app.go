@@ -110,7 +110,7 @@ func MakeInstallNginxIncIngress() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(NginxIncIngressInstallMsg)
+		fmt.Println(pkg.InstallMessage(NginxIncIngressInstallMsg))
 
 		return nil
 	}
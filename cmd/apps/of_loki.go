@@ -65,7 +65,7 @@ func MakeInstallOpenFaaSLoki() *cobra.Command {
 			"-c", "gateway",
 			"-e", fmt.Sprintf("logs_provider_url=http://openfaas-loki.%s:9191/", namespace))
 
-		println(lokiOFInstallMsg)
+		println(pkg.InstallMessage(lokiOFInstallMsg))
 		return nil
 	}
 
@@ -83,7 +83,7 @@ for the Kubernetes API.`,
 			return err
 		}
 
-		fmt.Println(opaGatekeeperInstallMsg)
+		fmt.Println(pkg.InstallMessage(opaGatekeeperInstallMsg))
 		return nil
 	}
 
@@ -201,7 +201,7 @@ func MakeInstallOpenFaaS() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(openfaasPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(openfaasPostInstallMsg))
 
 		if basicAuthEnabled == false {
 			fmt.Println(
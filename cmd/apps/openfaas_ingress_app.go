@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -67,8 +68,9 @@ func MakeInstallOpenFaaSIngress() *cobra.Command {
 			return errors.New("both --email and --domain flags should be set and not empty, please set these values")
 		}
 
-		if ingressClass == "" {
-			return errors.New("--ingress-class must be set")
+		ingressClass, err := k8s.NormalizeIngressClass(ingressClass)
+		if err != nil {
+			return fmt.Errorf("--ingress-class: %w", err)
 		}
 
 		kubeConfigPath, _ := command.Flags().GetString("kubeconfig")
@@ -85,7 +87,7 @@ func MakeInstallOpenFaaSIngress() *cobra.Command {
 			return err
 		}
 
-		caps, err := k8s.GetCapabilities()
+		caps, err := k8s.GetCapabilitiesCached()
 		if err != nil {
 			return err
 		}
@@ -104,7 +106,7 @@ func MakeInstallOpenFaaSIngress() *cobra.Command {
 			}
 		}
 
-		fmt.Println(openfaasIngressInstallMsg)
+		fmt.Println(pkg.InstallMessage(openfaasIngressInstallMsg))
 
 		return nil
 	}
@@ -124,6 +126,7 @@ func createIssuer(domain, email, ingressClass, ingressName string, staging bool,
 		log.Print("Unable to save generated yaml file into the temporary directory")
 		return tempFileErr
 	}
+	defer removeTempFile(os.Stdout, tempFile, false)
 
 	res, err := k8s.KubectlTask("apply", "-f", tempFile)
 
@@ -152,6 +155,7 @@ func createIngress(domain, email, ingressClass, ingressName string, staging bool
 		log.Print("Unable to save generated yaml file into the temporary directory")
 		return tempFileErr
 	}
+	defer removeTempFile(os.Stdout, tempFile, false)
 
 	res, err := k8s.KubectlTask("apply", "-f", tempFile)
 
@@ -182,21 +186,41 @@ func createTempDirectory(directory string) (string, error) {
 	return tempDirectory, nil
 }
 
+// writeTempFile writes the input to a new directory for this run, since
+// manifests can contain details such as an issuer's email, the file is
+// only readable by the user. Remove it with removeTempFile.
 func writeTempFile(input []byte, fileLocation string) (string, error) {
 	var tempDirectory, dirErr = createTempDirectory(".arkade/")
 	if dirErr != nil {
 		return "", dirErr
 	}
 
-	filename := filepath.Join(tempDirectory, fileLocation)
+	runDirectory, err := os.MkdirTemp(tempDirectory, "run-")
+	if err != nil {
+		return "", err
+	}
+
+	filename := filepath.Join(runDirectory, fileLocation)
 
-	err := ioutil.WriteFile(filename, input, 0744)
+	err = ioutil.WriteFile(filename, input, 0600)
 	if err != nil {
 		return "", err
 	}
 	return filename, nil
 }
 
+// removeTempFile removes a file from writeTempFile along with its run
+// directory, or when keep is set prints where it was kept
+func removeTempFile(w io.Writer, filename string, keep bool) {
+	if keep {
+		fmt.Fprintf(w, "Manifest kept at: %s\n", filename)
+		return
+	}
+	if err := os.RemoveAll(filepath.Dir(filename)); err != nil {
+		log.Printf("Unable to remove %s: %s", filename, err)
+	}
+}
+
 func buildOpenfaasIngressYAML(domain, email, ingressClass, ingressName string, staging, clusterIssuer bool, issuerName, namespace string, hasNetworking bool) ([]byte, error) {
 	tmplString := openfaasIngressExtensionTemplate
 	if hasNetworking {
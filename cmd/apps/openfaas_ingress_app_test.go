@@ -4,6 +4,7 @@
 package apps
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -316,6 +317,54 @@ func Test_writeTempFile_writes_to_tmp(t *testing.T) {
 	}
 }
 
+func Test_writeTempFile_OnlyReadableByUser(t *testing.T) {
+	tmpLocation, err := writeTempFile([]byte("email: admin@example.com"), "tmp_file_name.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Dir(tmpLocation))
+
+	info, err := os.Stat(tmpLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("want mode 0600, got: %o", mode)
+	}
+
+	other, err := writeTempFile([]byte("kind: Ingress"), "tmp_file_name.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Dir(other))
+
+	if filepath.Dir(other) == filepath.Dir(tmpLocation) {
+		t.Errorf("want a directory per run, got %s for both", filepath.Dir(other))
+	}
+}
+
+func Test_removeTempFile(t *testing.T) {
+	removed, _ := writeTempFile([]byte("kind: Ingress"), "tmp_file_name.yaml")
+	var out bytes.Buffer
+	removeTempFile(&out, removed, false)
+	if _, err := os.Stat(filepath.Dir(removed)); !os.IsNotExist(err) {
+		t.Errorf("want the run directory removed, got: %v", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("want no output, got: %q", out.String())
+	}
+
+	kept, _ := writeTempFile([]byte("kind: Ingress"), "tmp_file_name.yaml")
+	defer os.RemoveAll(filepath.Dir(kept))
+	removeTempFile(&out, kept, true)
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("want the manifest kept, got: %s", err)
+	}
+	if want := "Manifest kept at: " + kept + "\n"; out.String() != want {
+		t.Errorf("want: %q, got: %q", want, out.String())
+	}
+}
+
 func Test_createTempDirectory_creates(t *testing.T) {
 	var want = filepath.Join(os.TempDir(), ".arkade")
 
@@ -77,11 +77,11 @@ service mesh created by Microsoft Azure.`,
 			return fmt.Errorf("exit code %d, error: %s", res.ExitCode, res.Stderr)
 		}
 
-		fmt.Println(`=======================================================================
+		fmt.Println(pkg.InstallMessage(`=======================================================================
 = OSM has been installed.                                             =
 =======================================================================
 ` +
-			OSMInfoMsg + pkg.ThanksForUsing)
+			OSMInfoMsg + pkg.ThanksForUsing))
 		return nil
 	}
 
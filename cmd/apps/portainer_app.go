@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/alexellis/arkade/pkg/config"
+	"github.com/alexellis/arkade/pkg/get"
 	"github.com/alexellis/arkade/pkg/k8s"
 
 	"github.com/alexellis/arkade/pkg"
@@ -52,6 +53,7 @@ func MakeInstallPortainer() *cobra.Command {
 		if err != nil {
 			return err
 		}
+		req.Header.Set("User-Agent", get.UserAgent())
 
 		res, err := http.DefaultClient.Do(req)
 
@@ -75,7 +77,7 @@ func MakeInstallPortainer() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(PortainerInstallMsg)
+		fmt.Println(pkg.InstallMessage(PortainerInstallMsg))
 
 		return nil
 	}
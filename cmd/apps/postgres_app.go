@@ -78,7 +78,7 @@ func MakeInstallPostgresql() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(postgresqlInstallMsg)
+		fmt.Println(pkg.InstallMessage(postgresqlInstallMsg))
 		return nil
 	}
 
@@ -71,7 +71,7 @@ func MakeInstallRedis() *cobra.Command {
 			return err
 		}
 
-		println(redisInstallMsg)
+		println(pkg.InstallMessage(redisInstallMsg))
 		return nil
 	}
 
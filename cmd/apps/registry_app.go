@@ -141,7 +141,7 @@ func MakeInstallRegistry() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(registryInstallMsg)
+		fmt.Println(pkg.InstallMessage(registryInstallMsg))
 
 		if len(outputFile) > 0 {
 			err := ioutil.WriteFile(outputFile, []byte(pass), 0600)
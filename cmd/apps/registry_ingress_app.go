@@ -4,29 +4,210 @@
 package apps
 
 import (
-	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/alexellis/arkade/pkg"
 	"github.com/alexellis/arkade/pkg/config"
 	"github.com/alexellis/arkade/pkg/k8s"
-
-	"text/template"
-
-	"github.com/alexellis/arkade/pkg"
-
+	"github.com/alexellis/arkade/pkg/table"
 	"github.com/spf13/cobra"
 )
 
 type RegInputData struct {
-	IngressDomain    string
-	CertmanagerEmail string
-	IngressClass     string
-	Namespace        string
-	NginxMaxBuffer   string
-	IssuerType       string
-	IssuerAPI        string
+	IngressDomain          string
+	Hosts                  []string
+	Path                   string
+	APIVersions            map[string]string
+	CertmanagerEmail       string
+	IngressClass           string
+	Namespace              string
+	IssuerType             string
+	IssuerName             string
+	IssuerKind             string
+	IngressName            string
+	ServiceName            string
+	ServicePort            int
+	IssuerAPI              string
+	Annotations            map[string]string
+	KindAnnotations        map[string]map[string]string
+	TLSSecretName          string
+	SkipIssuer             bool
+	Protected              bool
+	CertDuration           string
+	RenewBefore            string
+	MinTLSVersion          string
+	Solvers                []acmeSolver
+	ExternalAccountBinding *externalAccountBinding
+	Canary                 *registryCanary
+	Middleware             *traefikMiddleware
+	ServiceMonitor         *serviceMonitor
+	OwnerReferences        []ownerReference
+}
+
+// traefikMiddleware raises traefik's limit on the size of a request
+// body, which it has no annotation for, to --max-size
+type traefikMiddleware struct {
+	Name                string
+	MaxRequestBodyBytes int64
+}
+
+// serviceMonitor has Prometheus scrape the metrics port of the ingress
+// controller's Service, found in any namespace by Selector
+type serviceMonitor struct {
+	Name     string
+	Selector map[string]string
+}
+
+// registryCanary is a second Ingress for the same host, which nginx
+// sends a weighted share of the traffic to
+type registryCanary struct {
+	Name        string
+	ServiceName string
+	ServicePort int
+	Annotations map[string]string
+}
+
+// registryHost is an extra host given with --host, Solver is http01 or
+// dns01
+type registryHost struct {
+	Domain string
+	Solver string
+}
+
+// registryIngressOptions holds the choices made via flags, from which
+// the RegInputData for the templates is built
+type registryIngressOptions struct {
+	Domain       string
+	Email        string
+	IngressClass string
+	Namespace    string
+	MaxSize      string
+	Staging      bool
+	Annotations  map[string]string
+	ReleaseName  string
+
+	// ACMEServer replaces the Let's Encrypt directory used by the
+	// Issuer, such as for ZeroSSL or a private CA
+	ACMEServer string
+
+	// EABKeyID binds the ACME account to one with the CA, using the HMAC
+	// key in the EABSecret secret
+	EABKeyID     string
+	EABSecret    string
+	EABSecretKey string
+
+	// ServiceName and ServicePort are the registry's Service, which the
+	// Ingress routes to
+	ServiceName string
+	ServicePort int
+
+	// IssuerKind is Issuer or ClusterIssuer, a ClusterIssuer can be
+	// shared by the registries in every namespace
+	IssuerKind string
+
+	// CertDuration and RenewBefore tune the Certificate created by
+	// cert-manager, as Go durations such as 2160h
+	CertDuration string
+	RenewBefore  string
+
+	// Path is where the registry is served on the domain, which may
+	// contain a capture group such as /registry/(.*) for RewriteTarget
+	Path          string
+	RewriteTarget string
+
+	// APIVersions pins the apiVersion of each kind, overriding the
+	// version chosen from the cluster's capabilities
+	APIVersions map[string]string
+
+	// Hosts are served by the Ingress as well as Domain, each solved
+	// with its own ACME challenge type
+	Hosts []registryHost
+
+	// SolverSeccomp is the seccompProfile type for the http01 solver's
+	// pod, for clusters which reject pods without one
+	SolverSeccomp string
+
+	// SolverServiceType is NodePort or ClusterIP for the http01 solver's
+	// Service, cert-manager uses NodePort when it is not set
+	SolverServiceType string
+
+	// MinTLSVersion is 1.2 or 1.3, older protocols are disabled by
+	// nginx, or by Contour for an HTTPProxy
+	MinTLSVersion string
+
+	// SessionAffinity makes nginx send each client to the same registry
+	// pod, by setting a cookie named SessionCookieName
+	SessionAffinity   string
+	SessionCookieName string
+
+	// Scheme is internal or internet-facing, rendered as the annotation
+	// for the cloud load balancer behind the ingress class
+	Scheme string
+
+	// KindAnnotations are added only to resources of the kind they are
+	// keyed by, and are applied after Annotations
+	KindAnnotations map[string]map[string]string
+
+	// CanaryServiceName renders a canary Ingress when set, which gets
+	// CanaryWeight percent of the traffic
+	CanaryServiceName string
+	CanaryServicePort int
+	CanaryWeight      int
+
+	// Challenge is http01 or dns01, with dns01 every host is solved by
+	// DNS01Provider, otherwise only DNS01Zones and hosts using dns01
+	Challenge string
+
+	// DNS01Provider adds a dns01 solver for DNS01Zones, next to the
+	// http01 solver for the domain
+	DNS01Provider  string
+	DNS01Secret    string
+	DNS01SecretKey string
+	DNS01Zones     []string
+	DNS01Region    string
+
+	// Template replaces the built-in templates when set
+	Template string
+
+	// HTTPProxy renders a Contour HTTPProxy and a Certificate instead
+	// of an Ingress
+	HTTPProxy bool
+
+	// OwnerReferences are stamped on every resource, so that they are
+	// deleted along with their owner
+	OwnerReferences []ownerReference
+
+	// TLSSecret replaces the docker-registry secret referenced by the
+	// Ingress, such as for a wildcard certificate managed elsewhere
+	TLSSecret string
+
+	// SkipIssuer leaves out the Issuer and its annotation, so that
+	// cert-manager does not issue a certificate into TLSSecret
+	SkipIssuer bool
+
+	// Protect labels the Issuer with protectedLabel, so that --uninstall
+	// refuses to delete it without --force
+	Protect bool
+
+	// ServiceMonitor renders a ServiceMonitor for the ingress controller,
+	// selecting its Service by MonitorSelector or the managed-by label
+	ServiceMonitor  bool
+	MonitorSelector map[string]string
 }
 
 func MakeInstallRegistryIngress() *cobra.Command {
@@ -40,66 +221,454 @@ to your email - this email is used by letsencrypt for domain expiry etc.`,
 		SilenceUsage: true,
 	}
 
-	registryIngress.Flags().StringP("domain", "d", "", "Custom Ingress Domain")
+	registryIngress.Flags().StringSliceP("domain", "d", []string{}, "Custom Ingress Domain, repeat or comma-separate to serve the registry under several hostnames with one certificate")
 	registryIngress.Flags().StringP("email", "e", "", "Letsencrypt Email")
-	registryIngress.Flags().String("ingress-class", "nginx", "Ingress class to be used such as nginx or traefik")
-	registryIngress.Flags().String("max-size", "200m", "the max size for the ingress proxy, default to 200m")
+	registryIngress.Flags().String("ingress-class", "", "Ingress class to be used such as nginx or traefik, defaults to the cluster's default IngressClass or nginx")
+	registryIngress.Flags().String("max-size", "200m", "the max size of a request body for the ingress proxy, with a k, m or g suffix, or 0 for no limit")
 	registryIngress.Flags().StringP("namespace", "n", "default", "The namespace where the registry is installed")
+	registryIngress.Flags().String("server", "", "URL of the kube API server to apply to, such as a throwaway cluster in CI, the kubeconfig is not read")
+	registryIngress.Flags().String("token", "", "Bearer token for the API server given by --server")
+	registryIngress.Flags().Bool("insecure-skip-tls-verify", false, "Don't verify the certificate of the API server given by --server")
+	registryIngress.Flags().String("tls-secret", "", "Name of the TLS secret referenced by the Ingress, defaults to docker-registry")
+	registryIngress.Flags().Bool("skip-issuer", false, "Don't create an Issuer or annotate the Ingress for cert-manager, use with --tls-secret for a certificate which already exists")
+	registryIngress.Flags().String("issuer-kind", "Issuer", "Kind of cert-manager issuer to create and reference from the Ingress: Issuer or ClusterIssuer")
+	registryIngress.Flags().String("service-name", "docker-registry", "Name of the registry's Service, i.e. when installed by a chart with a different release name")
+	registryIngress.Flags().Int("service-port", 5000, "Port of the registry's Service")
 	registryIngress.Flags().Bool("staging", false, "set --staging to true to use the staging Letsencrypt issuer")
+	registryIngress.Flags().String("eab-key-id", "", "Key ID for the External Account Binding required by some ACME servers, with --acme-server")
+	registryIngress.Flags().String("eab-hmac-secret", "", "Name of the secret holding the HMAC key for --eab-key-id")
+	registryIngress.Flags().String("eab-hmac-secret-key", "secret", "Key within --eab-hmac-secret which holds the HMAC key")
+	registryIngress.Flags().String("acme-server", "", "Directory URL of an ACME server to use instead of Let's Encrypt, i.e. https://acme.zerossl.com/v2/DV90, not with --staging")
+	registryIngress.Flags().String("domains-file", "", "Render and apply the registry ingress for each domain,namespace,email line of this file, the email defaults to --email")
+	registryIngress.Flags().String("config-from", "", "Read the domain and email keys from configmap/<name> or secret/<name> in --namespace, --domain and --email take precedence")
+	registryIngress.Flags().StringArray("annotation", []string{}, "Add an annotation to the Ingress i.e. --annotation key=value, overriding any set by arkade (can be repeated)")
+	registryIngress.Flags().String("cert-duration", "", "Requested lifetime of the certificate, as a duration i.e. 2160h")
+	registryIngress.Flags().String("renew-before", "", "How long before expiry the certificate is renewed, as a duration i.e. 360h")
+	registryIngress.Flags().String("path", "/", "Path the registry is served under, i.e. /registry/(.*) with --rewrite-target")
+	registryIngress.Flags().String("rewrite-target", "", "Rewrite the path for the registry with nginx, referencing capture groups in --path i.e. /$1")
+	registryIngress.Flags().StringToString("api-version", map[string]string{}, "Pin the apiVersion of a kind, overriding detection i.e. --api-version Ingress=networking.k8s.io/v1,Issuer=cert-manager.io/v1")
+	registryIngress.Flags().StringArray("host", []string{}, "Serve an extra host, solved with http01 by default or dns01 via --dns-provider i.e. --host mirror.example.com:dns01 (can be repeated)")
+	registryIngress.Flags().String("solver-service-type", "", "Service type for the http01 solver: ClusterIP for clusters where NodePorts can't be allocated, or NodePort, cert-manager's default")
+	registryIngress.Flags().String("solver-seccomp", "", "seccompProfile type for the http01 solver pod, for clusters enforcing the restricted Pod Security Standard: RuntimeDefault or Unconfined")
+	registryIngress.Flags().String("min-tls-version", "", "Lowest TLS version accepted, with the nginx ingress class or --httpproxy: 1.2 or 1.3")
+	registryIngress.Flags().String("session-affinity", "", "Send each client to the same pod with the nginx ingress class, the only type is: cookie")
+	registryIngress.Flags().String("session-cookie-name", defaultSessionCookieName, "Name of the cookie set for --session-affinity")
+	registryIngress.Flags().String("scheme", "", "Load balancer scheme for the alb, gce or azure/application-gateway ingress class: internal or internet-facing")
+	registryIngress.Flags().String("cloud", "", "Default --ingress-class and --scheme for the cloud the cluster runs on: aws, gcp, azure, or auto to detect it from the nodes")
+	registryIngress.Flags().StringArray("annotation-on", []string{}, "Add an annotation to resources of one kind i.e. --annotation-on Issuer:key=value (can be repeated)")
+	registryIngress.Flags().String("release-name", "", "Prefix the names of all resources with this release name, to install more than one registry ingress into a namespace")
+	registryIngress.Flags().Int("canary-weight", -1, "Percentage (0-100) of traffic for nginx to send to the canary service, via a second Ingress")
+	registryIngress.Flags().String("canary-service-name", "", "Service for the canary Ingress, required with --canary-weight")
+	registryIngress.Flags().Int("canary-service-port", 5000, "Port of the canary service")
+	registryIngress.Flags().String("challenge", "http01", "ACME challenge for the domain: http01, which needs port 80 reachable from the internet, or dns01 via --dns-provider")
+	registryIngress.Flags().String("dns-provider", "", "DNS01 provider, one of: cloudflare, digitalocean, route53. With --challenge http01 it only solves --dns01-zones and hosts using dns01")
+	registryIngress.Flags().String("dns01-provider", "", "Use --dns-provider instead")
+	registryIngress.Flags().MarkDeprecated("dns01-provider", "use --dns-provider instead")
+	registryIngress.Flags().String("dns01-secret", "", "Name of the secret holding the API token for the DNS01 provider")
+	registryIngress.Flags().String("dns01-secret-key", "api-token", "Key within --dns01-secret which holds the API token")
+	registryIngress.Flags().StringSlice("dns01-zones", []string{}, "DNS zones to solve with DNS01 i.e. example.com, other domains use HTTP01")
+	registryIngress.Flags().String("dns01-region", "", "AWS region for the route53 DNS01 provider")
+	registryIngress.Flags().Bool("create-namespace", false, "Create --namespace when it does not exist, instead of failing")
+	registryIngress.Flags().Bool("skip-if-exists", false, "Do nothing when the Ingress already exists with the same hosts, TLS secret, backend and annotations")
+	registryIngress.Flags().Bool("check-existing-cert", false, "Report on an existing certificate in the TLS secret and ask whether to proceed")
+	registryIngress.Flags().Bool("verify-tls", false, "After applying, make a HTTPS request to https://<domain>/v2/ and check the certificate is for the domain and from Let's Encrypt")
+	registryIngress.Flags().Duration("verify-tls-timeout", time.Minute*2, "How long to keep trying --verify-tls while the certificate is issued")
+	registryIngress.Flags().String("verify-tls-ca", "", "PEM bundle of the CA behind --acme-server, to verify the certificate's chain with --verify-tls, otherwise only its hostname and expiry are checked")
+	registryIngress.Flags().Bool("watch-cert-manager", false, "After applying, show cert-manager logs for the namespace and domain until the certificate is Ready or Control+C is pressed")
+	registryIngress.Flags().String("report", "", "Write a JSON report of what was installed to this file after a successful install")
+	registryIngress.Flags().Duration("wait-timeout", 0, "With --wait, the longest to wait for the Issuer and Certificate together, 0 for no limit other than --issuer-timeout and --cert-timeout")
+	registryIngress.Flags().Duration("issuer-timeout", time.Minute*2, "With --wait, how long to wait for the Issuer to be Ready")
+	registryIngress.Flags().Duration("cert-timeout", time.Minute*5, "With --wait, how long to wait for the Certificate to be Ready, after the Issuer")
+	registryIngress.Flags().Bool("httpproxy", false, "Render a Contour HTTPProxy and a cert-manager Certificate instead of an Ingress, requires the projectcontour.io/v1 CRDs")
+	registryIngress.Flags().StringArray("owner-ref", []string{}, "Add an ownerReference to every resource as apiVersion/kind/name/uid, so they are deleted with the owner (can be repeated)")
+	registryIngress.Flags().String("template-file", "", "Render this Go template instead of the built-in Ingress and Issuer, from a local file or <repo>@<ref>:<path> in Git")
+	registryIngress.Flags().String("from-git", "", "Render the template at <repo>@<ref>:<path> instead of the built-in Ingress and Issuer, from a shallow clone of the branch or tag")
+	registryIngress.Flags().String("pre-hook", "", "Shell command to run before applying, never with --print-apply-order, with ARKADE_DOMAIN, ARKADE_NAMESPACE, ARKADE_INGRESS_NAME and ARKADE_TLS_SECRET set")
+	registryIngress.Flags().String("post-hook", "", "Shell command to run after a successful apply, with the same environment as --pre-hook")
+	registryIngress.Flags().StringP("output", "o", "", "Print the result of the install as json instead of the install message, or with --dry-run, list the resources as a table or json instead of printing the YAML")
+	registryIngress.Flags().Bool("dry-run", false, "Print the YAML that would be applied without applying it, warnings are written to stderr")
+	registryIngress.Flags().Bool("render-matrix", false, "Print the YAML rendered for both the extensions/v1beta1 and networking.k8s.io/v1 Ingress APIs, then exit")
+	registryIngress.Flags().MarkHidden("render-matrix")
+	registryIngress.Flags().Bool("rollback-on-failure", false, "Delete the resources created by this run when any resource fails to apply")
+	registryIngress.Flags().Duration("poll-min", time.Second, "First interval between checks for readiness with --wait, doubling up to --poll-max")
+	registryIngress.Flags().Duration("poll-max", time.Second*30, "Longest interval between checks for readiness with --wait")
+	registryIngress.Flags().Bool("server-side", false, "Use server-side apply with arkade as the field manager, so that fields owned by GitOps tools are not clobbered")
+	registryIngress.Flags().Bool("keep-manifests", false, "Keep the generated manifest in the temporary directory after applying it, and print its path")
+	registryIngress.Flags().Bool("strict", false, "Fail instead of printing warnings, such as for a deprecated API, an unknown ingress class or a missing ingress controller")
+	registryIngress.Flags().Duration("timeout-banner", time.Minute, "With --wait, print troubleshooting tips once the Issuer or Certificate has not been Ready for this long, 0 to turn off")
+	registryIngress.Flags().Int("retries", 4, "Retries shared by the prerequisite checks, the apply and --wait when kubectl fails for a transient reason")
+	registryIngress.Flags().Duration("retry-interval", time.Second*2, "Wait before each retry counted by --retries")
+	registryIngress.Flags().Bool("show-next-steps", true, "Print the next steps after the installed message")
+	registryIngress.Flags().Bool("explain", false, "Print the YAML with a description of what each resource does, then exit without applying it")
+	registryIngress.Flags().Bool("render-with-comments", false, "Print the YAML with a header and a comment labelling each resource, then exit without applying it")
+	registryIngress.Flags().String("confirm-context", "", "Name of the current kubectl context, required to apply to a context matching protectedContexts in $HOME/.arkade/config.yaml")
+	registryIngress.Flags().Bool("print-apply-order", false, "Print the order in which the resources would be applied, then exit without applying them")
+	registryIngress.Flags().Bool("install-ingress-controller", false, "Install ingress-nginx or traefik2 for --ingress-class when no controller is found, before applying the Ingress")
+	registryIngress.Flags().String("kustomize", "", "Directory of a kustomize overlay listing ../base in its resources, applied with kubectl apply -k over the rendered manifest as the base")
+	registryIngress.Flags().Bool("uninstall", false, "Delete the resources rendered for the same flags, instead of applying them, resources which are already gone are skipped")
+	registryIngress.Flags().Bool("as-job", false, "Print a Job, with a ServiceAccount and RBAC, which runs this install with the same flags inside the cluster, then exit")
+	registryIngress.Flags().String("job-image", "", "Image for --as-job, with arkade and kubectl installed")
+	registryIngress.Flags().String("job-namespace", "", "Namespace for the Job printed by --as-job, defaults to --namespace")
+	registryIngress.Flags().Bool("protect", false, "Label the Issuer with "+protectedLabel+", so that --uninstall refuses to delete it without --force")
+	registryIngress.Flags().Bool("service-monitor", false, "Render a Prometheus ServiceMonitor for the metrics port of the ingress controller, requires the monitoring.coreos.com/v1 CRDs")
+	registryIngress.Flags().StringToString("monitor-selector", map[string]string{}, "Labels of the ingress controller's Service for --service-monitor to select, defaults to "+k8s.ManagedBySelector)
+	registryIngress.Flags().Bool("force", false, "With --uninstall, also delete resources labelled by --protect")
+	registryIngress.Flags().Bool("delete-tls-secret", false, "With --uninstall, also delete the TLS secret created by cert-manager, after confirming")
+	registryIngress.Flags().BoolP("yes", "y", false, "Do not ask for confirmation before deleting the TLS secret")
+	registryIngress.Flags().Bool("list-apis", false, "List the Ingress, Gateway API, Traefik and Contour backends and whether the cluster serves their APIs, then exit")
 
 	registryIngress.RunE = func(command *cobra.Command, args []string) error {
-		kubeConfigPath, _ := command.Flags().GetString("kubeconfig")
-		if err := config.SetKubeconfig(kubeConfigPath); err != nil {
+		// With --output json, stdout only carries the JSON document, so
+		// the progress printed to os.Stdout, including by kubectl, helm
+		// and the hooks, goes to stderr
+		stdout := os.Stdout
+		if output, _ := command.Flags().GetString("output"); output == "json" {
+			os.Stdout = os.Stderr
+			defer func() {
+				os.Stdout = stdout
+			}()
+		}
+
+		if err := setRegistryIngressCluster(command.Flags()); err != nil {
 			return err
 		}
 
-		email, _ := command.Flags().GetString("email")
-		domain, _ := command.Flags().GetString("domain")
-		ingressClass, _ := command.Flags().GetString("ingress-class")
-		namespace, _ := command.Flags().GetString("namespace")
-		maxSize, _ := command.Flags().GetString("max-size")
+		if asJob, _ := command.Flags().GetBool("as-job"); asJob {
+			job := registryInstallJob{}
+			job.Image, _ = command.Flags().GetString("job-image")
+			job.Namespace, _ = command.Flags().GetString("job-namespace")
+			if len(job.Namespace) == 0 {
+				job.Namespace, _ = command.Flags().GetString("namespace")
+			}
+			if len(job.Image) == 0 {
+				return errors.New("--as-job needs --job-image, an image with arkade and kubectl installed")
+			}
+
+			var err error
+			job.Args, err = installJobArgs(command.Flags())
+			if err != nil {
+				return err
+			}
+			return writeInstallJob(stdout, job)
+		}
+
+		if listAPIs, _ := command.Flags().GetBool("list-apis"); listAPIs {
+			caps, err := k8s.GetCapabilitiesCached()
+			if err != nil {
+				return err
+			}
+			output, _ := command.Flags().GetString("output")
+			return writeRenderBackends(stdout, availableRenderBackends(caps), output, table.TerminalWidth())
+		}
+
+		run, err := readRegistryIngressRun(command.Flags())
+		if err != nil {
+			return err
+		}
+		budget := run.Budget
 
-		if email == "" || domain == "" {
-			return errors.New("both --email and --domain flags should be set and not empty, please set these values")
+		var caps map[string]bool
+		err = budget.Do(context.Background(), nil, func() error {
+			var err error
+			caps, err = k8s.GetCapabilitiesCached()
+			return err
+		})
+		if err != nil {
+			return err
 		}
 
-		if ingressClass == "" {
-			return errors.New("--ingress-class must be set")
+		warnings := &installWarnings{Strict: run.Strict}
+		writeWarnings := true
+		defer func() {
+			if !writeWarnings {
+				return
+			}
+			// Only a result is written as JSON, on any other path the
+			// warnings are printed as text
+			if run.Output == "json" {
+				warnings.Write(os.Stderr, "")
+				return
+			}
+			warnings.Write(os.Stdout, run.Output)
+		}()
+
+		opts, hasNetworking, err := readRegistryIngressOptions(command.Flags(), caps, warnings)
+		if err != nil {
+			return err
+		}
+		namespace, domain := opts.Namespace, opts.Domain
+
+		if run.RenderMatrix {
+			renderings, err := renderMatrix(opts)
+			if err != nil {
+				return err
+			}
+			writeRenderMatrix(stdout, renderings)
+			return nil
 		}
 
-		caps, err := k8s.GetCapabilities()
+		userConfig, err := config.LoadUserConfig(config.UserConfigPath())
 		if err != nil {
 			return err
 		}
 
-		hasNetworking := caps["networking.k8s.io/v1"]
-		staging, _ := registryIngress.Flags().GetBool("staging")
-		yamlBytes, templateErr := buildRegistryYAML(domain, email, ingressClass, namespace, maxSize, staging, hasNetworking)
+		if len(run.DomainsFile) > 0 {
+			if err := checkProtectedContext(userConfig, currentKubeContext(), run.ConfirmContext); err != nil {
+				return err
+			}
+
+			f, err := os.Open(run.DomainsFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			entries, err := parseDomainsFile(f, opts.Email)
+			if err != nil {
+				return fmt.Errorf("unable to read --domains-file %s: %w", run.DomainsFile, err)
+			}
+			for _, entry := range entries {
+				if err := validateDomain(fmt.Sprintf("--domains-file line %d", entry.Line), entry.Domain, opts.Challenge != "dns01"); err != nil {
+					return err
+				}
+			}
+
+			if err := preflightDomains(entries, opts, hasNetworking, warnings, run.CreateNamespace, budget); err != nil {
+				return err
+			}
+			if !opts.HTTPProxy {
+				if err := ensureIngressController(os.Stdout, warnings, opts.IngressClass, run.InstallController, run.ControllerArgs, budget); err != nil {
+					return err
+				}
+			}
+
+			// Nothing has been applied yet, so a strict install stops here
+			if err := warnings.Err(); err != nil {
+				return err
+			}
+			return applyDomains(os.Stdout, entries, opts, hasNetworking, run.CreateNamespace, budget, run.Apply)
+		}
+
+		yamlBytes, templateErr := buildRegistryYAML(opts, hasNetworking)
 		if templateErr != nil {
 			log.Print("Unable to install the application. Could not build the templated yaml file for the resources")
 			return templateErr
 		}
 
+		yamlBytes, objects, err := k8s.SortManifest(yamlBytes)
+		if err != nil {
+			return err
+		}
+
+		if run.PrintApplyOrder {
+			writeApplyOrder(stdout, objects)
+			return nil
+		}
+
+		if run.Explain {
+			return writeExplainedManifest(stdout, yamlBytes)
+		}
+
+		if run.WithComments {
+			return writeCommentedManifest(stdout, yamlBytes)
+		}
+
+		if run.DryRun {
+			// The warnings are part of the JSON inventory, or kept out
+			// of the YAML so that it can be piped to a file
+			writeWarnings = false
+			if err := writeDryRun(stdout, os.Stderr, yamlBytes, objects, warnings, run.Output); err != nil {
+				return err
+			}
+			return warnings.Err()
+		}
+
+		if err := checkProtectedContext(userConfig, currentKubeContext(), run.ConfirmContext); err != nil {
+			return err
+		}
+
+		if run.Uninstall {
+			if err := uninstallRegistryIngress(os.Stdout, yamlBytes, run.Apply.KeepManifests, run.Force); err != nil {
+				return err
+			}
+			if run.DeleteTLSSecret {
+				return deleteTLSSecret(os.Stdout, command.InOrStdin(), namespace, newRegInputData(opts).TLSSecretName, run.Yes)
+			}
+			return nil
+		}
+
+		if err := ensureNamespace(namespace, run.CreateNamespace, budget); err != nil {
+			return err
+		}
+
+		if err := checkDNS01Secret(opts, budget); err != nil {
+			return err
+		}
+
+		if run.SkipIfExists {
+			ingressName := newRegInputData(opts).IngressName
+			matches, err := liveIngressMatches(namespace, ingressName, yamlBytes)
+			if err != nil {
+				return err
+			}
+			if matches {
+				fmt.Printf("Ingress %s/%s already exists and matches, nothing was applied.\n", namespace, ingressName)
+				return nil
+			}
+		}
+
+		if run.CheckExistingCert {
+			inputData := newRegInputData(opts)
+			proceed, err := checkExistingCert(os.Stdout, command.InOrStdin(), namespace, inputData.TLSSecretName, time.Now())
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				fmt.Println("Keeping the existing certificate, nothing was applied.")
+				return nil
+			}
+		}
+
+		quotaWarnings, err := checkResourceQuota(namespace, objects)
+		if err != nil {
+			warnings.Add("unable to check ResourceQuota in namespace %s: %s", namespace, err)
+		}
+		for _, warning := range quotaWarnings {
+			warnings.Add("%s", warning)
+		}
+
+		hostWarnings, err := checkIngressHostConflicts(newRegInputData(opts).Hosts, objects)
+		if err != nil {
+			warnings.Add("unable to check for other Ingresses serving %s: %s", domain, err)
+		}
+		for _, warning := range hostWarnings {
+			warnings.Add("%s", warning)
+		}
+
+		if !opts.HTTPProxy {
+			if err := ensureIngressController(os.Stdout, warnings, opts.IngressClass, run.InstallController, run.ControllerArgs, budget); err != nil {
+				return err
+			}
+		}
+
+		// Nothing has been applied yet, so a strict install stops here
+		if err := warnings.Err(); err != nil {
+			return err
+		}
+
 		tempFile, tempFileErr := writeTempFile(yamlBytes, "temp_registry_ingress.yaml")
 		if tempFileErr != nil {
 			log.Print("Unable to save generated yaml file into the temporary directory")
 			return tempFileErr
 		}
+		defer removeTempFile(os.Stdout, tempFile, run.Apply.KeepManifests)
 
-		res, err := k8s.KubectlTask("apply", "-f", tempFile)
+		hookEnv := registryHookEnv(newRegInputData(opts))
+
+		source := []string{"-f", tempFile}
+		if len(run.Apply.Kustomize) > 0 {
+			overlay, err := writeKustomization(filepath.Dir(tempFile), yamlBytes, run.Apply.Kustomize)
+			if err != nil {
+				return err
+			}
+			source = []string{"-k", overlay}
+		}
 
+		var applied []string
+		err = runWithHooks(run.PreHook, run.PostHook, hookEnv, func() error {
+			res, created, err := applySourceWithRetry(source, budget, run.Apply.args()...)
+
+			if err != nil {
+				log.Print(err)
+				return err
+			}
+
+			if conflictErr := applyConflictError(res); conflictErr != nil {
+				return conflictErr
+			}
+			if kubectlErr := k8s.ResultError(res, append(append([]string{"apply"}, source...), run.Apply.args()...)...); kubectlErr != nil {
+				applyErr := fmt.Errorf(`Unable to apply YAML files.
+Have you got the Registry running and cert-manager 0.11.0 or higher installed? %w`,
+					kubectlErr)
+				if run.Rollback {
+					return rollbackCreated(os.Stdout, namespace, created, applyErr)
+				}
+				return applyErr
+			}
+			applied = parseAppliedResources(res.Stdout)
+			return nil
+		})
 		if err != nil {
-			log.Print(err)
 			return err
 		}
 
-		if res.ExitCode != 0 {
-			return fmt.Errorf(`Unable to apply YAML files.
-Have you got the Registry running and cert-manager 0.11.0 or higher installed? %s`,
-				res.Stderr)
+		if run.Wait {
+			inputData := newRegInputData(opts)
+
+			var waits []readyWait
+			if !inputData.SkipIssuer {
+				waits = append(waits,
+					readyWait{Kind: inputData.IssuerKind, Name: inputData.IssuerName, Timeout: run.IssuerTimeout},
+					readyWait{Kind: "Certificate", Name: inputData.TLSSecretName, Timeout: run.CertTimeout})
+			}
+
+			banner := newTroubleshootingBanner(run.TimeoutBanner)
+
+			ctx := context.Background()
+			if run.WaitTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, run.WaitTimeout)
+				defer cancel()
+			}
+
+			if err := waitForResources(ctx, os.Stdout, namespace, waits, newPollPolicy(run.PollMin, run.PollMax), budget, banner); err != nil {
+				return err
+			}
+		}
+
+		if run.WatchCertManager {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err := watchCertManager(ctx, os.Stdout, namespace, newRegInputData(opts).TLSSecretName, domain, certManagerPollInterval)
+			stop()
+			if err != nil {
+				return err
+			}
+		}
+
+		if run.VerifyTLS {
+			client, err := newTLSVerifyClient(opts, run.VerifyTLSCA)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), run.VerifyTLSTimeout)
+			issuerOrganization := letsEncryptOrganization
+			if len(opts.ACMEServer) > 0 {
+				issuerOrganization = ""
+			}
+			err = verifyTLSUntil(ctx, client, "https://"+domain, domain, issuerOrganization, certManagerPollInterval)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("TLS verification of %s failed: %w", domain, err)
+			}
+			fmt.Printf("Verified the certificate served for https://%s/v2/\n", domain)
+		}
+
+		if len(run.Report) > 0 {
+			report := newRegistryIngressReport(newRegInputData(opts), currentKubeContext(), time.Now())
+			if err := writeRegistryIngressReport(run.Report, report); err != nil {
+				return fmt.Errorf("unable to write report to %s: %w", run.Report, err)
+			}
+			fmt.Printf("Report written to: %s\n", run.Report)
 		}
 
-		fmt.Println(RegistryIngressInstallMsg)
+		if run.Output == "json" {
+			writeWarnings = false
+			result := newRegistryIngressResult(newRegInputData(opts), applied, warnings.Warnings)
+			return writeRegistryIngressResult(stdout, result)
+		}
+
+		writeInstallMessage(os.Stdout, run.ShowNextSteps)
 
 		return nil
 	}
@@ -107,42 +676,301 @@ Have you got the Registry running and cert-manager 0.11.0 or higher installed? %
 	return registryIngress
 }
 
-func buildRegistryYAML(domain, email, ingressClass, namespace, maxSize string, staging, hasNetworking bool) ([]byte, error) {
-	tmplString := registryIngressExtensionsYamlTemplate
-	tmpl, err := template.New("yaml").Parse(tmplString)
+// readConfigFrom reads the data of a ConfigMap or Secret given as
+// configmap/<name> or secret/<name>, decoding a Secret's values
+func readConfigFrom(namespace, ref string) (map[string]string, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 || (parts[0] != "configmap" && parts[0] != "secret") {
+		return nil, fmt.Errorf("--config-from must be configmap/<name> or secret/<name>, but got: %q", ref)
+	}
+	kind, name := parts[0], parts[1]
 
+	args := []string{"get", kind, name, "-n", namespace, "-o", "json"}
+	res, err := k8s.KubectlTask(args...)
 	if err != nil {
 		return nil, err
 	}
+	if err := k8s.ResultError(res, args...); err != nil {
+		return nil, fmt.Errorf("unable to read %s %s/%s: %w", kind, namespace, name, err)
+	}
+
+	object := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(res.Stdout), &object); err != nil {
+		return nil, fmt.Errorf("unable to parse %s %s/%s: %w", kind, namespace, name, err)
+	}
+
+	values := map[string]string{}
+	for key, value := range object.Data {
+		if kind == "secret" {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode %s from secret %s/%s: %w", key, namespace, name, err)
+			}
+			value = string(decoded)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// releaseNameRegex leaves room within the 63 character limit of a
+// Kubernetes name for the prefixed resource names
+var releaseNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,28}[a-z0-9])?$`)
 
+// newRegInputData builds the template data from the chosen options
+func newRegInputData(opts registryIngressOptions) RegInputData {
 	inputData := RegInputData{
-		IngressDomain:    domain,
-		CertmanagerEmail: email,
-		IngressClass:     ingressClass,
-		Namespace:        namespace,
+		IngressDomain:    opts.Domain,
+		Hosts:            []string{opts.Domain},
+		CertmanagerEmail: opts.Email,
+		IngressClass:     opts.IngressClass,
+		Namespace:        opts.Namespace,
 		IssuerType:       "letsencrypt-prod-issuer",
 		IssuerAPI:        "https://acme-v02.api.letsencrypt.org/directory",
-		NginxMaxBuffer:   "",
+		IngressName:      "docker-registry",
+		TLSSecretName:    "docker-registry",
+		ServiceName:      opts.ServiceName,
+		ServicePort:      opts.ServicePort,
+	}
+	if len(inputData.ServiceName) == 0 {
+		inputData.ServiceName = "docker-registry"
+	}
+	if inputData.ServicePort == 0 {
+		inputData.ServicePort = 5000
 	}
 
-	if staging {
+	if opts.Staging {
 		inputData.IssuerType = "letsencrypt-staging-issuer"
 		inputData.IssuerAPI = "https://acme-staging-v02.api.letsencrypt.org/directory"
 	}
+	if len(opts.ACMEServer) > 0 {
+		inputData.IssuerType = "acme-issuer"
+		inputData.IssuerAPI = opts.ACMEServer
+	}
+	if len(opts.EABKeyID) > 0 {
+		inputData.ExternalAccountBinding = &externalAccountBinding{
+			KeyID:        opts.EABKeyID,
+			KeySecretRef: secretKeySelector{Name: opts.EABSecret, Key: opts.EABSecretKey},
+		}
+	}
+
+	inputData.IssuerName = inputData.IssuerType
+	inputData.IssuerKind = opts.IssuerKind
+	if len(inputData.IssuerKind) == 0 {
+		inputData.IssuerKind = "Issuer"
+	}
+
+	if len(opts.ReleaseName) > 0 {
+		inputData.IngressName = opts.ReleaseName + "-" + inputData.IngressName
+		inputData.IssuerName = opts.ReleaseName + "-" + inputData.IssuerName
+		inputData.TLSSecretName = opts.ReleaseName + "-" + inputData.TLSSecretName
+	}
+	if len(opts.TLSSecret) > 0 {
+		inputData.TLSSecretName = opts.TLSSecret
+	}
 
-	if ingressClass == "nginx" {
-		inputData.NginxMaxBuffer = fmt.Sprintf("    nginx.ingress.kubernetes.io/proxy-body-size: %s", maxSize)
+	inputData.Annotations = map[string]string{
+		"kubernetes.io/ingress.class": opts.IngressClass,
+	}
+	inputData.SkipIssuer = opts.SkipIssuer
+	inputData.Protected = opts.Protect
+	if !opts.SkipIssuer {
+		issuerAnnotation := "cert-manager.io/issuer"
+		if inputData.IssuerKind == "ClusterIssuer" {
+			issuerAnnotation = "cert-manager.io/cluster-issuer"
+		}
+		inputData.Annotations[issuerAnnotation] = inputData.IssuerName
 	}
 
-	var tpl bytes.Buffer
+	if annotate, ok := classAnnotations[opts.IngressClass]; ok {
+		for key, value := range annotate(opts, &inputData) {
+			inputData.Annotations[key] = value
+		}
+	}
 
-	err = tmpl.Execute(&tpl, inputData)
+	// Validated by schemeAnnotations when the flags were read
+	schemeValues, _ := schemeAnnotations(opts.IngressClass, opts.Scheme)
+	for key, value := range schemeValues {
+		inputData.Annotations[key] = value
+	}
 
-	if err != nil {
-		return nil, err
+	inputData.Path = opts.Path
+	if len(inputData.Path) == 0 {
+		inputData.Path = "/"
+	}
+	if len(opts.RewriteTarget) > 0 {
+		inputData.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] = opts.RewriteTarget
+		inputData.Annotations["nginx.ingress.kubernetes.io/use-regex"] = "true"
+	}
+
+	inputData.MinTLSVersion = opts.MinTLSVersion
+	if len(opts.MinTLSVersion) > 0 {
+		inputData.Annotations["nginx.ingress.kubernetes.io/ssl-protocols"] = sslProtocols[opts.MinTLSVersion]
+	}
+
+	if len(opts.SessionAffinity) > 0 {
+		cookieName := opts.SessionCookieName
+		if len(cookieName) == 0 {
+			cookieName = defaultSessionCookieName
+		}
+		inputData.Annotations["nginx.ingress.kubernetes.io/affinity"] = opts.SessionAffinity
+		inputData.Annotations["nginx.ingress.kubernetes.io/session-cookie-name"] = cookieName
+	}
+
+	// Read by cert-manager's ingress-shim, the HTTPProxy template sets
+	// these on the Certificate instead
+	inputData.CertDuration = opts.CertDuration
+	inputData.RenewBefore = opts.RenewBefore
+	if len(opts.CertDuration) > 0 {
+		inputData.Annotations["cert-manager.io/duration"] = opts.CertDuration
+	}
+	if len(opts.RenewBefore) > 0 {
+		inputData.Annotations["cert-manager.io/renew-before"] = opts.RenewBefore
+	}
+
+	for key, value := range opts.Annotations {
+		inputData.Annotations[key] = value
+	}
+	for key, value := range opts.KindAnnotations["Ingress"] {
+		inputData.Annotations[key] = value
+	}
+	inputData.KindAnnotations = opts.KindAnnotations
+	inputData.APIVersions = opts.APIVersions
+
+	for _, host := range opts.Hosts {
+		inputData.Hosts = append(inputData.Hosts, host.Domain)
 	}
 
-	return tpl.Bytes(), nil
+	inputData.Solvers = buildSolvers(opts)
+	inputData.OwnerReferences = opts.OwnerReferences
+
+	if opts.ServiceMonitor {
+		inputData.ServiceMonitor = &serviceMonitor{
+			Name:     inputData.IngressName + "-controller",
+			Selector: opts.MonitorSelector,
+		}
+		if len(inputData.ServiceMonitor.Selector) == 0 {
+			inputData.ServiceMonitor.Selector = defaultMonitorSelector()
+		}
+	}
+
+	if len(opts.CanaryServiceName) > 0 {
+		inputData.Canary = &registryCanary{
+			Name:        inputData.IngressName + "-canary",
+			ServiceName: opts.CanaryServiceName,
+			ServicePort: opts.CanaryServicePort,
+			Annotations: map[string]string{
+				"kubernetes.io/ingress.class":                 opts.IngressClass,
+				"nginx.ingress.kubernetes.io/canary":          "true",
+				"nginx.ingress.kubernetes.io/canary-weight":   strconv.Itoa(opts.CanaryWeight),
+				"nginx.ingress.kubernetes.io/proxy-body-size": opts.MaxSize,
+			},
+		}
+		if len(opts.RewriteTarget) > 0 {
+			inputData.Canary.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] = opts.RewriteTarget
+			inputData.Canary.Annotations["nginx.ingress.kubernetes.io/use-regex"] = "true"
+		}
+		for key, value := range opts.KindAnnotations["Ingress"] {
+			inputData.Canary.Annotations[key] = value
+		}
+	}
+
+	return inputData
+}
+
+// classAnnotations gives the annotations, and any resources they refer
+// to, which set --max-size for each ingress class arkade supports. Add
+// an entry here to support another ingress controller.
+var classAnnotations = map[string]func(opts registryIngressOptions, inputData *RegInputData) map[string]string{
+	"nginx":                     nginxClassAnnotations,
+	"traefik":                   traefikClassAnnotations,
+	"alb":                       cloudClassAnnotations,
+	"gce":                       cloudClassAnnotations,
+	"azure/application-gateway": cloudClassAnnotations,
+}
+
+// knownIngressClasses gives the sorted ingress classes of classAnnotations
+func knownIngressClasses() []string {
+	var classes []string
+	for class := range classAnnotations {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func nginxClassAnnotations(opts registryIngressOptions, inputData *RegInputData) map[string]string {
+	return map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size": opts.MaxSize,
+	}
+}
+
+// traefikClassAnnotations adds a Middleware for the body size limit,
+// since traefik can only set it through a Middleware
+func traefikClassAnnotations(opts registryIngressOptions, inputData *RegInputData) map[string]string {
+	// Validated by normalizeMaxSize when the flags were read
+	maxBytes, err := maxSizeBytes(opts.MaxSize)
+	if err != nil || maxBytes == 0 {
+		return nil
+	}
+
+	inputData.Middleware = &traefikMiddleware{
+		Name:                inputData.IngressName + "-max-size",
+		MaxRequestBodyBytes: maxBytes,
+	}
+	return map[string]string{
+		"traefik.ingress.kubernetes.io/router.middlewares": fmt.Sprintf("%s-%s@kubernetescrd", opts.Namespace, inputData.Middleware.Name),
+	}
+}
+
+// fallbackIngressClass is used when the cluster has no default
+// IngressClass, or it can't be read
+const fallbackIngressClass = "nginx"
+
+// defaultIngressClassAnnotation marks the IngressClass used for Ingresses
+// which don't name one
+const defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+// defaultIngressClass gives the name of the cluster's default
+// IngressClass, or nginx when there is none
+func defaultIngressClass() string {
+	res, err := k8s.KubectlTask("get", "ingressclass", "-o", "json")
+	if err != nil || res.ExitCode != 0 {
+		return fallbackIngressClass
+	}
+
+	classes := struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal([]byte(res.Stdout), &classes); err != nil {
+		return fallbackIngressClass
+	}
+
+	for _, class := range classes.Items {
+		if class.Metadata.Annotations[defaultIngressClassAnnotation] == "true" {
+			return class.Metadata.Name
+		}
+	}
+	return fallbackIngressClass
+}
+
+// trimDomains trims the space around each domain and drops empty ones,
+// such as from a trailing comma
+func trimDomains(domains []string) []string {
+	var trimmed []string
+	for _, domain := range domains {
+		if domain = strings.TrimSpace(domain); len(domain) > 0 {
+			trimmed = append(trimmed, domain)
+		}
+	}
+	return trimmed
 }
 
 const RegistryIngressInfoMsg = `# You will need to ensure that your domain points to your cluster and is
@@ -168,93 +996,19 @@ kubectl describe -n <installed-namespace> Certificate docker-registry
 # It may take a while to be issued by LetsEncrypt, in the meantime a
 # self-signed cert will be installed`
 
-const RegistryIngressInstallMsg = `=======================================================================
+const RegistryIngressInstallHeader = `=======================================================================
 = Docker Registry Ingress and cert-manager Issuer have been installed =
-=======================================================================` +
+=======================================================================`
+
+const RegistryIngressInstallMsg = RegistryIngressInstallHeader +
 	"\n\n" + RegistryIngressInfoMsg + "\n\n" + pkg.ThanksForUsing
 
-// Ingress in extensions/v1beta1 are removed in k8s 1.22+, July 2021
-var registryIngressExtensionsYamlTemplate = `
-apiVersion: extensions/v1beta1
-kind: Ingress
-metadata:
-  name: docker-registry
-  namespace: {{.Namespace}}
-  annotations:
-    cert-manager.io/issuer: {{.IssuerType}}
-    kubernetes.io/ingress.class: {{.IngressClass}}
-{{.NginxMaxBuffer}}
-spec:
-  rules:
-  - host: {{.IngressDomain}}
-    http:
-      paths:
-      - backend:
-          serviceName: docker-registry
-          servicePort: 5000
-        path: /
-  tls:
-  - hosts:
-    - {{.IngressDomain}}
-    secretName: docker-registry
----
-apiVersion: cert-manager.io/v1
-kind: Issuer
-metadata:
-  name: {{.IssuerType}}
-  namespace: {{.Namespace}}
-spec:
-  acme:
-    email: {{.CertmanagerEmail}}
-    server: {{.IssuerAPI}}
-    privateKeySecretRef:
-      name: {{.IssuerType}}
-    solvers:
-    - http01:
-        ingress:
-          class: {{.IngressClass}}`
-
-// Ingress in networking.k8s.io/v1 was added in k8s 1.19+
-// this includes the pathType change added in 1.18
-var registryIngressNetworkingYamlTemplate = `
-apiVersion: networking.k8s.io/v1
-kind: Ingress
-metadata:
-  name: docker-registry
-  namespace: {{.Namespace}}
-  annotations:
-    cert-manager.io/issuer: {{.IssuerType}}
-    kubernetes.io/ingress.class: {{.IngressClass}}
-{{.NginxMaxBuffer}}
-spec:
-  rules:
-  - host: {{.IngressDomain}}
-    http:
-      paths:
-      - path: /
-        pathType: ImplementationSpecific
-        backend:
-          service
-            name: docker-registry
-            port:
-              number: 5000
-  tls:
-  - hosts:
-    - {{.IngressDomain}}
-    secretName: docker-registry
----
-apiVersion: cert-manager.io/v1
-kind: Issuer
-metadata:
-  name: {{.IssuerType}}
-  namespace: {{.Namespace}}
-spec:
-  acme:
-    email: {{.CertmanagerEmail}}
-    server: {{.IssuerAPI}}
-    privateKeySecretRef:
-      name: {{.IssuerType}}
-    solvers:
-    - http01:
-        ingress:
-          class: {{.IngressClass}}`
+// writeInstallMessage writes the installed header, followed by the next
+// steps from RegistryIngressInfoMsg unless they are turned off
+func writeInstallMessage(w io.Writer, showNextSteps bool) {
+	if showNextSteps {
+		fmt.Fprintln(w, pkg.InstallMessage(RegistryIngressInstallMsg))
+		return
+	}
+	fmt.Fprintln(w, pkg.InstallMessage(RegistryIngressInstallHeader+"\n\n"+pkg.ThanksForUsing))
+}
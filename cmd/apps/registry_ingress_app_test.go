@@ -0,0 +1,256 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg"
+	"github.com/alexellis/arkade/pkg/k8s"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeKubectl is a k8s.Runner which returns canned results for kubectl
+// commands, matched on the longest prefix of the joined arguments, and
+// records each call. Queued results are returned in turn before falling
+// back to results.
+type fakeKubectl struct {
+	results map[string]execute.ExecResult
+	queued  map[string][]execute.ExecResult
+	calls   []string
+}
+
+func (f *fakeKubectl) queue(prefix string, results ...execute.ExecResult) {
+	if f.queued == nil {
+		f.queued = map[string][]execute.ExecResult{}
+	}
+	f.queued[prefix] = append(f.queued[prefix], results...)
+}
+
+func (f *fakeKubectl) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	command := strings.Join(task.Args, " ")
+	if task.Command != "kubectl" {
+		command = strings.TrimSpace(task.Command + " " + command)
+	}
+	f.calls = append(f.calls, command)
+
+	match := ""
+	for prefix, queued := range f.queued {
+		if len(queued) > 0 && strings.HasPrefix(command, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if len(match) > 0 {
+		res := f.queued[match][0]
+		f.queued[match] = f.queued[match][1:]
+		return res, nil
+	}
+
+	for prefix := range f.results {
+		if strings.HasPrefix(command, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if len(match) == 0 {
+		return execute.ExecResult{}, nil
+	}
+	return f.results[match], nil
+}
+
+func useFakeKubectl(t *testing.T, results map[string]execute.ExecResult) *fakeKubectl {
+	fake := &fakeKubectl{results: results}
+	previous := k8s.SetRunner(fake)
+	t.Cleanup(func() {
+		k8s.SetRunner(previous)
+	})
+	return fake
+}
+
+// executeRegistryIngress runs the docker-registry-ingress command with
+// the args, returning what it wrote to stdout
+func executeRegistryIngress(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	out, err := ioutil.TempFile(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	command := MakeInstallRegistryIngress()
+	command.SetArgs(args)
+	command.SetOut(out)
+	command.SetErr(out)
+	runErr := command.Execute()
+
+	data, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), runErr
+}
+
+// registryIngressDoc unmarshals the Ingress document of a rendered
+// registry ingress manifest
+func registryIngressDoc(t *testing.T, manifest []byte) map[string]interface{} {
+	t.Helper()
+
+	for _, doc := range k8s.SplitManifest(manifest) {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			t.Fatalf("rendered YAML is invalid: %s\n%s", err, doc)
+		}
+		if obj["kind"] == "Ingress" {
+			return obj
+		}
+	}
+
+	t.Fatalf("no Ingress found in:\n%s", manifest)
+	return nil
+}
+
+func Test_readConfigFrom_ConfigMap(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get configmap registry-settings -n registry -o json": {
+			Stdout: `{"kind":"ConfigMap","data":{"domain":"registry.example.com","email":"admin@example.com"}}`,
+		},
+	})
+
+	values, err := readConfigFrom("registry", "configmap/registry-settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["domain"] != "registry.example.com" || values["email"] != "admin@example.com" {
+		t.Errorf("want the domain and email from the ConfigMap, got: %v", values)
+	}
+}
+
+func Test_readConfigFrom_Secret(t *testing.T) {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get secret registry-settings -n registry -o json": {
+			Stdout: `{"kind":"Secret","data":{"domain":"` + encode("registry.example.com") + `","email":"` + encode("admin@example.com\n") + `"}}`,
+		},
+	})
+
+	values, err := readConfigFrom("registry", "secret/registry-settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["domain"] != "registry.example.com" || values["email"] != "admin@example.com" {
+		t.Errorf("want the decoded domain and email from the Secret, got: %v", values)
+	}
+}
+
+func Test_readConfigFrom_InvalidRef(t *testing.T) {
+	for _, ref := range []string{"registry-settings", "deployment/registry", "configmap/"} {
+		if _, err := readConfigFrom("registry", ref); err == nil {
+			t.Errorf("want %q to be rejected", ref)
+		}
+	}
+}
+
+func Test_writeInstallMessage_WithoutNextSteps(t *testing.T) {
+	var out bytes.Buffer
+	writeInstallMessage(&out, false)
+
+	if !strings.Contains(out.String(), RegistryIngressInstallHeader) {
+		t.Errorf("want the header, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), RegistryIngressInfoMsg) {
+		t.Errorf("want no next steps, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	writeInstallMessage(&out, true)
+	if !strings.Contains(out.String(), RegistryIngressInstallHeader) || !strings.Contains(out.String(), RegistryIngressInfoMsg) {
+		t.Errorf("want the header and next steps, got:\n%s", out.String())
+	}
+}
+
+func Test_writeInstallMessage_Quiet(t *testing.T) {
+	pkg.SetQuiet(true)
+	t.Cleanup(func() {
+		pkg.SetQuiet(false)
+	})
+
+	var out bytes.Buffer
+	writeInstallMessage(&out, true)
+
+	if strings.Contains(out.String(), "===") || strings.Contains(out.String(), pkg.ThanksForUsing) {
+		t.Errorf("want no banner, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), RegistryIngressInfoMsg) {
+		t.Errorf("want the next steps, got:\n%s", out.String())
+	}
+}
+
+func Test_defaultIngressClass(t *testing.T) {
+	cases := []struct {
+		name   string
+		result execute.ExecResult
+		want   string
+	}{
+		{
+			name: "default-marked class is chosen",
+			result: execute.ExecResult{Stdout: `{"items":[
+				{"metadata":{"name":"nginx"}},
+				{"metadata":{"name":"traefik","annotations":{"ingressclass.kubernetes.io/is-default-class":"true"}}}]}`},
+			want: "traefik",
+		},
+		{
+			name:   "no default falls back to nginx",
+			result: execute.ExecResult{Stdout: `{"items":[{"metadata":{"name":"traefik"}}]}`},
+			want:   "nginx",
+		},
+		{
+			name:   "kubectl failure falls back to nginx",
+			result: execute.ExecResult{ExitCode: 1, Stderr: `the server doesn't have a resource type "ingressclass"`},
+			want:   "nginx",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useFakeKubectl(t, map[string]execute.ExecResult{
+				"get ingressclass -o json": tc.result,
+			})
+
+			if got := defaultIngressClass(); got != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_newRegInputData_TLSSecretIsNotPrefixed(t *testing.T) {
+	inputData := newRegInputData(registryIngressOptions{
+		Domain:      "registry.example.com",
+		ReleaseName: "team-a",
+		TLSSecret:   "wildcard-example-com",
+	})
+
+	if inputData.TLSSecretName != "wildcard-example-com" {
+		t.Errorf("want the --tls-secret as given, got: %s", inputData.TLSSecretName)
+	}
+	if inputData.Annotations["cert-manager.io/issuer"] != "team-a-letsencrypt-prod-issuer" {
+		t.Errorf("want the issuer annotation kept without --skip-issuer, got: %v", inputData.Annotations)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// errTransientKubectl marks a kubectl command which failed for a reason
+// that is usually temporary
+var errTransientKubectl = errors.New("kubectl failed with a transient error")
+
+func isTransientError(err error) bool {
+	return errors.Is(err, errTransientKubectl)
+}
+
+// applyOptions control how a generated manifest is applied
+type applyOptions struct {
+	// ServerSide uses server-side apply, with arkade as the field manager
+	ServerSide bool
+
+	// KeepManifests leaves the manifest in its temporary directory
+	KeepManifests bool
+
+	// Kustomize is an overlay directory, applied with kubectl apply -k
+	// over a base of the rendered manifest
+	Kustomize string
+}
+
+// args gives the extra arguments for kubectl apply
+func (o applyOptions) args() []string {
+	if o.ServerSide {
+		return []string{"--server-side", "--field-manager=arkade"}
+	}
+	return nil
+}
+
+// applyConflictError explains a server-side apply which failed because
+// another field manager owns some of the fields, or gives nil for any
+// other result
+func applyConflictError(res execute.ExecResult) error {
+	if res.ExitCode == 0 || !strings.Contains(res.Stderr, "Apply failed with") {
+		return nil
+	}
+	return fmt.Errorf(`server-side apply conflicts with fields owned by another field manager,
+such as a GitOps tool. Change the fields there, or take ownership of them by
+running kubectl apply with --server-side --force-conflicts:
+%s`, strings.TrimSpace(res.Stderr))
+}
+
+// applyWithRetry applies the manifest, retrying from budget when kubectl
+// fails for reasons such as the cert-manager webhook not being ready yet
+// or the API server being briefly unreachable. The result of the final
+// attempt is returned for the caller to check the exit code, along with
+// the resources created by any of the attempts.
+func applyWithRetry(file string, budget *retry.Budget, applyArgs ...string) (execute.ExecResult, []string, error) {
+	return applySourceWithRetry([]string{"-f", file}, budget, applyArgs...)
+}
+
+// applySourceWithRetry is applyWithRetry for a source given as kubectl
+// apply's arguments, such as -k for a kustomization
+func applySourceWithRetry(source []string, budget *retry.Budget, applyArgs ...string) (execute.ExecResult, []string, error) {
+	var res execute.ExecResult
+	var created []string
+
+	args := append(append([]string{"apply"}, source...), applyArgs...)
+	err := budget.Do(context.Background(), isTransientError, func() error {
+		var err error
+		res, err = k8s.KubectlTask(args...)
+		if err != nil {
+			return err
+		}
+		created = append(created, parseCreatedResources(res.Stdout)...)
+
+		if res.ExitCode != 0 && isTransientKubectlError(res.Stderr) {
+			log.Printf("Retrying apply, kubectl error: %s", strings.TrimSpace(res.Stderr))
+			return errTransientKubectl
+		}
+		return nil
+	})
+
+	if errors.Is(err, errTransientKubectl) {
+		return res, created, nil
+	}
+	return res, created, err
+}
+
+// parseCreatedResources reads the resources kubectl apply reports as
+// created, i.e. "issuer.cert-manager.io/letsencrypt-prod-issuer created",
+// resources which were configured or unchanged existed before the apply
+func parseCreatedResources(stdout string) []string {
+	var created []string
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "created" {
+			created = append(created, fields[0])
+		}
+	}
+	return created
+}
+
+// parseAppliedResources reads every resource kubectl apply reports on,
+// whether it was created, configured, unchanged or server-side applied
+func parseAppliedResources(stdout string) []string {
+	applied := []string{}
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.Contains(fields[0], "/") {
+			applied = append(applied, fields[0])
+		}
+	}
+	return applied
+}
+
+// rollbackCreated deletes the resources created by a failed apply, in
+// the reverse order to which they were created. applyErr is returned,
+// noting any resources which could not be deleted.
+func rollbackCreated(w io.Writer, namespace string, created []string, applyErr error) error {
+	var failed []string
+	for i := len(created) - 1; i >= 0; i-- {
+		res, err := k8s.KubectlTask("delete", created[i], "-n", namespace, "--ignore-not-found")
+		if err != nil || res.ExitCode != 0 {
+			failed = append(failed, created[i])
+			continue
+		}
+		fmt.Fprintf(w, "Rolled back %s\n", created[i])
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w, and unable to roll back: %s", applyErr, strings.Join(failed, ", "))
+	}
+	return applyErr
+}
+
+// isNotFoundOnly reports whether every line of kubectl's stderr is for a
+// resource, or the API of one, not being found
+func isNotFoundOnly(stderr string) bool {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	for _, line := range lines {
+		if !strings.Contains(line, "NotFound") && !strings.Contains(line, "not found") {
+			return false
+		}
+	}
+	return len(lines) > 0 && len(lines[0]) > 0
+}
+
+func isTransientKubectlError(stderr string) bool {
+	transient := []string{
+		"connection refused",
+		"i/o timeout",
+		"TLS handshake timeout",
+		"failed calling webhook",
+		"the server is currently unable to handle the request",
+		"etcdserver: request timed out",
+	}
+
+	for _, msg := range transient {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressFields are the parts of an Ingress compared by --skip-if-exists,
+// from either the extensions/v1beta1 or networking.k8s.io/v1 API
+type ingressFields struct {
+	Metadata struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Rules []struct {
+			Host string `json:"host"`
+			HTTP struct {
+				Paths []struct {
+					Path    string `json:"path"`
+					Backend struct {
+						ServiceName string      `json:"serviceName"`
+						ServicePort interface{} `json:"servicePort"`
+						Service     struct {
+							Name string `json:"name"`
+							Port struct {
+								Number int    `json:"number"`
+								Name   string `json:"name"`
+							} `json:"port"`
+						} `json:"service"`
+					} `json:"backend"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+		TLS []struct {
+			Hosts      []string `json:"hosts"`
+			SecretName string   `json:"secretName"`
+		} `json:"tls"`
+	} `json:"spec"`
+}
+
+// summary gives a comparable description of the routing and TLS
+func (f ingressFields) summary() []string {
+	var out []string
+	for _, rule := range f.Spec.Rules {
+		for _, p := range rule.HTTP.Paths {
+			service, port := p.Backend.ServiceName, fmt.Sprint(p.Backend.ServicePort)
+			if len(p.Backend.Service.Name) > 0 {
+				service, port = p.Backend.Service.Name, strconv.Itoa(p.Backend.Service.Port.Number)
+				if len(p.Backend.Service.Port.Name) > 0 {
+					port = p.Backend.Service.Port.Name
+				}
+			}
+			out = append(out, fmt.Sprintf("rule %s%s -> %s:%s", rule.Host, p.Path, service, port))
+		}
+	}
+	for _, tls := range f.Spec.TLS {
+		out = append(out, fmt.Sprintf("tls %s -> %s", strings.Join(tls.Hosts, ","), tls.SecretName))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ingressMatches compares a rendered Ingress with the live one, the live
+// Ingress may have extra annotations such as those added by kubectl
+func ingressMatches(rendered, live []byte) (bool, error) {
+	var want, got ingressFields
+	if err := yaml.Unmarshal(rendered, &want); err != nil {
+		return false, err
+	}
+	if err := yaml.Unmarshal(live, &got); err != nil {
+		return false, err
+	}
+
+	if strings.Join(want.summary(), "\n") != strings.Join(got.summary(), "\n") {
+		return false, nil
+	}
+
+	for key, value := range want.Metadata.Annotations {
+		if got.Metadata.Annotations[key] != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// liveIngressMatches fetches the Ingress and compares it with the one in
+// the rendered manifest, a missing Ingress does not match
+func liveIngressMatches(namespace, name string, manifest []byte) (bool, error) {
+	var rendered []byte
+	objects, err := k8s.ParseObjects(manifest)
+	if err != nil {
+		return false, err
+	}
+	for i, doc := range k8s.SplitManifest(manifest) {
+		if objects[i].Kind == "Ingress" && objects[i].Name == name {
+			rendered = doc
+		}
+	}
+	if rendered == nil {
+		return false, nil
+	}
+
+	res, err := k8s.KubectlTask("get", "ingress", name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return false, err
+	}
+	if res.ExitCode != 0 {
+		return false, nil
+	}
+
+	return ingressMatches(rendered, []byte(res.Stdout))
+}
+
+// registryHookEnv gives the environment for --pre-hook and --post-hook
+func registryHookEnv(inputData RegInputData) []string {
+	return []string{
+		"ARKADE_DOMAIN=" + inputData.IngressDomain,
+		"ARKADE_NAMESPACE=" + inputData.Namespace,
+		"ARKADE_INGRESS_NAME=" + inputData.IngressName,
+		"ARKADE_TLS_SECRET=" + inputData.TLSSecretName,
+	}
+}
+
+// runWithHooks runs the pre hook, then apply, then the post hook only
+// when apply succeeded. Empty hooks are skipped.
+func runWithHooks(preHook, postHook string, env []string, apply func() error) error {
+	if err := runHook("pre-hook", preHook, env); err != nil {
+		return err
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+
+	return runHook("post-hook", postHook, env)
+}
+
+func runHook(name, hook string, env []string) error {
+	if len(hook) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Running %s: %s\n", name, hook)
+	task := execute.ExecTask{
+		Command:     "/bin/sh",
+		Args:        []string{"-c", hook},
+		Env:         append(os.Environ(), env...),
+		StreamStdio: true,
+	}
+
+	// Hooks go through the Runner, so that they are in the --audit-log
+	res, err := k8s.Run(task)
+	if err != nil {
+		return fmt.Errorf("unable to run %s: %w", name, err)
+	}
+	if res.ExitCode != 0 {
+		return fmt.Errorf("%s exited with code %d: %s", name, res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_runWithHooks_RunsInOrderWithEnv(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "hooks.log")
+	env := registryHookEnv(newRegInputData(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Namespace:    "registry",
+		IngressClass: "nginx",
+	}))
+
+	preHook := `echo "pre $ARKADE_DOMAIN $ARKADE_NAMESPACE" >> ` + logFile
+	postHook := `echo "post $ARKADE_INGRESS_NAME $ARKADE_TLS_SECRET" >> ` + logFile
+
+	err := runWithHooks(preHook, postHook, env, func() error {
+		f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.WriteString("apply\n")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pre registry.example.com registry\napply\npost docker-registry docker-registry\n"
+	if string(got) != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, string(got))
+	}
+}
+
+func Test_runWithHooks_SkipsPostHookOnFailure(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "hooks.log")
+
+	err := runWithHooks("", "echo post >> "+logFile, nil, func() error {
+		return errors.New("apply failed")
+	})
+	if err == nil || err.Error() != "apply failed" {
+		t.Fatalf("want the apply error, got: %v", err)
+	}
+
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Errorf("want the post hook not to run")
+	}
+}
+
+func Test_runWithHooks_FailedPreHookStopsApply(t *testing.T) {
+	applied := false
+	err := runWithHooks("exit 3", "", nil, func() error {
+		applied = true
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "pre-hook exited with code 3") {
+		t.Fatalf("want the pre-hook error, got: %v", err)
+	}
+	if applied {
+		t.Errorf("want apply to be skipped")
+	}
+}
+
+func Test_runHook_IsAudited(t *testing.T) {
+	var audit bytes.Buffer
+	previous := k8s.SetRunner(k8s.NewAuditRunner(k8s.ExecRunner{}, &audit))
+	defer k8s.SetRunner(previous)
+
+	if err := runHook("pre-hook", "exit 0", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry k8s.AuditEntry
+	if err := json.Unmarshal(audit.Bytes(), &entry); err != nil {
+		t.Fatalf("want an audit entry for the hook, got: %q, error: %s", audit.String(), err)
+	}
+	if entry.Command != "/bin/sh" || strings.Join(entry.Args, " ") != "-c exit 0" {
+		t.Errorf("want the hook in the audit log, got: %+v", entry)
+	}
+}
+
+func Test_liveIngressMatches(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+	manifest, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As returned by the API server in networking.k8s.io/v1 with an
+	// annotation added by kubectl
+	live := `{"apiVersion":"networking.k8s.io/v1","kind":"Ingress",
+"metadata":{"name":"docker-registry","namespace":"registry","annotations":{
+  "cert-manager.io/issuer":"letsencrypt-prod-issuer",
+  "kubernetes.io/ingress.class":"nginx",
+  "nginx.ingress.kubernetes.io/proxy-body-size":"200m",
+  "kubectl.kubernetes.io/last-applied-configuration":"{}"}},
+"spec":{"rules":[{"host":"registry.example.com","http":{"paths":[{"path":"/","pathType":"ImplementationSpecific",
+  "backend":{"service":{"name":"docker-registry","port":{"number":5000}}}}]}}],
+"tls":[{"hosts":["registry.example.com"],"secretName":"docker-registry"}]}}`
+
+	cases := []struct {
+		name string
+		live execute.ExecResult
+		want bool
+	}{
+		{name: "matching", live: execute.ExecResult{Stdout: live}, want: true},
+		{name: "different max size", live: execute.ExecResult{Stdout: strings.Replace(live, `"200m"`, `"1g"`, 1)}, want: false},
+		{name: "different host", live: execute.ExecResult{Stdout: strings.Replace(live, `"host":"registry.example.com"`, `"host":"old.example.com"`, 1)}, want: false},
+		{name: "missing", live: execute.ExecResult{ExitCode: 1, Stderr: "NotFound"}, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"get ingress docker-registry -n registry -o json": tc.live,
+			})
+
+			got, err := liveIngressMatches("registry", "docker-registry", manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want match: %t, got: %t", tc.want, got)
+			}
+
+			for _, call := range fake.calls {
+				if strings.HasPrefix(call, "apply") {
+					t.Errorf("want nothing to be applied, got: %s", call)
+				}
+			}
+		})
+	}
+}
+
+func Test_retryBudget_SharedByApplyAndWait(t *testing.T) {
+	refused := execute.ExecResult{ExitCode: 1, Stderr: "The connection to the server localhost:6443 was refused - did you specify the right host or port? dial tcp: connection refused"}
+
+	cases := []struct {
+		name    string
+		retries int
+		wantErr bool
+	}{
+		{name: "budget covers apply and wait", retries: 2},
+		{name: "apply spends the budget for the wait", retries: 1, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"apply -f": {Stdout: "issuer.cert-manager.io/letsencrypt-prod-issuer created"},
+				"get issuer letsencrypt-prod-issuer -n registry": {Stdout: "True"},
+			})
+			fake.queue("apply -f", refused)
+			fake.queue("get issuer letsencrypt-prod-issuer -n registry", refused)
+
+			budget := retry.NewBudget(tc.retries, time.Millisecond)
+
+			res, _, err := applyWithRetry("registry.yaml", budget)
+			if err != nil || res.ExitCode != 0 {
+				t.Fatalf("want the apply to be retried, got: %v %+v", err, res)
+			}
+
+			waits := []readyWait{{Kind: "Issuer", Name: "letsencrypt-prod-issuer", Timeout: time.Second}}
+			err = waitForResources(context.Background(), ioutil.Discard, "registry", waits, newPollPolicy(time.Millisecond, time.Millisecond), budget, nil)
+			if tc.wantErr {
+				if err == nil || !errors.Is(err, errTransientKubectl) {
+					t.Fatalf("want the wait to fail with the transient error, got: %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if budget.Remaining() != 0 {
+				t.Errorf("want the budget to be spent, got %d remaining", budget.Remaining())
+			}
+		})
+	}
+}
+
+func Test_rollbackCreated_DeletesResourcesCreatedBeforeFailure(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"apply -f": {
+			ExitCode: 1,
+			Stdout:   "issuer.cert-manager.io/letsencrypt-prod-issuer created\n",
+			Stderr:   `Error from server (Invalid): error when creating "registry.yaml": Ingress.networking.k8s.io "docker-registry" is invalid`,
+		},
+	})
+
+	res, created, err := applyWithRetry("registry.yaml", retry.NewBudget(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode == 0 {
+		t.Fatal("want the apply to fail on the second resource")
+	}
+
+	applyErr := errors.New("unable to apply")
+	var out bytes.Buffer
+	if err := rollbackCreated(&out, "registry", created, applyErr); !errors.Is(err, applyErr) {
+		t.Fatalf("want the apply error, got: %v", err)
+	}
+
+	wantCall := "delete issuer.cert-manager.io/letsencrypt-prod-issuer -n registry --ignore-not-found"
+	deleted := 0
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "delete") {
+			deleted++
+			if call != wantCall {
+				t.Errorf("want only the Issuer deleted, got: %s", call)
+			}
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("want one delete, got calls: %v", fake.calls)
+	}
+	if !strings.Contains(out.String(), "Rolled back issuer.cert-manager.io/letsencrypt-prod-issuer") {
+		t.Errorf("want the rollback reported, got:\n%s", out.String())
+	}
+}
+
+func Test_parseCreatedResources(t *testing.T) {
+	stdout := `issuer.cert-manager.io/letsencrypt-prod-issuer created
+ingress.networking.k8s.io/docker-registry configured
+certificate.cert-manager.io/docker-registry unchanged
+`
+	got := parseCreatedResources(stdout)
+	if len(got) != 1 || got[0] != "issuer.cert-manager.io/letsencrypt-prod-issuer" {
+		t.Errorf("want only the created Issuer, got: %v", got)
+	}
+}
+
+func Test_applyWithRetry_ServerSide(t *testing.T) {
+	conflict := `error: Apply failed with 1 conflict: conflict with "flux" using networking.k8s.io/v1: .metadata.annotations.nginx.ingress.kubernetes.io/proxy-body-size`
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"apply -f registry.yaml --server-side --field-manager=arkade": {ExitCode: 1, Stderr: conflict},
+	})
+
+	apply := applyOptions{ServerSide: true}
+	res, _, err := applyWithRetry("registry.yaml", retry.NewBudget(0, 0), apply.args()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "apply -f registry.yaml --server-side --field-manager=arkade"; len(fake.calls) != 1 || fake.calls[0] != want {
+		t.Errorf("want call: %q, got: %v", want, fake.calls)
+	}
+
+	conflictErr := applyConflictError(res)
+	if conflictErr == nil {
+		t.Fatal("want a conflict error")
+	}
+	if !strings.Contains(conflictErr.Error(), `conflict with "flux"`) || strings.Contains(conflictErr.Error(), "Have you got the Registry running") {
+		t.Errorf("want kubectl's conflict message without the generic hint, got: %s", conflictErr)
+	}
+
+	if err := applyConflictError(execute.ExecResult{ExitCode: 1, Stderr: "connection refused"}); err != nil {
+		t.Errorf("want no conflict error for other failures, got: %s", err)
+	}
+	if args := (applyOptions{}).args(); len(args) != 0 {
+		t.Errorf("want client-side apply by default, got: %v", args)
+	}
+}
+
+func Test_applySourceWithRetry_Kustomize(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"apply -k /tmp/run/overlay": {Stdout: "ingress.networking.k8s.io/docker-registry created\n"},
+	})
+
+	res, created, err := applySourceWithRetry([]string{"-k", "/tmp/run/overlay"}, retry.NewBudget(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 || len(created) != 1 {
+		t.Errorf("want the ingress created, got: %v, %v", res, created)
+	}
+	if want := "apply -k /tmp/run/overlay"; len(fake.calls) != 1 || fake.calls[0] != want {
+		t.Errorf("want call: %q, got: %v", want, fake.calls)
+	}
+}
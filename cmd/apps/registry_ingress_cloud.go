@@ -0,0 +1,101 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/spf13/pflag"
+)
+
+// schemeAnnotations gives the annotation which makes the load balancer
+// of a cloud ingress class internal or internet-facing. Controllers such
+// as nginx take the scheme from their own Service, so are not supported.
+func schemeAnnotations(ingressClass, scheme string) (map[string]string, error) {
+	if len(scheme) == 0 {
+		return nil, nil
+	}
+
+	if scheme != "internal" && scheme != "internet-facing" {
+		return nil, fmt.Errorf("--scheme must be internal or internet-facing, but got: %q", scheme)
+	}
+	internal := scheme == "internal"
+
+	switch ingressClass {
+	case "alb":
+		return map[string]string{"alb.ingress.kubernetes.io/scheme": scheme}, nil
+	case "gce":
+		loadBalancerType := "External"
+		if internal {
+			loadBalancerType = "Internal"
+		}
+		return map[string]string{"networking.gke.io/load-balancer-type": loadBalancerType}, nil
+	case "azure/application-gateway":
+		return map[string]string{"appgw.ingress.kubernetes.io/use-private-ip": strconv.FormatBool(internal)}, nil
+	}
+
+	return nil, fmt.Errorf("--scheme is not supported for the %s ingress class, use one of: alb, gce, azure/application-gateway, or set the scheme on the ingress controller's Service", ingressClass)
+}
+
+// cloudIngressDefaults are the ingress class and load balancer scheme
+// which --cloud picks on each provider, internet-facing so that Let's
+// Encrypt can reach the HTTP01 challenge
+var cloudIngressDefaults = map[k8s.Cloud]struct{ IngressClass, Scheme string }{
+	k8s.CloudAWS:   {IngressClass: "alb", Scheme: "internet-facing"},
+	k8s.CloudGCP:   {IngressClass: "gce", Scheme: "internet-facing"},
+	k8s.CloudAzure: {IngressClass: "azure/application-gateway", Scheme: "internet-facing"},
+}
+
+// applyCloudDefaults tunes the ingress class and scheme for --cloud,
+// detecting the cloud from the nodes for auto. Flags which were set are
+// kept, and a cloud which can't be detected is only a warning.
+func applyCloudDefaults(w io.Writer, flags *pflag.FlagSet, ingressClass, scheme string, warnings *installWarnings) (string, string, error) {
+	value, _ := flags.GetString("cloud")
+	if len(value) == 0 {
+		return ingressClass, scheme, nil
+	}
+
+	cloud, err := k8s.ParseCloud(value)
+	if err != nil {
+		return ingressClass, scheme, fmt.Errorf("--cloud: %w", err)
+	}
+
+	if cloud == k8s.CloudAuto {
+		cloud, err = k8s.DetectCloud()
+		if err != nil {
+			warnings.Add("unable to detect the cloud from the cluster's nodes: %s", err)
+			return ingressClass, scheme, nil
+		}
+		if len(cloud) == 0 {
+			warnings.Add("--cloud auto did not recognise the cluster's nodes as aws, gcp or azure, the usual defaults are used")
+			return ingressClass, scheme, nil
+		}
+	}
+
+	defaults := cloudIngressDefaults[cloud]
+	if !flags.Changed("ingress-class") {
+		ingressClass = defaults.IngressClass
+	}
+	// --scheme is only valid with the cloud's own ingress class
+	if !flags.Changed("scheme") && ingressClass == defaults.IngressClass {
+		scheme = defaults.Scheme
+	}
+
+	if len(scheme) > 0 {
+		fmt.Fprintf(w, "Using the defaults for %s, ingress class: %s, scheme: %s\n", cloud, ingressClass, scheme)
+	} else {
+		fmt.Fprintf(w, "Using the defaults for %s, ingress class: %s\n", cloud, ingressClass)
+	}
+	return ingressClass, scheme, nil
+}
+
+// cloudClassAnnotations is for the ingress classes which --cloud picks,
+// their load balancers have no limit on the size of a request body for
+// --max-size to raise, and --scheme is set by schemeAnnotations
+func cloudClassAnnotations(opts registryIngressOptions, inputData *RegInputData) map[string]string {
+	return nil
+}
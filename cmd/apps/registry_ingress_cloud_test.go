@@ -0,0 +1,141 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_schemeAnnotations(t *testing.T) {
+	cases := []struct {
+		ingressClass string
+		scheme       string
+		wantKey      string
+		wantValue    string
+	}{
+		{ingressClass: "alb", scheme: "internal", wantKey: "alb.ingress.kubernetes.io/scheme", wantValue: "internal"},
+		{ingressClass: "alb", scheme: "internet-facing", wantKey: "alb.ingress.kubernetes.io/scheme", wantValue: "internet-facing"},
+		{ingressClass: "gce", scheme: "internal", wantKey: "networking.gke.io/load-balancer-type", wantValue: "Internal"},
+		{ingressClass: "gce", scheme: "internet-facing", wantKey: "networking.gke.io/load-balancer-type", wantValue: "External"},
+		{ingressClass: "azure/application-gateway", scheme: "internal", wantKey: "appgw.ingress.kubernetes.io/use-private-ip", wantValue: "true"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ingressClass+" "+tc.scheme, func(t *testing.T) {
+			opts := registryIngressOptions{
+				Domain:       "registry.example.com",
+				Email:        "admin@example.com",
+				IngressClass: tc.ingressClass,
+				Namespace:    "registry",
+				Scheme:       tc.scheme,
+			}
+			if _, err := schemeAnnotations(opts.IngressClass, opts.Scheme); err != nil {
+				t.Fatal(err)
+			}
+
+			annotations := newRegInputData(opts).Annotations
+			if got := annotations[tc.wantKey]; got != tc.wantValue {
+				t.Errorf("want %s: %s, got annotations: %v", tc.wantKey, tc.wantValue, annotations)
+			}
+		})
+	}
+}
+
+func Test_schemeAnnotations_Invalid(t *testing.T) {
+	if _, err := schemeAnnotations("alb", "private"); err == nil {
+		t.Error("want an unknown scheme to be rejected")
+	}
+	if _, err := schemeAnnotations("nginx", "internal"); err == nil {
+		t.Error("want the nginx ingress class to be rejected")
+	}
+	if annotations, err := schemeAnnotations("nginx", ""); err != nil || len(annotations) > 0 {
+		t.Errorf("want no annotations without --scheme, got: %v %v", annotations, err)
+	}
+}
+
+func Test_applyCloudDefaults(t *testing.T) {
+	eksNodes := `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"m5.large","topology.kubernetes.io/region":"us-east-1"}},"spec":{"providerID":"aws:///us-east-1a/i-0abc123"}}]}`
+	laptopNodes := `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"k3s"}},"spec":{"providerID":"k3s://laptop"}}]}`
+
+	cases := []struct {
+		name         string
+		args         []string
+		nodes        string
+		wantClass    string
+		wantScheme   string
+		wantWarnings int
+	}{
+		{name: "not set", wantClass: "nginx"},
+		{name: "auto on eks", args: []string{"--cloud", "auto"}, nodes: eksNodes, wantClass: "alb", wantScheme: "internet-facing"},
+		{name: "gcp", args: []string{"--cloud", "gcp"}, wantClass: "gce", wantScheme: "internet-facing"},
+		{name: "azure keeps the given scheme", args: []string{"--cloud", "azure", "--scheme", "internal"}, wantClass: "azure/application-gateway", wantScheme: "internal"},
+		{name: "aws keeps the given ingress class", args: []string{"--cloud", "aws", "--ingress-class", "nginx"}, wantClass: "nginx"},
+		{name: "auto not recognised", args: []string{"--cloud", "auto"}, nodes: laptopNodes, wantClass: "nginx", wantWarnings: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"get nodes": {Stdout: tc.nodes},
+			})
+
+			command := MakeInstallRegistryIngress()
+			if err := command.ParseFlags(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			scheme, _ := command.Flags().GetString("scheme")
+
+			warnings := &installWarnings{}
+			var out bytes.Buffer
+			ingressClass, scheme, err := applyCloudDefaults(&out, command.Flags(), "nginx", scheme, warnings)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ingressClass != tc.wantClass || scheme != tc.wantScheme {
+				t.Errorf("want ingress class %q and scheme %q, got: %q and %q", tc.wantClass, tc.wantScheme, ingressClass, scheme)
+			}
+			if len(warnings.Warnings) != tc.wantWarnings {
+				t.Errorf("want %d warnings, got: %v", tc.wantWarnings, warnings.Warnings)
+			}
+			if len(tc.nodes) == 0 && len(fake.calls) > 0 {
+				t.Errorf("want the nodes to be read only for auto, got: %v", fake.calls)
+			}
+		})
+	}
+}
+
+func Test_applyCloudDefaults_Invalid(t *testing.T) {
+	command := MakeInstallRegistryIngress()
+	if err := command.ParseFlags([]string{"--cloud", "digitalocean"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := applyCloudDefaults(ioutil.Discard, command.Flags(), "nginx", "", &installWarnings{}); err == nil {
+		t.Error("want an unknown cloud to be rejected")
+	}
+}
+
+func Test_Cloud_StrictHasNoUnknownClassWarning(t *testing.T) {
+	for _, cloud := range []string{"aws", "gcp", "azure"} {
+		t.Run(cloud, func(t *testing.T) {
+			useFakeKubectl(t, map[string]execute.ExecResult{
+				"api-versions": {Stdout: "networking.k8s.io/v1\ncert-manager.io/v1\n"},
+			})
+
+			out, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+				"--cloud", cloud, "--strict", "--dry-run")
+			if err != nil {
+				t.Fatalf("want no warnings for the ingress class of --cloud %s, got: %s", cloud, err)
+			}
+			if strings.Contains(out, "unknown ingress class") {
+				t.Errorf("want no unknown ingress class warning, got:\n%s", out)
+			}
+		})
+	}
+}
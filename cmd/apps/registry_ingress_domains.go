@@ -0,0 +1,189 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	"github.com/spf13/pflag"
+)
+
+// domainsFileEntry is a line of --domains-file
+type domainsFileEntry struct {
+	Line      int
+	Domain    string
+	Namespace string
+	Email     string
+}
+
+// parseDomainsFile reads domain,namespace,email lines, skipping blank
+// lines and comments starting with "#"
+func parseDomainsFile(r io.Reader, defaultEmail string) ([]domainsFileEntry, error) {
+	var entries []domainsFileEntry
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: want domain,namespace,email, got: %q", line, text)
+		}
+
+		entry := domainsFileEntry{
+			Line:      line,
+			Domain:    strings.TrimSpace(fields[0]),
+			Namespace: strings.TrimSpace(fields[1]),
+			Email:     defaultEmail,
+		}
+		if len(fields) == 3 && len(strings.TrimSpace(fields[2])) > 0 {
+			entry.Email = strings.TrimSpace(fields[2])
+		}
+
+		if len(entry.Domain) == 0 || len(entry.Namespace) == 0 || len(entry.Email) == 0 {
+			return nil, fmt.Errorf("line %d: domain, namespace and email must be set, got: %q", line, text)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no domains found")
+	}
+	return entries, nil
+}
+
+// domainsFileUnsupportedFlags render, check or follow up a single
+// install, so they are refused with --domains-file instead of ignored
+var domainsFileUnsupportedFlags = []string{
+	"dry-run", "print-apply-order", "explain", "render-with-comments", "output",
+	"uninstall", "delete-tls-secret", "skip-if-exists", "check-existing-cert",
+	"pre-hook", "post-hook", "rollback-on-failure",
+	"wait", "watch-cert-manager", "verify-tls", "report",
+}
+
+// validateDomainsFileFlags rejects the flags which --domains-file does
+// not support
+func validateDomainsFileFlags(flags *pflag.FlagSet) error {
+	for _, name := range domainsFileUnsupportedFlags {
+		if flags.Changed(name) {
+			return fmt.Errorf("--%s is not supported with --domains-file", name)
+		}
+	}
+	return nil
+}
+
+// domainOptions gives the options for a line of --domains-file, with
+// the rest of the options shared by all lines
+func domainOptions(entry domainsFileEntry, opts registryIngressOptions) registryIngressOptions {
+	opts.Domain = entry.Domain
+	opts.Namespace = entry.Namespace
+	opts.Email = entry.Email
+	return opts
+}
+
+// renderDomain renders the sorted manifest for a line of --domains-file
+func renderDomain(entry domainsFileEntry, opts registryIngressOptions, hasNetworking bool) ([]byte, []k8s.Object, error) {
+	yamlBytes, err := buildRegistryYAML(domainOptions(entry, opts), hasNetworking)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k8s.SortManifest(yamlBytes)
+}
+
+// preflightDomains makes the checks of a single install for every line
+// of --domains-file before any line is applied, so that an error, or a
+// warning with --strict, stops the whole batch. Nothing is created, a
+// missing namespace is created by applyDomain with --create-namespace.
+func preflightDomains(entries []domainsFileEntry, opts registryIngressOptions, hasNetworking bool, warnings *installWarnings, createNamespace bool, budget *retry.Budget) error {
+	for _, entry := range entries {
+		if _, err := checkNamespace(entry.Namespace, createNamespace, budget); err != nil {
+			return fmt.Errorf("--domains-file line %d: %w", entry.Line, err)
+		}
+		if err := checkDNS01Secret(domainOptions(entry, opts), budget); err != nil {
+			return fmt.Errorf("--domains-file line %d: %w", entry.Line, err)
+		}
+
+		_, objects, err := renderDomain(entry, opts, hasNetworking)
+		if err != nil {
+			return fmt.Errorf("--domains-file line %d: %w", entry.Line, err)
+		}
+
+		quotaWarnings, err := checkResourceQuota(entry.Namespace, objects)
+		if err != nil {
+			warnings.Add("line %d: unable to check ResourceQuota in namespace %s: %s", entry.Line, entry.Namespace, err)
+		}
+		for _, warning := range quotaWarnings {
+			warnings.Add("line %d: %s", entry.Line, warning)
+		}
+
+		hostWarnings, err := checkIngressHostConflicts(newRegInputData(domainOptions(entry, opts)).Hosts, objects)
+		if err != nil {
+			warnings.Add("line %d: unable to check for other Ingresses serving %s: %s", entry.Line, entry.Domain, err)
+		}
+		for _, warning := range hostWarnings {
+			warnings.Add("line %d: %s", entry.Line, warning)
+		}
+	}
+	return nil
+}
+
+// applyDomains renders and applies each line of --domains-file in turn,
+// reporting the result of each line. An error is returned when any
+// line failed. The lines share the retries in budget.
+func applyDomains(w io.Writer, entries []domainsFileEntry, opts registryIngressOptions, hasNetworking bool, createNamespace bool, budget *retry.Budget, apply applyOptions) error {
+	failed := 0
+	for _, entry := range entries {
+		err := applyDomain(w, entry, opts, hasNetworking, createNamespace, budget, apply)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "line %d: %s (namespace: %s) failed: %s\n", entry.Line, entry.Domain, entry.Namespace, err)
+			continue
+		}
+		fmt.Fprintf(w, "line %d: %s (namespace: %s) applied\n", entry.Line, entry.Domain, entry.Namespace)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d domains failed to apply", failed, len(entries))
+	}
+	return nil
+}
+
+func applyDomain(w io.Writer, entry domainsFileEntry, opts registryIngressOptions, hasNetworking bool, createNamespace bool, budget *retry.Budget, apply applyOptions) error {
+	yamlBytes, _, err := renderDomain(entry, opts, hasNetworking)
+	if err != nil {
+		return err
+	}
+	if err := ensureNamespace(entry.Namespace, createNamespace, budget); err != nil {
+		return err
+	}
+
+	tempFile, err := writeTempFile(yamlBytes, fmt.Sprintf("temp_registry_ingress_%d.yaml", entry.Line))
+	if err != nil {
+		return err
+	}
+	defer removeTempFile(w, tempFile, apply.KeepManifests)
+
+	res, _, err := applyWithRetry(tempFile, budget, apply.args()...)
+	if err != nil {
+		return err
+	}
+	if conflictErr := applyConflictError(res); conflictErr != nil {
+		return conflictErr
+	}
+	return k8s.ResultError(res, append([]string{"apply", "-f", tempFile}, apply.args()...)...)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_domainsFile_RendersEachLine(t *testing.T) {
+	file := `# domain,namespace,email
+registry.example.com,registry,admin@example.com
+
+images.example.org,images
+`
+	entries, err := parseDomainsFile(strings.NewReader(file), "ops@example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got: %+v", entries)
+	}
+
+	opts := registryIngressOptions{
+		IngressClass: "nginx",
+		MaxSize:      "200m",
+	}
+
+	want := []struct {
+		line      int
+		domain    string
+		namespace string
+		email     string
+	}{
+		{line: 2, domain: "registry.example.com", namespace: "registry", email: "admin@example.com"},
+		{line: 4, domain: "images.example.org", namespace: "images", email: "ops@example.org"},
+	}
+
+	for i, entry := range entries {
+		w := want[i]
+		if entry.Line != w.line {
+			t.Errorf("want line %d, got: %d", w.line, entry.Line)
+		}
+
+		manifest, _, err := renderDomain(entry, opts, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		objects, err := k8s.ParseObjects(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, object := range objects {
+			if object.Namespace != w.namespace {
+				t.Errorf("line %d: want %s in namespace %s, got: %s", w.line, object.Kind, w.namespace, object.Namespace)
+			}
+		}
+
+		for _, s := range []string{"host: " + w.domain, "email: " + w.email} {
+			if !strings.Contains(string(manifest), s) {
+				t.Errorf("line %d: want the manifest to contain %q, got:\n%s", w.line, s, manifest)
+			}
+		}
+	}
+}
+
+func Test_applyDomains_ReportsEachLine(t *testing.T) {
+	tempHome := t.TempDir()
+	previousHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempHome)
+	defer os.Setenv("HOME", previousHome)
+
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get namespace": {Stdout: "namespace/registry"},
+	})
+	fake.queue("apply -f", execute.ExecResult{}, execute.ExecResult{ExitCode: 1, Stderr: "namespaces \"images\" not found"})
+
+	entries := []domainsFileEntry{
+		{Line: 1, Domain: "registry.example.com", Namespace: "registry", Email: "admin@example.com"},
+		{Line: 2, Domain: "images.example.org", Namespace: "images", Email: "admin@example.com"},
+	}
+
+	var out bytes.Buffer
+	err := applyDomains(&out, entries, registryIngressOptions{IngressClass: "nginx", MaxSize: "200m"}, true, false, retry.NewBudget(0, 0), applyOptions{})
+	if err == nil || err.Error() != "1 of 2 domains failed to apply" {
+		t.Errorf("want one failure, got: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[0] != "line 1: registry.example.com (namespace: registry) applied" {
+		t.Fatalf("want a line for each domain, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "line 2: images.example.org (namespace: images) failed: kubectl apply -f ") ||
+		!strings.HasSuffix(lines[1], `exit code 1, stderr: namespaces "images" not found`) {
+		t.Errorf("want the kubectl error for line 2, got: %s", lines[1])
+	}
+}
+
+func Test_domainsFile_CreatesNamespacesOnlyWhenApplying(t *testing.T) {
+	tempHome := t.TempDir()
+	previousHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempHome)
+	defer os.Setenv("HOME", previousHome)
+
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	entries := []domainsFileEntry{
+		{Line: 1, Domain: "registry.example.com", Namespace: "registry", Email: "admin@example.com"},
+	}
+	opts := registryIngressOptions{IngressClass: "nginx", MaxSize: "200m"}
+
+	warnings := &installWarnings{}
+	if err := preflightDomains(entries, opts, true, warnings, true, retry.NewBudget(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "create namespace") {
+			t.Fatalf("want the preflight to only check the namespace, got: %s", call)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := applyDomains(&out, entries, opts, true, true, retry.NewBudget(0, 0), applyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	createAt, applyAt := -1, -1
+	for i, call := range fake.calls {
+		if call == "create namespace registry" {
+			createAt = i
+		}
+		if strings.HasPrefix(call, "apply -f") && applyAt == -1 {
+			applyAt = i
+		}
+	}
+	if createAt == -1 || applyAt < createAt {
+		t.Errorf("want the namespace created before the apply, got calls: %v", fake.calls)
+	}
+}
+
+func Test_parseDomainsFile_Invalid(t *testing.T) {
+	for _, file := range []string{"registry.example.com\n", ",registry,admin@example.com\n", "# only comments\n"} {
+		if _, err := parseDomainsFile(strings.NewReader(file), ""); err == nil {
+			t.Errorf("want %q to be rejected", file)
+		}
+	}
+}
+
+func Test_domainsFile_RejectsSingleInstallModes(t *testing.T) {
+	domainsFile := filepath.Join(t.TempDir(), "domains.txt")
+	if err := ioutil.WriteFile(domainsFile, []byte("registry.example.com,registry\nimages.example.org,images\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []string{"--dry-run", "--print-apply-order", "--uninstall", "--explain", "--render-with-comments"} {
+		t.Run(mode, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+
+			_, err := executeRegistryIngress(t, "--domains-file", domainsFile, "--email", "admin@example.com", mode)
+			if err == nil || !strings.Contains(err.Error(), "not supported with --domains-file") {
+				t.Errorf("want %s to be rejected, got: %v", mode, err)
+			}
+			for _, call := range fake.calls {
+				if strings.HasPrefix(call, "apply") || strings.HasPrefix(call, "delete") {
+					t.Errorf("want nothing applied or deleted, got: %s", call)
+				}
+			}
+		})
+	}
+}
+
+func Test_domainsFile_StrictStopsBeforeAnyApply(t *testing.T) {
+	tempHome := t.TempDir()
+	previousHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempHome)
+	defer os.Setenv("HOME", previousHome)
+
+	domainsFile := filepath.Join(tempHome, "domains.txt")
+	if err := ioutil.WriteFile(domainsFile, []byte("registry.example.com,registry\nimages.example.org,images\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	quota := `{"items":[{"metadata":{"name":"limits"},"status":{
+		"hard":{"count/ingresses.networking.k8s.io":"1"},
+		"used":{"count/ingresses.networking.k8s.io":"1"}}}]}`
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"api-versions":                  {Stdout: "networking.k8s.io/v1\ncert-manager.io/v1\n"},
+		"get namespace registry":        {Stdout: "namespace/registry\n"},
+		"get namespace images":          {Stdout: "namespace/images\n"},
+		"get resourcequota -n images":   {Stdout: quota},
+		"get resourcequota -n registry": {Stdout: `{"items":[]}`},
+		"get ingress --all-namespaces":  {Stdout: `{"items":[]}`},
+	})
+
+	out, err := executeRegistryIngress(t, "--domains-file", domainsFile, "--email", "admin@example.com", "--strict")
+	if err == nil {
+		t.Fatal("want --strict to fail on the quota of the second line")
+	}
+	if !strings.Contains(out, "line 2: ResourceQuota limits") {
+		t.Errorf("want the warning for line 2, got:\n%s", out)
+	}
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "apply") {
+			t.Errorf("want nothing applied, got: %s", call)
+		}
+	}
+}
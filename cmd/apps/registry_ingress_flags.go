@@ -0,0 +1,469 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/config"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	"github.com/spf13/pflag"
+)
+
+// registryIngressRun holds the flags which choose what a run does with
+// the rendered resources, the resources themselves are rendered from
+// registryIngressOptions
+type registryIngressRun struct {
+	Output string
+	DryRun bool
+	Strict bool
+
+	// DomainsFile renders and applies the resources once per line
+	DomainsFile string
+
+	// RenderMatrix, PrintApplyOrder, Explain and WithComments print the
+	// rendered resources in another form, then exit
+	RenderMatrix    bool
+	PrintApplyOrder bool
+	Explain         bool
+	WithComments    bool
+
+	// Uninstall deletes the rendered resources instead of applying them
+	Uninstall       bool
+	Force           bool
+	DeleteTLSSecret bool
+	Yes             bool
+
+	ConfirmContext    string
+	CreateNamespace   bool
+	SkipIfExists      bool
+	CheckExistingCert bool
+
+	// InstallController installs the controller for the ingress class
+	// with ControllerArgs, when it is missing
+	InstallController bool
+	ControllerArgs    []string
+
+	PreHook  string
+	PostHook string
+	Rollback bool
+	Apply    applyOptions
+	Budget   *retry.Budget
+
+	// Wait for the Issuer and Certificate to be Ready, polling from
+	// PollMin up to PollMax
+	Wait          bool
+	IssuerTimeout time.Duration
+	CertTimeout   time.Duration
+	PollMin       time.Duration
+	PollMax       time.Duration
+	WaitTimeout   time.Duration
+	TimeoutBanner time.Duration
+
+	WatchCertManager bool
+	VerifyTLS        bool
+	VerifyTLSTimeout time.Duration
+	VerifyTLSCA      string
+	Report           string
+	ShowNextSteps    bool
+}
+
+// setRegistryIngressCluster points kubectl at the kubeconfig, or at the
+// API server given by --server
+func setRegistryIngressCluster(flags *pflag.FlagSet) error {
+	kubeConfigPath, _ := flags.GetString("kubeconfig")
+	if err := config.SetKubeconfig(kubeConfigPath); err != nil {
+		return err
+	}
+
+	if server, _ := flags.GetString("server"); len(server) > 0 {
+		if len(k8s.KubeContext()) > 0 {
+			return errors.New("--server and --kube-context can't be used together, --server does not read the kubeconfig")
+		}
+		token, _ := flags.GetString("token")
+		insecure, _ := flags.GetBool("insecure-skip-tls-verify")
+		if err := k8s.SetAPIServer(k8s.APIServer{URL: server, Token: token, InsecureSkipTLSVerify: insecure}); err != nil {
+			return fmt.Errorf("--server: %w", err)
+		}
+	} else if flags.Changed("token") || flags.Changed("insecure-skip-tls-verify") {
+		return errors.New("--token and --insecure-skip-tls-verify are only supported with --server")
+	}
+	return nil
+}
+
+// readRegistryIngressRun reads and validates the flags of a run which
+// don't change the rendered resources
+func readRegistryIngressRun(flags *pflag.FlagSet) (registryIngressRun, error) {
+	run := registryIngressRun{}
+
+	run.Output, _ = flags.GetString("output")
+	run.DryRun, _ = flags.GetBool("dry-run")
+	if run.Output != "" && run.Output != "json" && run.Output != "table" {
+		return run, fmt.Errorf("--output must be json, table or left empty, but got: %q", run.Output)
+	}
+	if run.Output == "table" && !run.DryRun {
+		return run, errors.New("--output table is only supported with --dry-run")
+	}
+	run.Strict, _ = flags.GetBool("strict")
+
+	run.DomainsFile, _ = flags.GetString("domains-file")
+	if len(run.DomainsFile) > 0 {
+		if err := validateDomainsFileFlags(flags); err != nil {
+			return run, err
+		}
+	}
+
+	retries, _ := flags.GetInt("retries")
+	retryInterval, _ := flags.GetDuration("retry-interval")
+	if retries < 0 {
+		return run, fmt.Errorf("--retries must be 0 or more, got: %d", retries)
+	}
+	run.Budget = retry.NewBudget(retries, retryInterval)
+
+	run.Apply.KeepManifests, _ = flags.GetBool("keep-manifests")
+	run.Apply.ServerSide, _ = flags.GetBool("server-side")
+	run.Rollback, _ = flags.GetBool("rollback-on-failure")
+	if run.Rollback && run.Apply.ServerSide {
+		return run, errors.New("--rollback-on-failure is not supported with --server-side, which does not report the resources it created")
+	}
+	run.Uninstall, _ = flags.GetBool("uninstall")
+	run.Apply.Kustomize, _ = flags.GetString("kustomize")
+	if len(run.Apply.Kustomize) > 0 {
+		if len(run.DomainsFile) > 0 {
+			return run, errors.New("--kustomize is not supported with --domains-file")
+		}
+		if run.Uninstall {
+			return run, errors.New("--kustomize is not supported with --uninstall, delete the overlay's resources with kubectl delete -k")
+		}
+		if err := validateKustomizeOverlay(run.Apply.Kustomize); err != nil {
+			return run, err
+		}
+	}
+
+	run.RenderMatrix, _ = flags.GetBool("render-matrix")
+	run.PrintApplyOrder, _ = flags.GetBool("print-apply-order")
+	run.Explain, _ = flags.GetBool("explain")
+	run.WithComments, _ = flags.GetBool("render-with-comments")
+
+	run.Force, _ = flags.GetBool("force")
+	run.DeleteTLSSecret, _ = flags.GetBool("delete-tls-secret")
+	run.Yes, _ = flags.GetBool("yes")
+
+	run.ConfirmContext, _ = flags.GetString("confirm-context")
+	run.CreateNamespace, _ = flags.GetBool("create-namespace")
+	run.SkipIfExists, _ = flags.GetBool("skip-if-exists")
+	run.CheckExistingCert, _ = flags.GetBool("check-existing-cert")
+	run.InstallController, _ = flags.GetBool("install-ingress-controller")
+	run.ControllerArgs = controllerInstallArgs(flags)
+
+	run.PreHook, _ = flags.GetString("pre-hook")
+	run.PostHook, _ = flags.GetString("post-hook")
+
+	run.Wait, _ = flags.GetBool("wait")
+	run.IssuerTimeout, _ = flags.GetDuration("issuer-timeout")
+	run.CertTimeout, _ = flags.GetDuration("cert-timeout")
+	run.PollMin, _ = flags.GetDuration("poll-min")
+	run.PollMax, _ = flags.GetDuration("poll-max")
+	if run.Wait && (run.PollMin <= 0 || run.PollMax < run.PollMin) {
+		return run, fmt.Errorf("--poll-min must be positive and no more than --poll-max, got: %s and %s", run.PollMin, run.PollMax)
+	}
+	run.WaitTimeout, _ = flags.GetDuration("wait-timeout")
+	run.TimeoutBanner, _ = flags.GetDuration("timeout-banner")
+
+	run.WatchCertManager, _ = flags.GetBool("watch-cert-manager")
+	if skipIssuer, _ := flags.GetBool("skip-issuer"); skipIssuer && run.WatchCertManager {
+		return run, errors.New("--watch-cert-manager is not supported with --skip-issuer, cert-manager does not issue a certificate")
+	}
+	run.VerifyTLS, _ = flags.GetBool("verify-tls")
+	run.VerifyTLSTimeout, _ = flags.GetDuration("verify-tls-timeout")
+	run.VerifyTLSCA, _ = flags.GetString("verify-tls-ca")
+	if len(run.VerifyTLSCA) > 0 && !run.VerifyTLS {
+		return run, errors.New("--verify-tls-ca is only used with --verify-tls")
+	}
+	run.Report, _ = flags.GetString("report")
+	run.ShowNextSteps, _ = flags.GetBool("show-next-steps")
+
+	return run, nil
+}
+
+// readRegistryIngressOptions reads and validates the flags which the
+// resources are rendered from. It also gives whether the Ingress uses
+// networking.k8s.io/v1, and adds warnings which don't stop the install.
+func readRegistryIngressOptions(flags *pflag.FlagSet, caps map[string]bool, warnings *installWarnings) (registryIngressOptions, bool, error) {
+	opts := registryIngressOptions{}
+
+	email, _ := flags.GetString("email")
+	domains, _ := flags.GetStringSlice("domain")
+	ingressClass, _ := flags.GetString("ingress-class")
+	if !flags.Changed("ingress-class") {
+		ingressClass = defaultIngressClass()
+	}
+	namespace, _ := flags.GetString("namespace")
+
+	if configFrom, _ := flags.GetString("config-from"); len(configFrom) > 0 {
+		values, err := readConfigFrom(namespace, configFrom)
+		if err != nil {
+			return opts, false, err
+		}
+		if !flags.Changed("domain") && len(values["domain"]) > 0 {
+			domains = strings.Split(values["domain"], ",")
+		}
+		if !flags.Changed("email") {
+			email = values["email"]
+		}
+	}
+
+	// The first domain is the registry's, any others are served as
+	// extra hosts on the same Ingress and certificate
+	domains = trimDomains(domains)
+	domain := ""
+	if len(domains) > 0 {
+		domain = domains[0]
+	}
+
+	// No ACME account is registered without an Issuer, so --email
+	// is only needed when one is created
+	skipIssuer, _ := flags.GetBool("skip-issuer")
+	domainsFile, _ := flags.GetString("domains-file")
+	if len(domainsFile) == 0 {
+		if skipIssuer && domain == "" {
+			return opts, false, errors.New("the --domain flag should be set and not empty, please set this value")
+		}
+		if !skipIssuer && (email == "" || domain == "") {
+			return opts, false, errors.New("both --email and --domain flags should be set and not empty, please set these values")
+		}
+	}
+
+	ingressClass, err := k8s.NormalizeIngressClass(ingressClass)
+	if err != nil {
+		return opts, false, fmt.Errorf("--ingress-class: %w", err)
+	}
+
+	annotationValues, _ := flags.GetStringArray("annotation")
+	annotations, err := parseAnnotations(annotationValues)
+	if err != nil {
+		return opts, false, err
+	}
+
+	kindAnnotationValues, _ := flags.GetStringArray("annotation-on")
+	kindAnnotations, err := parseKindAnnotations(kindAnnotationValues)
+	if err != nil {
+		return opts, false, err
+	}
+
+	scheme, _ := flags.GetString("scheme")
+	ingressClass, scheme, err = applyCloudDefaults(os.Stderr, flags, ingressClass, scheme, warnings)
+	if err != nil {
+		return opts, false, err
+	}
+
+	apiVersions, _ := flags.GetStringToString("api-version")
+	if err := validateAPIVersions(apiVersions); err != nil {
+		return opts, false, err
+	}
+
+	hasNetworking := caps["networking.k8s.io/v1"]
+	if pinned, ok := apiVersions["Ingress"]; ok {
+		hasNetworking = pinned == "networking.k8s.io/v1"
+	}
+	if !hasNetworking {
+		warnings.Add("networking.k8s.io/v1 is not available, the deprecated extensions/v1beta1 Ingress API will be used")
+	}
+	if _, ok := classAnnotations[ingressClass]; !ok {
+		warnings.Add("unknown ingress class %q, annotations are only set by arkade for: %s", ingressClass, strings.Join(knownIngressClasses(), ", "))
+	}
+
+	opts.Domain = domain
+	opts.Email = email
+	opts.IngressClass = ingressClass
+	opts.Namespace = namespace
+	opts.MaxSize, _ = flags.GetString("max-size")
+	opts.Staging, _ = flags.GetBool("staging")
+	opts.Annotations = annotations
+	opts.KindAnnotations = kindAnnotations
+	opts.APIVersions = apiVersions
+
+	opts.ACMEServer, _ = flags.GetString("acme-server")
+	opts.EABKeyID, _ = flags.GetString("eab-key-id")
+	opts.EABSecret, _ = flags.GetString("eab-hmac-secret")
+	opts.EABSecretKey, _ = flags.GetString("eab-hmac-secret-key")
+	if err := validateACMEServer(opts); err != nil {
+		return opts, false, err
+	}
+	if err := validateExternalAccountBinding(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.ServiceName, _ = flags.GetString("service-name")
+	opts.ServicePort, _ = flags.GetInt("service-port")
+	if err := validateService(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.ReleaseName, _ = flags.GetString("release-name")
+	if len(opts.ReleaseName) > 0 && !releaseNameRegex.MatchString(opts.ReleaseName) {
+		return opts, false, fmt.Errorf("--release-name %q must be at most 30 lower case alphanumeric characters or '-', starting and ending with an alphanumeric character", opts.ReleaseName)
+	}
+
+	opts.IssuerKind, _ = flags.GetString("issuer-kind")
+	if opts.IssuerKind != "Issuer" && opts.IssuerKind != "ClusterIssuer" {
+		return opts, false, fmt.Errorf("--issuer-kind must be Issuer or ClusterIssuer, but got: %q", opts.IssuerKind)
+	}
+
+	opts.HTTPProxy, _ = flags.GetBool("httpproxy")
+	if opts.HTTPProxy && !caps["projectcontour.io/v1"] {
+		return opts, false, errors.New("--httpproxy needs the projectcontour.io/v1 HTTPProxy CRD, install Contour first")
+	}
+
+	opts.ServiceMonitor, _ = flags.GetBool("service-monitor")
+	opts.MonitorSelector, _ = flags.GetStringToString("monitor-selector")
+	if err := validateServiceMonitor(opts); err != nil {
+		return opts, false, err
+	}
+	if opts.ServiceMonitor && !caps["monitoring.coreos.com/v1"] {
+		return opts, false, errors.New("--service-monitor needs the monitoring.coreos.com/v1 ServiceMonitor CRD, install the Prometheus operator first")
+	}
+
+	opts.CanaryWeight, _ = flags.GetInt("canary-weight")
+	opts.CanaryServiceName, _ = flags.GetString("canary-service-name")
+	opts.CanaryServicePort, _ = flags.GetInt("canary-service-port")
+	if err := validateCanaryOptions(opts); err != nil {
+		return opts, false, err
+	}
+
+	hostValues, _ := flags.GetStringArray("host")
+	if len(hostValues) > 0 && len(domainsFile) > 0 {
+		return opts, false, errors.New("--host is not supported with --domains-file")
+	}
+	if len(domains) > 1 && len(domainsFile) > 0 {
+		return opts, false, errors.New("more than one --domain is not supported with --domains-file")
+	}
+	if len(domains) > 1 {
+		for _, extra := range domains[1:] {
+			opts.Hosts = append(opts.Hosts, registryHost{Domain: extra, Solver: "http01"})
+		}
+	}
+	for _, value := range hostValues {
+		host, err := parseRegistryHost(value)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Hosts = append(opts.Hosts, host)
+	}
+
+	opts.Challenge, _ = flags.GetString("challenge")
+	opts.DNS01Provider, _ = flags.GetString("dns-provider")
+	if !flags.Changed("dns-provider") {
+		opts.DNS01Provider, _ = flags.GetString("dns01-provider")
+	}
+	opts.SolverSeccomp, _ = flags.GetString("solver-seccomp")
+	opts.SolverServiceType, _ = flags.GetString("solver-service-type")
+	opts.DNS01Secret, _ = flags.GetString("dns01-secret")
+	opts.DNS01SecretKey, _ = flags.GetString("dns01-secret-key")
+	opts.DNS01Zones, _ = flags.GetStringSlice("dns01-zones")
+	opts.DNS01Region, _ = flags.GetString("dns01-region")
+	if err := validateSolverOptions(opts); err != nil {
+		return opts, false, err
+	}
+	if err := validateHosts(opts); err != nil {
+		return opts, false, err
+	}
+	if len(domainsFile) == 0 {
+		if err := validateDomain("--domain", opts.Domain, opts.Challenge != "dns01"); err != nil {
+			return opts, false, err
+		}
+	}
+
+	opts.Path, _ = flags.GetString("path")
+	opts.RewriteTarget, _ = flags.GetString("rewrite-target")
+	if err := validatePathOptions(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.CertDuration, _ = flags.GetString("cert-duration")
+	opts.RenewBefore, _ = flags.GetString("renew-before")
+	if err := validateCertDurations(opts); err != nil {
+		return opts, false, err
+	}
+
+	if err := validateSolverSeccomp(opts.SolverSeccomp); err != nil {
+		return opts, false, err
+	}
+	if opts.MaxSize, err = normalizeMaxSize(opts.MaxSize); err != nil {
+		return opts, false, err
+	}
+
+	// The Middleware's API group depends on the version of Traefik
+	if maxBytes, _ := maxSizeBytes(opts.MaxSize); opts.IngressClass == "traefik" && !opts.HTTPProxy && maxBytes > 0 {
+		if _, pinned := opts.APIVersions["Middleware"]; !pinned {
+			version, err := middlewareAPIVersion(caps)
+			if err != nil {
+				return opts, false, err
+			}
+			if opts.APIVersions == nil {
+				opts.APIVersions = map[string]string{}
+			}
+			opts.APIVersions["Middleware"] = version
+		}
+	}
+
+	opts.Protect, _ = flags.GetBool("protect")
+	opts.TLSSecret, _ = flags.GetString("tls-secret")
+	opts.SkipIssuer = skipIssuer
+	if err := validateTLSSecret(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.MinTLSVersion, _ = flags.GetString("min-tls-version")
+	if err := validateMinTLSVersion(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.SessionAffinity, _ = flags.GetString("session-affinity")
+	opts.SessionCookieName, _ = flags.GetString("session-cookie-name")
+	if err := validateSessionAffinity(opts); err != nil {
+		return opts, false, err
+	}
+
+	opts.Scheme = scheme
+	if _, err := schemeAnnotations(opts.IngressClass, opts.Scheme); err != nil {
+		return opts, false, err
+	}
+
+	ownerRefs, _ := flags.GetStringArray("owner-ref")
+	for _, value := range ownerRefs {
+		ref, err := parseOwnerReference(value)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.OwnerReferences = append(opts.OwnerReferences, ref)
+	}
+
+	templateFile, _ := flags.GetString("template-file")
+	fromGit, _ := flags.GetString("from-git")
+	if len(templateFile) > 0 && len(fromGit) > 0 {
+		return opts, false, errors.New("--from-git and --template-file can't be used together")
+	}
+	if len(templateFile) > 0 {
+		if opts.Template, err = readTemplateFile(templateFile); err != nil {
+			return opts, false, err
+		}
+	}
+	if len(fromGit) > 0 {
+		if opts.Template, err = readGitTemplate(fromGit); err != nil {
+			return opts, false, err
+		}
+	}
+
+	if err := validateKindAnnotations(opts, hasNetworking); err != nil {
+		return opts, false, err
+	}
+
+	return opts, hasNetworking, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_readRegistryIngressRun(t *testing.T) {
+	command := MakeInstallRegistryIngress()
+	// --wait is a persistent flag of arkade install
+	command.Flags().Bool("wait", false, "")
+	if err := command.ParseFlags([]string{"--wait", "--poll-min=2s", "--retries=1", "--pre-hook", "echo pre", "--report", "report.json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := readRegistryIngressRun(command.Flags())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !run.Wait || run.PollMin != time.Second*2 || run.PreHook != "echo pre" || run.Report != "report.json" {
+		t.Errorf("want the flags read into the run, got: %+v", run)
+	}
+	if run.Budget == nil {
+		t.Errorf("want a retry budget")
+	}
+}
+
+func Test_readRegistryIngressRun_Invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "output", args: []string{"--output", "yaml"}, wantErr: `--output must be json, table or left empty, but got: "yaml"`},
+		{name: "retries", args: []string{"--retries=-1"}, wantErr: "--retries must be 0 or more, got: -1"},
+		{name: "poll", args: []string{"--wait", "--poll-min=10s", "--poll-max=1s"}, wantErr: "--poll-min must be positive and no more than --poll-max, got: 10s and 1s"},
+		{name: "verify-tls-ca", args: []string{"--verify-tls-ca", "ca.pem"}, wantErr: "--verify-tls-ca is only used with --verify-tls"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			command := MakeInstallRegistryIngress()
+			command.Flags().Bool("wait", false, "")
+			if err := command.ParseFlags(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readRegistryIngressRun(command.Flags()); err == nil || err.Error() != tc.wantErr {
+				t.Errorf("want error %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_readRegistryIngressOptions(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	command := MakeInstallRegistryIngress()
+	if err := command.ParseFlags([]string{"--domain", "registry.example.com,mirror.example.com", "--email", "admin@example.com",
+		"--ingress-class", "nginx", "--namespace", "registry", "--max-size", "1g", "--release-name", "team-a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := &installWarnings{}
+	opts, hasNetworking, err := readRegistryIngressOptions(command.Flags(), map[string]bool{"networking.k8s.io/v1": true}, warnings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !hasNetworking {
+		t.Errorf("want networking.k8s.io/v1 to be used")
+	}
+	if opts.Domain != "registry.example.com" || opts.Namespace != "registry" || opts.ReleaseName != "team-a" {
+		t.Errorf("want the flags read into the options, got: %+v", opts)
+	}
+	if len(opts.Hosts) != 1 || opts.Hosts[0].Domain != "mirror.example.com" {
+		t.Errorf("want the second domain as an extra host, got: %v", opts.Hosts)
+	}
+	if len(warnings.Warnings) != 0 {
+		t.Errorf("want no warnings, got: %v", warnings.Warnings)
+	}
+}
+
+func Test_readRegistryIngressOptions_MiddlewareAPIVersion(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	cases := []struct {
+		name    string
+		caps    map[string]bool
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "Traefik 2.10 and newer", caps: map[string]bool{"traefik.io/v1alpha1": true, "traefik.containo.us/v1alpha1": true}, want: "traefik.io/v1alpha1"},
+		{name: "Traefik before 2.10", caps: map[string]bool{"traefik.containo.us/v1alpha1": true}, want: "traefik.containo.us/v1alpha1"},
+		{name: "pinned", caps: map[string]bool{}, args: []string{"--api-version", "Middleware=traefik.containo.us/v1alpha1"}, want: "traefik.containo.us/v1alpha1"},
+		{name: "no Traefik CRDs", caps: map[string]bool{}, wantErr: "--ingress-class traefik needs the Middleware CRD from traefik.io/v1alpha1 or traefik.containo.us/v1alpha1"},
+		{name: "no body size limit", caps: map[string]bool{}, args: []string{"--max-size", "0"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			command := MakeInstallRegistryIngress()
+			args := append([]string{"--domain", "registry.example.com", "--email", "admin@example.com", "--ingress-class", "traefik"}, tc.args...)
+			if err := command.ParseFlags(args); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.caps["networking.k8s.io/v1"] = true
+			opts, _, err := readRegistryIngressOptions(command.Flags(), tc.caps, &installWarnings{})
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want an error containing %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := opts.APIVersions["Middleware"]; got != tc.want {
+				t.Errorf("want Middleware in %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
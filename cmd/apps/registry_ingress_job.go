@@ -0,0 +1,166 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// registryInstallJob is printed by --as-job, to run the install in the
+// cluster with a ServiceAccount instead of a kubeconfig
+type registryInstallJob struct {
+	Image     string
+	Namespace string
+	Args      []string
+}
+
+// jobExcludedFlags configure the Job or how arkade reaches the cluster,
+// which the Job does through its ServiceAccount instead
+var jobExcludedFlags = map[string]bool{
+	"as-job":                   true,
+	"job-image":                true,
+	"job-namespace":            true,
+	"kubeconfig":               true,
+	"kube-context":             true,
+	"server":                   true,
+	"token":                    true,
+	"insecure-skip-tls-verify": true,
+	"audit-log":                true,
+	"keep-manifests":           true,
+}
+
+// jobUnsupportedFlags read local files, which the Job can't see
+var jobUnsupportedFlags = []string{"domains-file", "template-file", "kustomize", "pre-hook", "post-hook", "report"}
+
+// installJobArgs rebuilds the arguments of arkade install for the Job
+// from the flags which were set, in the order of their names
+func installJobArgs(flags *pflag.FlagSet) ([]string, error) {
+	for _, name := range jobUnsupportedFlags {
+		if flags.Changed(name) {
+			return nil, fmt.Errorf("--%s is not supported with --as-job, as the Job can't read local files", name)
+		}
+	}
+
+	args := []string{"install", "docker-registry-ingress"}
+	var err error
+	flags.Visit(func(flag *pflag.Flag) {
+		if jobExcludedFlags[flag.Name] || err != nil {
+			return
+		}
+
+		switch value := flag.Value.(type) {
+		case pflag.SliceValue:
+			for _, item := range value.GetSlice() {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.Name, item))
+			}
+			return
+		}
+
+		if flag.Value.Type() == "stringToString" {
+			var values map[string]string
+			values, err = flags.GetStringToString(flag.Name)
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				args = append(args, fmt.Sprintf("--%s=%s=%s", flag.Name, key, values[key]))
+			}
+			return
+		}
+
+		args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	return args, err
+}
+
+// writeInstallJob writes the ServiceAccount, its RBAC and the Job
+func writeInstallJob(w io.Writer, job registryInstallJob) error {
+	tmpl, err := template.New("job").Funcs(registryTemplateFuncs).Parse(registryInstallJobTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, job)
+}
+
+// The ClusterRole covers the preflight checks across namespaces, as well
+// as the resources which may be rendered
+var registryInstallJobTemplate = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: arkade-docker-registry-ingress
+  namespace: {{.Namespace}}
+  labels:
+    app.kubernetes.io/managed-by: arkade
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: arkade-docker-registry-ingress
+  labels:
+    app.kubernetes.io/managed-by: arkade
+rules:
+- apiGroups: ["networking.k8s.io", "extensions"]
+  resources: ["ingresses", "ingressclasses"]
+  verbs: ["get", "list", "watch", "create", "update", "patch", "delete"]
+- apiGroups: ["cert-manager.io"]
+  resources: ["issuers", "clusterissuers", "certificates"]
+  verbs: ["get", "list", "watch", "create", "update", "patch", "delete"]
+- apiGroups: ["traefik.io", "traefik.containo.us"]
+  resources: ["middlewares"]
+  verbs: ["get", "list", "create", "update", "patch", "delete"]
+- apiGroups: ["projectcontour.io"]
+  resources: ["httpproxies"]
+  verbs: ["get", "list", "create", "update", "patch", "delete"]
+- apiGroups: [""]
+  resources: ["namespaces"]
+  verbs: ["get", "create"]
+- apiGroups: [""]
+  resources: ["resourcequotas", "services", "pods", "configmaps", "secrets"]
+  verbs: ["get", "list"]
+- apiGroups: ["apps"]
+  resources: ["deployments", "daemonsets"]
+  verbs: ["get", "list"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: arkade-docker-registry-ingress
+  labels:
+    app.kubernetes.io/managed-by: arkade
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: arkade-docker-registry-ingress
+subjects:
+- kind: ServiceAccount
+  name: arkade-docker-registry-ingress
+  namespace: {{.Namespace}}
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: arkade-docker-registry-ingress
+  namespace: {{.Namespace}}
+  labels:
+    app.kubernetes.io/managed-by: arkade
+spec:
+  backoffLimit: 2
+  template:
+    spec:
+      serviceAccountName: arkade-docker-registry-ingress
+      restartPolicy: Never
+      containers:
+      - name: arkade
+        image: {{ toYaml .Image }}
+        command: ["arkade"]
+        args:
+{{ toYaml .Args | indent 8 }}
+`
@@ -0,0 +1,103 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_writeInstallJob_EmbedsArgsAndImage(t *testing.T) {
+	command := MakeInstallRegistryIngress()
+	err := command.ParseFlags([]string{
+		"--as-job",
+		"--job-image", "registry.example.com/tools/arkade:0.7.0",
+		"--domain", "registry.example.com,mirror.example.com",
+		"--email", "admin@example.com",
+		"--namespace", "registry",
+		"--annotation-on", "Issuer:team=platform",
+		"--api-version", "Ingress=networking.k8s.io/v1",
+		"--server", "https://10.0.0.1:6443",
+		"--staging",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := installJobArgs(command.Flags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantArgs := []string{
+		"install", "docker-registry-ingress",
+		"--annotation-on=Issuer:team=platform",
+		"--api-version=Ingress=networking.k8s.io/v1",
+		"--domain=registry.example.com",
+		"--domain=mirror.example.com",
+		"--email=admin@example.com",
+		"--namespace=registry",
+		"--staging=true",
+	}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("want args:\n%v\ngot:\n%v", wantArgs, args)
+	}
+
+	var out bytes.Buffer
+	job := registryInstallJob{Image: "registry.example.com/tools/arkade:0.7.0", Namespace: "registry", Args: args}
+	if err := writeInstallJob(&out, job); err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, doc := range k8s.SplitManifest(out.Bytes()) {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			t.Fatalf("rendered YAML is invalid: %s\n%s", err, doc)
+		}
+		kinds = append(kinds, fmt.Sprint(obj["kind"]))
+		if obj["kind"] != "Job" {
+			continue
+		}
+
+		spec := obj["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+		if spec["serviceAccountName"] != "arkade-docker-registry-ingress" {
+			t.Errorf("want the Job to use the ServiceAccount, got: %v", spec["serviceAccountName"])
+		}
+		container := spec["containers"].([]interface{})[0].(map[string]interface{})
+		if container["image"] != job.Image {
+			t.Errorf("want image %s, got: %v", job.Image, container["image"])
+		}
+		var gotArgs []string
+		for _, arg := range container["args"].([]interface{}) {
+			gotArgs = append(gotArgs, arg.(string))
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want the Job's args:\n%v\ngot:\n%v", wantArgs, gotArgs)
+		}
+	}
+
+	if want := "ServiceAccount,ClusterRole,ClusterRoleBinding,Job"; strings.Join(kinds, ",") != want {
+		t.Errorf("want kinds %s, got: %v", want, kinds)
+	}
+}
+
+func Test_installJobArgs_RejectsLocalFiles(t *testing.T) {
+	command := MakeInstallRegistryIngress()
+	if err := command.ParseFlags([]string{"--as-job", "--domains-file", "domains.txt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := installJobArgs(command.Flags())
+	want := "--domains-file is not supported with --as-job, as the Job can't read local files"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got: %v", want, err)
+	}
+}
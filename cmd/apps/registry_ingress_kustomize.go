@@ -0,0 +1,81 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// kustomizationFiles are the names kustomize reads a kustomization from
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// validateKustomizeOverlay checks the --kustomize directory has a
+// kustomization
+func validateKustomizeOverlay(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("--kustomize must be a directory with a kustomization, got: %q", dir)
+	}
+	for _, name := range kustomizationFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("--kustomize directory %s has no kustomization.yaml", dir)
+}
+
+// writeKustomization writes the manifest as a kustomize base into
+// runDirectory/base, next to a copy of the overlay which is expected to
+// list ../base in its resources. The directory of the copy is returned
+// for kubectl apply -k.
+func writeKustomization(runDirectory string, manifest []byte, overlay string) (string, error) {
+	base := filepath.Join(runDirectory, "base")
+	if err := os.MkdirAll(base, 0700); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(base, "registry.yaml"), manifest, 0600); err != nil {
+		return "", err
+	}
+	kustomization := "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n- registry.yaml\n"
+	if err := ioutil.WriteFile(filepath.Join(base, "kustomization.yaml"), []byte(kustomization), 0600); err != nil {
+		return "", err
+	}
+
+	overlayCopy := filepath.Join(runDirectory, "overlay")
+	if err := copyDirectory(overlay, overlayCopy); err != nil {
+		return "", fmt.Errorf("unable to copy --kustomize directory %s: %w", overlay, err)
+	}
+	return overlayCopy, nil
+}
+
+// copyDirectory copies the regular files and directories within src
+func copyDirectory(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0600)
+	})
+}
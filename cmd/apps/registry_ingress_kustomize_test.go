@@ -0,0 +1,70 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_writeKustomization_LaysOutBaseAndOverlay(t *testing.T) {
+	overlay := t.TempDir()
+	files := map[string]string{
+		"kustomization.yaml":       "resources:\n- ../base\npatchesStrategicMerge:\n- patches/labels.yaml\n",
+		"patches/labels.yaml":      "kind: Ingress\nmetadata:\n  name: docker-registry\n  labels:\n    team: platform\n",
+		"patches/ingress-tls.yaml": "kind: Ingress\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(overlay, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(overlay, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := validateKustomizeOverlay(overlay); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	runDirectory := t.TempDir()
+	manifest := []byte("apiVersion: networking.k8s.io/v1\nkind: Ingress\n")
+	overlayCopy, err := writeKustomization(runDirectory, manifest, overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(runDirectory, "overlay"); overlayCopy != want {
+		t.Errorf("want overlay copied to %s, got: %s", want, overlayCopy)
+	}
+	for name, content := range files {
+		got, err := ioutil.ReadFile(filepath.Join(overlayCopy, name))
+		if err != nil || string(got) != content {
+			t.Errorf("want %s copied, got: %q, %v", name, got, err)
+		}
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(runDirectory, "base", "registry.yaml"))
+	if err != nil || !bytes.Equal(got, manifest) {
+		t.Errorf("want the manifest as the base, got: %q, %v", got, err)
+	}
+	kustomization, err := ioutil.ReadFile(filepath.Join(runDirectory, "base", "kustomization.yaml"))
+	if err != nil || !strings.Contains(string(kustomization), "resources:\n- registry.yaml\n") {
+		t.Errorf("want a base kustomization listing registry.yaml, got: %q, %v", kustomization, err)
+	}
+}
+
+func Test_validateKustomizeOverlay_NeedsKustomization(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := validateKustomizeOverlay(dir); err == nil || !strings.Contains(err.Error(), "has no kustomization.yaml") {
+		t.Errorf("want an error for a directory without a kustomization, got: %v", err)
+	}
+	if err := validateKustomizeOverlay(filepath.Join(dir, "missing")); err == nil {
+		t.Error("want an error for a missing directory")
+	}
+}
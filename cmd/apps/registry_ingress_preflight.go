@@ -0,0 +1,418 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/config"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	"github.com/alexellis/arkade/pkg/timestamp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ingressController is the arkade app which installs the controller
+// for an ingress class, Selector finds the controller once installed
+type ingressController struct {
+	Selector string
+	App      func() *cobra.Command
+}
+
+var ingressControllers = map[string]ingressController{
+	"nginx":   {Selector: "app.kubernetes.io/name=ingress-nginx", App: MakeInstallNginx},
+	"traefik": {Selector: "app.kubernetes.io/name=traefik", App: MakeInstallTraefik2},
+}
+
+// controllerInstallFlags are the flags of arkade install and its
+// parent which choose the cluster and how the install is made
+var controllerInstallFlags = []string{"kubeconfig", "kube-context", "wait"}
+
+// controllerInstallArgs gives the controllerInstallFlags which were set
+// for the registry ingress, to pass them on to the controller's app
+func controllerInstallArgs(flags *pflag.FlagSet) []string {
+	var args []string
+	for _, name := range controllerInstallFlags {
+		if flag := flags.Lookup(name); flag != nil && flag.Changed {
+			args = append(args, fmt.Sprintf("--%s=%s", name, flag.Value.String()))
+		}
+	}
+	return args
+}
+
+// runControllerInstall runs the app with its default flags and args.
+// The app is added to an install command of its own, as its RunE reads
+// the persistent flags which arkade install defines.
+var runControllerInstall = func(app *cobra.Command, args []string) error {
+	install := &cobra.Command{
+		Use: "install",
+		PersistentPreRun: func(command *cobra.Command, args []string) {
+			if kubeContext, _ := command.Flags().GetString("kube-context"); len(kubeContext) > 0 {
+				k8s.SetKubeContext(kubeContext)
+			}
+		},
+	}
+	install.PersistentFlags().String("kubeconfig", "", "Local path for your kubeconfig file")
+	install.PersistentFlags().String("kube-context", "", "Name of the kubeconfig context for kubectl and helm to use")
+	install.PersistentFlags().Bool("wait", false, "If we should wait for the resource to be ready before returning")
+	install.AddCommand(app)
+
+	install.SetArgs(append([]string{app.Name()}, args...))
+	return install.Execute()
+}
+
+// ensureIngressController looks for the controller of a known ingress
+// class, when none is found it is installed with installArgs if install
+// is set, or a warning suggests --install-ingress-controller
+func ensureIngressController(w io.Writer, warnings *installWarnings, ingressClass string, install bool, installArgs []string, budget *retry.Budget) error {
+	controller, ok := ingressControllers[ingressClass]
+	if !ok {
+		return nil
+	}
+
+	var found bool
+	err := budget.Do(context.Background(), nil, func() error {
+		args := []string{"get", "deploy,daemonset", "--all-namespaces", "-l", controller.Selector, "-o", "name"}
+		res, err := k8s.KubectlTask(args...)
+		if err != nil {
+			return err
+		}
+		if err := k8s.ResultError(res, args...); err != nil {
+			return err
+		}
+		found = len(strings.TrimSpace(res.Stdout)) > 0
+		return nil
+	})
+	if err != nil {
+		warnings.Add("unable to find the %s ingress controller: %s", ingressClass, err)
+		return nil
+	}
+	if found {
+		return nil
+	}
+
+	app := controller.App()
+	if !install {
+		warnings.Add("no %s ingress controller was found, install it with: arkade install %s, or add --install-ingress-controller", ingressClass, app.Name())
+		return nil
+	}
+
+	fmt.Fprintf(w, "No %s ingress controller was found, installing %s\n", ingressClass, app.Name())
+	if err := runControllerInstall(app, installArgs); err != nil {
+		return fmt.Errorf("unable to install %s: %w", app.Name(), err)
+	}
+	return nil
+}
+
+// checkExistingCert reports on the certificate held in the TLS secret,
+// if there is one, and asks whether to proceed with the install
+func checkExistingCert(w io.Writer, in io.Reader, namespace, secretName string, now time.Time) (bool, error) {
+	res, err := k8s.KubectlTask("get", "secret", secretName, "-n", namespace, "-o", `jsonpath={.data.tls\.crt}`)
+	if err != nil {
+		return false, err
+	}
+
+	if res.ExitCode != 0 || len(strings.TrimSpace(res.Stdout)) == 0 {
+		fmt.Fprintf(w, "No existing certificate found in secret %s/%s\n", namespace, secretName)
+		return true, nil
+	}
+
+	pemBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(res.Stdout))
+	if err != nil {
+		return false, fmt.Errorf("unable to decode tls.crt from secret %s: %w", secretName, err)
+	}
+
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return false, fmt.Errorf("no PEM certificate found in secret %s", secretName)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse certificate from secret %s: %w", secretName, err)
+	}
+
+	valid := now.After(cert.NotBefore) && now.Before(cert.NotAfter)
+
+	fmt.Fprintf(w, "Existing certificate in secret %s/%s\n", namespace, secretName)
+	fmt.Fprintf(w, "  Subject:    %s\n", cert.Subject.CommonName)
+	fmt.Fprintf(w, "  Issuer:     %s\n", cert.Issuer.CommonName)
+	fmt.Fprintf(w, "  SANs:       %s\n", strings.Join(cert.DNSNames, ", "))
+	fmt.Fprintf(w, "  Not before: %s\n", timestamp.Format(cert.NotBefore))
+	fmt.Fprintf(w, "  Not after:  %s\n", timestamp.Format(cert.NotAfter))
+	fmt.Fprintf(w, "  Valid:      %t\n", valid)
+
+	fmt.Fprint(w, "Proceed with the install? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes", nil
+}
+
+// ensureNamespace fails early when the namespace is missing, unless it
+// is to be created
+func ensureNamespace(namespace string, create bool, budget *retry.Budget) error {
+	exists, err := checkNamespace(namespace, create, budget)
+	if err != nil || exists {
+		return err
+	}
+	return k8s.Kubectl("create", "namespace", namespace)
+}
+
+// checkNamespace reports whether the namespace exists, failing when it
+// is missing and create is not set, without creating it
+func checkNamespace(namespace string, create bool, budget *retry.Budget) (bool, error) {
+	var exists bool
+	err := budget.Do(context.Background(), nil, func() error {
+		var err error
+		exists, err = k8s.NamespaceExists(namespace)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if !exists && !create {
+		return false, fmt.Errorf("namespace %s does not exist, create it or pass --create-namespace", namespace)
+	}
+	return exists, nil
+}
+
+// checkDNS01Secret fails early when the credentials for the DNS01
+// provider are missing, as the Issuer reads them from its own namespace
+func checkDNS01Secret(opts registryIngressOptions, budget *retry.Budget) error {
+	if len(opts.DNS01Provider) == 0 || len(opts.DNS01Secret) == 0 {
+		return nil
+	}
+
+	// cert-manager reads the secrets of a ClusterIssuer from its own
+	// cluster resource namespace
+	namespace := opts.Namespace
+	if opts.IssuerKind == "ClusterIssuer" {
+		namespace = certManagerNamespace
+	}
+
+	var exists bool
+	err := budget.Do(context.Background(), nil, func() error {
+		var err error
+		exists, err = k8s.ResourceExists("secret", opts.DNS01Secret, namespace)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf(`secret %s/%s for the %s DNS01 provider does not exist, create it with:
+
+  kubectl create secret generic %s -n %s --from-literal=%s=<api-token>`,
+			namespace, opts.DNS01Secret, opts.DNS01Provider,
+			opts.DNS01Secret, namespace, opts.DNS01SecretKey)
+	}
+	return nil
+}
+
+// currentKubeContext returns the name of the active kubectl context,
+// or an empty string if it can't be found
+func currentKubeContext() string {
+	if kubeContext := k8s.KubeContext(); len(kubeContext) > 0 {
+		return kubeContext
+	}
+
+	res, err := k8s.KubectlTask("config", "current-context")
+	if err != nil || res.ExitCode != 0 {
+		return ""
+	}
+	return strings.TrimSpace(res.Stdout)
+}
+
+// checkProtectedContext refuses to apply to a context matching one of the
+// user's protectedContexts, unless it was confirmed with --confirm-context
+func checkProtectedContext(userConfig config.UserConfig, kubeContext, confirmed string) error {
+	if len(userConfig.ProtectedContexts) == 0 {
+		return nil
+	}
+	if len(kubeContext) == 0 {
+		return errors.New("unable to find the current kubectl context to check against protectedContexts")
+	}
+
+	pattern := userConfig.ProtectedContext(kubeContext)
+	if len(pattern) == 0 || confirmed == kubeContext {
+		return nil
+	}
+	return fmt.Errorf("refusing to apply to context %q which matches the protected pattern %q, add --confirm-context %s to continue", kubeContext, pattern, kubeContext)
+}
+
+type resourceQuotaList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Hard map[string]string `json:"hard"`
+			Used map[string]string `json:"used"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// checkResourceQuota is a best-effort check of the object count limits
+// set by any ResourceQuota in the namespace, returning a warning for
+// each limit which the objects would exceed when created
+func checkResourceQuota(namespace string, objects []k8s.Object) ([]string, error) {
+	args := []string{"get", "resourcequota", "-n", namespace, "-o", "json"}
+	res, err := k8s.KubectlTask(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k8s.ResultError(res, args...); err != nil {
+		return nil, err
+	}
+
+	quotas := resourceQuotaList{}
+	if err := json.Unmarshal([]byte(res.Stdout), &quotas); err != nil {
+		return nil, err
+	}
+
+	required := map[string]int{}
+	for _, object := range objects {
+		if object.Namespace == namespace {
+			required[quotaCountKey(object)]++
+		}
+	}
+
+	keys := make([]string, 0, len(required))
+	for key := range required {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	for _, quota := range quotas.Items {
+		for _, key := range keys {
+			count := required[key]
+			hard, err := strconv.Atoi(quota.Status.Hard[key])
+			if err != nil {
+				continue
+			}
+
+			used, _ := strconv.Atoi(quota.Status.Used[key])
+			if used+count > hard {
+				warnings = append(warnings,
+					fmt.Sprintf("ResourceQuota %s in namespace %s allows %s=%d, %d in use, applying %d more may exceed it",
+						quota.Metadata.Name, namespace, key, hard, used, count))
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// quotaResources gives the resource name of each kind which arkade
+// renders, as a kind can't always be pluralised by adding an s
+var quotaResources = map[string]string{
+	"Certificate":   "certificates",
+	"ClusterIssuer": "clusterissuers",
+	"HTTPProxy":     "httpproxies",
+	"Ingress":       "ingresses",
+	"Issuer":        "issuers",
+	"Middleware":    "middlewares",
+	"Secret":        "secrets",
+}
+
+// quotaCountKey gives the object count key used by a ResourceQuota
+// for the object's kind i.e. count/ingresses.networking.k8s.io
+func quotaCountKey(object k8s.Object) string {
+	resource, ok := quotaResources[object.Kind]
+	if !ok {
+		resource = strings.ToLower(object.Kind)
+		switch {
+		case strings.HasSuffix(resource, "s"):
+			resource += "es"
+		case len(resource) > 1 && strings.HasSuffix(resource, "y") && !strings.ContainsRune("aeiou", rune(resource[len(resource)-2])):
+			resource = strings.TrimSuffix(resource, "y") + "ies"
+		default:
+			resource += "s"
+		}
+	}
+
+	if group := object.Group(); len(group) > 0 {
+		return fmt.Sprintf("count/%s.%s", resource, group)
+	}
+	return fmt.Sprintf("count/%s", resource)
+}
+
+type ingressHostList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Rules []struct {
+				Host string `json:"host"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// checkIngressHostConflicts lists the Ingresses in every namespace and
+// returns a warning for each other Ingress which already serves one of
+// the hosts, the Ingresses within objects are expected to share them
+func checkIngressHostConflicts(hosts []string, objects []k8s.Object) ([]string, error) {
+	args := []string{"get", "ingress", "--all-namespaces", "-o", "json"}
+	res, err := k8s.KubectlTask(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k8s.ResultError(res, args...); err != nil {
+		return nil, err
+	}
+
+	ingresses := ingressHostList{}
+	if err := json.Unmarshal([]byte(res.Stdout), &ingresses); err != nil {
+		return nil, err
+	}
+
+	own := map[string]bool{}
+	for _, object := range objects {
+		if object.Kind == "Ingress" {
+			own[object.Namespace+"/"+object.Name] = true
+		}
+	}
+	wanted := map[string]bool{}
+	for _, host := range hosts {
+		wanted[strings.ToLower(host)] = true
+	}
+
+	var warnings []string
+	for _, ingress := range ingresses.Items {
+		id := ingress.Metadata.Namespace + "/" + ingress.Metadata.Name
+		if own[id] {
+			continue
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if wanted[strings.ToLower(rule.Host)] {
+				warnings = append(warnings,
+					fmt.Sprintf("host %s is already served by Ingress %s, the ingress controller may route it to either and the certificates can conflict", rule.Host, id))
+			}
+		}
+	}
+
+	return warnings, nil
+}
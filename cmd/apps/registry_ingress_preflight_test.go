@@ -0,0 +1,439 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/config"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+func Test_checkResourceQuota(t *testing.T) {
+	quota := `{"items":[{"metadata":{"name":"limits"},"status":{
+		"hard":{"count/ingresses.networking.k8s.io":"1","count/issuers.cert-manager.io":"5"},
+		"used":{"count/ingresses.networking.k8s.io":"1","count/issuers.cert-manager.io":"1"}}}]}`
+
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get resourcequota -n registry": {Stdout: quota},
+	})
+
+	objects := []k8s.Object{
+		{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "docker-registry", Namespace: "registry"},
+		{APIVersion: "cert-manager.io/v1", Kind: "Issuer", Name: "letsencrypt-prod-issuer", Namespace: "registry"},
+	}
+
+	warnings, err := checkResourceQuota("registry", objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("want 1 warning, got %d: %v", len(warnings), warnings)
+	}
+
+	if !strings.Contains(warnings[0], "count/ingresses.networking.k8s.io=1") {
+		t.Errorf("want warning for the ingress count, got: %s", warnings[0])
+	}
+}
+
+func Test_checkResourceQuota_NoQuota(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get resourcequota -n registry": {Stdout: `{"items":[]}`},
+	})
+
+	objects := []k8s.Object{
+		{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "docker-registry", Namespace: "registry"},
+	}
+
+	warnings, err := checkResourceQuota("registry", objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("want no warnings, got: %v", warnings)
+	}
+}
+
+func Test_quotaCountKey(t *testing.T) {
+	cases := []struct {
+		object k8s.Object
+		want   string
+	}{
+		{object: k8s.Object{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"}, want: "count/ingresses.networking.k8s.io"},
+		{object: k8s.Object{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy"}, want: "count/httpproxies.projectcontour.io"},
+		{object: k8s.Object{APIVersion: "cert-manager.io/v1", Kind: "Issuer"}, want: "count/issuers.cert-manager.io"},
+		{object: k8s.Object{APIVersion: "v1", Kind: "Secret"}, want: "count/secrets"},
+		{object: k8s.Object{APIVersion: "example.com/v1", Kind: "Policy"}, want: "count/policies.example.com"},
+		{object: k8s.Object{APIVersion: "example.com/v1", Kind: "Gateway"}, want: "count/gateways.example.com"},
+	}
+
+	for _, tc := range cases {
+		if got := quotaCountKey(tc.object); got != tc.want {
+			t.Errorf("%s: want %s, got: %s", tc.object.Kind, tc.want, got)
+		}
+	}
+}
+
+func Test_checkIngressHostConflicts(t *testing.T) {
+	ingresses := `{"items":[
+		{"metadata":{"name":"docker-registry","namespace":"registry"},"spec":{"rules":[{"host":"registry.example.com"}]}},
+		{"metadata":{"name":"old-registry","namespace":"legacy"},"spec":{"rules":[{"host":"Registry.example.com"}]}},
+		{"metadata":{"name":"blog","namespace":"web"},"spec":{"rules":[{"host":"blog.example.com"}]}}]}`
+
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get ingress --all-namespaces -o json": {Stdout: ingresses},
+	})
+
+	objects := []k8s.Object{
+		{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "docker-registry", Namespace: "registry"},
+		{APIVersion: "cert-manager.io/v1", Kind: "Issuer", Name: "letsencrypt-prod-issuer", Namespace: "registry"},
+	}
+
+	warnings, err := checkIngressHostConflicts([]string{"registry.example.com"}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(fake.calls) != 1 {
+		t.Errorf("want ingresses listed once, got: %v", fake.calls)
+	}
+	want := "host Registry.example.com is already served by Ingress legacy/old-registry, the ingress controller may route it to either and the certificates can conflict"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Fatalf("want the warning %q, got: %v", want, warnings)
+	}
+
+	strictWarnings := &installWarnings{Strict: true}
+	strictWarnings.Add("%s", warnings[0])
+	if err := strictWarnings.Err(); err == nil {
+		t.Errorf("want --strict to fail on the conflict, got: %v", err)
+	}
+}
+
+// selfSignedCertPEM returns a PEM certificate for the given DNS names
+func selfSignedCertPEM(t *testing.T, dnsNames []string, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func Test_checkExistingCert_ReportsAndExits(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	certPEM := selfSignedCertPEM(t, []string{"registry.example.com"}, now.Add(-time.Hour), now.Add(time.Hour*24*60))
+
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get secret docker-registry -n registry": {Stdout: base64.StdEncoding.EncodeToString(certPEM)},
+	})
+
+	var out bytes.Buffer
+	proceed, err := checkExistingCert(&out, strings.NewReader("n\n"), "registry", "docker-registry", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if proceed {
+		t.Errorf("want the install to stop when the answer is no")
+	}
+
+	for _, want := range []string{
+		"SANs:       registry.example.com",
+		"Not after:  2021-08-30T12:00:00Z",
+		"Valid:      true",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func Test_checkExistingCert_NoSecretProceeds(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get secret docker-registry -n registry": {ExitCode: 1, Stderr: `secrets "docker-registry" not found`},
+	})
+
+	var out bytes.Buffer
+	proceed, err := checkExistingCert(&out, strings.NewReader(""), "registry", "docker-registry", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !proceed {
+		t.Errorf("want the install to proceed when there is no existing certificate")
+	}
+}
+
+func Test_ensureNamespace(t *testing.T) {
+	missing := execute.ExecResult{}
+
+	cases := []struct {
+		name       string
+		result     execute.ExecResult
+		create     bool
+		wantErr    string
+		wantCreate bool
+	}{
+		{name: "existing", result: execute.ExecResult{Stdout: "namespace/registry"}},
+		{name: "missing", result: missing, wantErr: "namespace registry does not exist, create it or pass --create-namespace"},
+		{name: "missing with --create-namespace", result: missing, create: true, wantCreate: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"get namespace registry": tc.result,
+			})
+
+			err := ensureNamespace("registry", tc.create, retry.NewBudget(0, 0))
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got: %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			created := false
+			for _, call := range fake.calls {
+				if call == "create namespace registry" {
+					created = true
+				}
+			}
+			if created != tc.wantCreate {
+				t.Errorf("want namespace created: %t, got calls: %v", tc.wantCreate, fake.calls)
+			}
+		})
+	}
+}
+
+func Test_checkDNS01Secret(t *testing.T) {
+	opts := registryIngressOptions{
+		Namespace:      "registry",
+		DNS01Provider:  "cloudflare",
+		DNS01Secret:    "cloudflare-api-token",
+		DNS01SecretKey: "api-token",
+		DNS01Zones:     []string{"example.com"},
+	}
+
+	cases := []struct {
+		name    string
+		result  execute.ExecResult
+		wantErr string
+	}{
+		{name: "present", result: execute.ExecResult{Stdout: "secret/cloudflare-api-token"}},
+		{
+			name:    "absent",
+			result:  execute.ExecResult{},
+			wantErr: "kubectl create secret generic cloudflare-api-token -n registry --from-literal=api-token=<api-token>",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"get secret cloudflare-api-token -n registry": tc.result,
+			})
+
+			err := checkDNS01Secret(opts, retry.NewBudget(0, 0))
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want an error with guidance %q, got: %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fake.calls) != 1 || fake.calls[0] != "get secret cloudflare-api-token -n registry --ignore-not-found -o name" {
+				t.Errorf("want a single get secret call, got: %v", fake.calls)
+			}
+		})
+	}
+}
+
+func Test_checkProtectedContext(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"config current-context": {Stdout: "prod-eu-west-1\n"},
+	})
+	userConfig := config.UserConfig{ProtectedContexts: []string{"prod-*"}}
+
+	err := checkProtectedContext(userConfig, currentKubeContext(), "")
+	if err == nil {
+		t.Fatal("want applying to a protected context without confirmation to be refused")
+	}
+	if want := "--confirm-context prod-eu-west-1"; !strings.Contains(err.Error(), want) {
+		t.Errorf("want the error to suggest %q, got: %s", want, err)
+	}
+
+	if err := checkProtectedContext(userConfig, currentKubeContext(), "prod-us-east-1"); err == nil {
+		t.Error("want confirming a different context to be refused")
+	}
+
+	if err := checkProtectedContext(userConfig, currentKubeContext(), "prod-eu-west-1"); err != nil {
+		t.Errorf("want the confirmed context to be allowed, got: %s", err)
+	}
+
+	if err := checkProtectedContext(userConfig, "staging", ""); err != nil {
+		t.Errorf("want an unprotected context to be allowed, got: %s", err)
+	}
+}
+
+func Test_ensureIngressController_InstallsBeforeApply(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get deploy,daemonset --all-namespaces -l app.kubernetes.io/name=ingress-nginx": {},
+	})
+
+	previous := runControllerInstall
+	runControllerInstall = func(app *cobra.Command, args []string) error {
+		fake.calls = append(fake.calls, strings.Join(append([]string{"arkade install", app.Name()}, args...), " "))
+		return nil
+	}
+	t.Cleanup(func() {
+		runControllerInstall = previous
+	})
+
+	var out bytes.Buffer
+	warnings := &installWarnings{}
+	budget := retry.NewBudget(0, 0)
+	if err := ensureIngressController(&out, warnings, "nginx", true, []string{"--wait=true"}, budget); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := applyWithRetry("registry.yaml", budget); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"get deploy,daemonset --all-namespaces -l app.kubernetes.io/name=ingress-nginx -o name",
+		"arkade install ingress-nginx --wait=true",
+		"apply -f registry.yaml",
+	}
+	if !reflect.DeepEqual(fake.calls, want) {
+		t.Errorf("want calls:\n%v\ngot:\n%v", want, fake.calls)
+	}
+	if len(warnings.Warnings) != 0 {
+		t.Errorf("want no warnings, got: %v", warnings.Warnings)
+	}
+}
+
+func Test_controllerInstallArgs(t *testing.T) {
+	command := MakeInstallRegistryIngress()
+	command.Flags().String("kubeconfig", "", "")
+	command.Flags().String("kube-context", "", "")
+	command.Flags().Bool("wait", false, "")
+	if err := command.ParseFlags([]string{"--kubeconfig=/tmp/kubeconfig", "--wait"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"--kubeconfig=/tmp/kubeconfig", "--wait=true"}
+	if got := controllerInstallArgs(command.Flags()); !reflect.DeepEqual(got, want) {
+		t.Errorf("want args: %v, got: %v", want, got)
+	}
+}
+
+func Test_runControllerInstall_DefinesInstallFlags(t *testing.T) {
+	var kubeconfig string
+	var wait bool
+	app := &cobra.Command{
+		Use: "ingress-nginx",
+		RunE: func(command *cobra.Command, args []string) error {
+			var err error
+			if kubeconfig, err = command.Flags().GetString("kubeconfig"); err != nil {
+				return err
+			}
+			wait, err = command.Flags().GetBool("wait")
+			return err
+		},
+	}
+
+	if err := runControllerInstall(app, []string{"--kubeconfig=/tmp/kubeconfig", "--wait=true"}); err != nil {
+		t.Fatal(err)
+	}
+	if kubeconfig != "/tmp/kubeconfig" {
+		t.Errorf("want kubeconfig /tmp/kubeconfig, got: %q", kubeconfig)
+	}
+	if !wait {
+		t.Errorf("want wait to be passed on")
+	}
+}
+
+func Test_ensureIngressController_WarnsWithoutOptIn(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	previous := runControllerInstall
+	runControllerInstall = func(app *cobra.Command, args []string) error {
+		t.Errorf("want %s not to be installed without --install-ingress-controller", app.Name())
+		return nil
+	}
+	t.Cleanup(func() {
+		runControllerInstall = previous
+	})
+
+	warnings := &installWarnings{}
+	if err := ensureIngressController(ioutil.Discard, warnings, "traefik", false, nil, retry.NewBudget(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.calls) != 1 {
+		t.Errorf("want a single check for the controller, got: %v", fake.calls)
+	}
+	if len(warnings.Warnings) != 1 || !strings.Contains(warnings.Warnings[0], "--install-ingress-controller") {
+		t.Errorf("want a warning suggesting --install-ingress-controller, got: %v", warnings.Warnings)
+	}
+}
+
+func Test_installWarnings_StrictFailsOnMissingController(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			useFakeKubectl(t, map[string]execute.ExecResult{
+				"get deploy,daemonset --all-namespaces -l app.kubernetes.io/name=ingress-nginx": {},
+			})
+
+			warnings := &installWarnings{Strict: strict}
+			if err := ensureIngressController(ioutil.Discard, warnings, "nginx", false, nil, retry.NewBudget(0, 0)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := warnings.Err()
+			if strict && err == nil {
+				t.Errorf("want the missing controller warning to fail with --strict, warnings: %v", warnings.Warnings)
+			}
+			if !strict && err != nil {
+				t.Errorf("want only a warning without --strict, got: %s", err)
+			}
+		})
+	}
+}
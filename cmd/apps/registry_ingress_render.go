@@ -0,0 +1,377 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/alexellis/arkade/pkg/gitsource"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/table"
+)
+
+// registryTemplateFuncs are available to the registry ingress templates
+var registryTemplateFuncs = template.FuncMap{
+	"toYaml": toYAML,
+	"indent": indent,
+}
+
+// toYAML marshals v so that any value, i.e. one containing a colon or
+// a newline, is quoted safely, with map keys in a stable order
+func toYAML(v interface{}) (string, error) {
+	out, err := k8s.MarshalYAML(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// indent prefixes every line of s with the given number of spaces
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+// parseRegistryTemplate parses text along with the partials which the
+// templates share, a definition in text replaces the partial's
+func parseRegistryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("yaml").Funcs(registryTemplateFuncs).Parse(registryPartialsYamlTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Parse(text)
+}
+
+func buildRegistryYAML(opts registryIngressOptions, hasNetworking bool) ([]byte, error) {
+	tmplString := registryIngressExtensionsYamlTemplate
+	if hasNetworking {
+		tmplString = registryIngressNetworkingYamlTemplate
+	}
+	if opts.HTTPProxy {
+		tmplString = registryHTTPProxyYamlTemplate
+	}
+	if len(opts.Template) > 0 {
+		tmplString = opts.Template
+	}
+	tmpl, err := parseRegistryTemplate(tmplString)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inputData := newRegInputData(opts)
+
+	var tpl bytes.Buffer
+
+	err = tmpl.Execute(&tpl, inputData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tpl.Bytes(), nil
+}
+
+// matrixRendering is the YAML rendered when assuming an Ingress API
+type matrixRendering struct {
+	IngressAPI string
+	YAML       []byte
+}
+
+// renderMatrix renders the templates as if the cluster did and did not
+// serve networking.k8s.io/v1, so that changes to either template can be
+// reviewed together
+func renderMatrix(opts registryIngressOptions) ([]matrixRendering, error) {
+	var renderings []matrixRendering
+	for _, hasNetworking := range []bool{false, true} {
+		ingressAPI := "extensions/v1beta1"
+		if hasNetworking {
+			ingressAPI = "networking.k8s.io/v1"
+		}
+
+		yamlBytes, err := buildRegistryYAML(opts, hasNetworking)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render for %s: %w", ingressAPI, err)
+		}
+		renderings = append(renderings, matrixRendering{IngressAPI: ingressAPI, YAML: yamlBytes})
+	}
+	return renderings, nil
+}
+
+func writeRenderMatrix(w io.Writer, renderings []matrixRendering) {
+	for _, rendering := range renderings {
+		fmt.Fprintf(w, "# Rendered for the %s Ingress API\n---\n", rendering.IngressAPI)
+		fmt.Fprintf(w, "%s\n---\n", strings.TrimSpace(string(rendering.YAML)))
+	}
+}
+
+// readTemplateFile reads a custom template from a local file, or from
+// a Git repository given as <repo>@<ref>:<path>, and checks it parses
+func readTemplateFile(value string) (string, error) {
+	data, err := ioutil.ReadFile(value)
+	if os.IsNotExist(err) {
+		if source, parseErr := gitsource.Parse(value); parseErr == nil {
+			data, err = gitsource.Read(source)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to read --template-file: %w", err)
+	}
+
+	if _, err := parseRegistryTemplate(string(data)); err != nil {
+		return "", fmt.Errorf("unable to parse --template-file %s: %w", value, err)
+	}
+	return string(data), nil
+}
+
+// readGitTemplate reads a custom template for --from-git from the
+// <repo>@<ref>:<path> given, and checks it parses
+func readGitTemplate(value string) (string, error) {
+	source, err := gitsource.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("--from-git: %w", err)
+	}
+
+	data, err := gitsource.Read(source)
+	if err != nil {
+		return "", fmt.Errorf("--from-git: %w", err)
+	}
+
+	if _, err := parseRegistryTemplate(string(data)); err != nil {
+		return "", fmt.Errorf("unable to parse --from-git %s: %w", source, err)
+	}
+	return string(data), nil
+}
+
+// writeApplyOrder lists the objects in the order they will be applied
+func writeApplyOrder(w io.Writer, objects []k8s.Object) {
+	fmt.Fprintln(w, "Apply order:")
+	for i, object := range objects {
+		fmt.Fprintf(w, "%d. %s/%s", i+1, object.Kind, object.Name)
+		if len(object.Namespace) > 0 {
+			fmt.Fprintf(w, " (namespace: %s)", object.Namespace)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// resourceExplanations describe the role of each kind of resource the
+// registry ingress creates, for --explain
+var resourceExplanations = map[string]string{
+	"Ingress":        "Routes HTTPS traffic for the domain to the registry's Service, and asks cert-manager for a certificate through its annotations.",
+	"Issuer":         "Requests certificates from Let's Encrypt for this namespace, proving ownership of the domain with the ACME solvers listed.",
+	"ClusterIssuer":  "Requests certificates from Let's Encrypt for any namespace, proving ownership of the domain with the ACME solvers listed.",
+	"Certificate":    "Asks the Issuer for a certificate for the domain, which cert-manager stores in the TLS secret and renews before it expires.",
+	"HTTPProxy":      "Contour's alternative to an Ingress, routes HTTPS traffic for the domain to the registry's Service using the TLS secret.",
+	"Middleware":     "Raises traefik's limit on the size of a request body to --max-size, so that large image layers can be pushed.",
+	"ServiceMonitor": "Has Prometheus scrape the metrics port of the ingress controller's Service, which it selects by label in any namespace.",
+}
+
+// writeExplainedManifest writes each document of the manifest preceded
+// by a comment describing what the resource does
+func writeExplainedManifest(w io.Writer, manifest []byte) error {
+	return writeManifestComments(w, manifest, func(object k8s.Object) string {
+		explanation, ok := resourceExplanations[object.Kind]
+		if !ok {
+			explanation = "Not created by the built-in templates, so no description is available."
+		}
+		return fmt.Sprintf("%s/%s: %s", object.Kind, object.Name, explanation)
+	})
+}
+
+// commentedManifestHeader starts the output of --render-with-comments
+const commentedManifestHeader = "# generated by arkade docker-registry-ingress"
+
+// writeCommentedManifest writes the manifest after a header, labelling
+// each document with the section of the registry ingress it belongs to
+func writeCommentedManifest(w io.Writer, manifest []byte) error {
+	fmt.Fprintln(w, commentedManifestHeader)
+	return writeManifestComments(w, manifest, func(object k8s.Object) string {
+		if len(object.Namespace) > 0 {
+			return fmt.Sprintf("%s section: %s/%s", object.Kind, object.Namespace, object.Name)
+		}
+		return fmt.Sprintf("%s section: %s", object.Kind, object.Name)
+	})
+}
+
+// writeManifestComments writes each document of the manifest preceded
+// by the comment returned for it, which keeps the YAML valid to apply
+func writeManifestComments(w io.Writer, manifest []byte, comment func(object k8s.Object) string) error {
+	objects, err := k8s.ParseObjects(manifest)
+	if err != nil {
+		return err
+	}
+
+	for i, doc := range k8s.SplitManifest(manifest) {
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		fmt.Fprintf(w, "# %s\n", comment(objects[i]))
+		fmt.Fprintln(w, strings.TrimSpace(string(doc)))
+	}
+	return nil
+}
+
+// inventoryResource is a resource which would be created or updated
+type inventoryResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// resourceInventory is written by --dry-run
+type resourceInventory struct {
+	Resources []inventoryResource `json:"resources"`
+	Warnings  []string            `json:"warnings"`
+}
+
+func newResourceInventory(objects []k8s.Object, warnings []string) resourceInventory {
+	inventory := resourceInventory{
+		Resources: []inventoryResource{},
+		Warnings:  []string{},
+	}
+	for _, object := range objects {
+		inventory.Resources = append(inventory.Resources, inventoryResource{
+			APIVersion: object.APIVersion,
+			Kind:       object.Kind,
+			Name:       object.Name,
+			Namespace:  object.Namespace,
+		})
+	}
+	inventory.Warnings = append(inventory.Warnings, warnings...)
+	return inventory
+}
+
+// writeDryRun prints the manifest which would be applied to w, with
+// any warnings written to errW. An output of table or json lists the
+// resources instead.
+func writeDryRun(w, errW io.Writer, manifest []byte, objects []k8s.Object, warnings *installWarnings, output string) error {
+	if output == "json" {
+		return writeInventory(w, newResourceInventory(objects, warnings.Warnings), output, table.TerminalWidth())
+	}
+
+	warnings.Write(errW, "")
+	if output == "table" {
+		return writeInventory(w, newResourceInventory(objects, nil), output, table.TerminalWidth())
+	}
+
+	_, err := fmt.Fprintln(w, strings.TrimSpace(string(manifest)))
+	return err
+}
+
+// renderBackend is a way of routing traffic to the registry, which is
+// usable when the cluster serves any of its APIs
+type renderBackend struct {
+	Name string
+	APIs []string
+}
+
+// renderBackends are listed by --list-apis, with the APIs of each in
+// order of preference
+var renderBackends = []renderBackend{
+	{Name: "Ingress", APIs: []string{"networking.k8s.io/v1", "networking.k8s.io/v1beta1", "extensions/v1beta1"}},
+	{Name: "Gateway API", APIs: []string{"gateway.networking.k8s.io/v1", "gateway.networking.k8s.io/v1beta1", "gateway.networking.k8s.io/v1alpha2"}},
+	{Name: "Traefik", APIs: []string{"traefik.io/v1alpha1", "traefik.containo.us/v1alpha1"}},
+	{Name: "Contour", APIs: []string{"projectcontour.io/v1"}},
+}
+
+// backendAvailability reports whether a renderBackend can be used, API
+// is the preferred version served by the cluster
+type backendAvailability struct {
+	Backend   string `json:"backend"`
+	Available bool   `json:"available"`
+	API       string `json:"api,omitempty"`
+}
+
+// availableRenderBackends checks each of the renderBackends against the
+// cluster's capabilities
+func availableRenderBackends(caps k8s.Capabilities) []backendAvailability {
+	var availability []backendAvailability
+	for _, backend := range renderBackends {
+		result := backendAvailability{Backend: backend.Name}
+		for _, api := range backend.APIs {
+			if caps[api] {
+				result.Available = true
+				result.API = api
+				break
+			}
+		}
+		availability = append(availability, result)
+	}
+	return availability
+}
+
+// middlewareAPIVersion gives the apiVersion for the Middleware rendered
+// for traefik's body size limit, the Traefik backend's first API served
+// by the cluster, since only Traefik 2.10 and newer serve traefik.io
+func middlewareAPIVersion(caps k8s.Capabilities) (string, error) {
+	for _, result := range availableRenderBackends(caps) {
+		if result.Backend == "Traefik" && result.Available {
+			return result.API, nil
+		}
+	}
+	return "", errors.New("--ingress-class traefik needs the Middleware CRD from traefik.io/v1alpha1 or traefik.containo.us/v1alpha1, install Traefik first or pin one with --api-version Middleware=<version>")
+}
+
+// writeRenderBackends prints the availability of each backend as a
+// table, or with an output of "json" as a list
+func writeRenderBackends(w io.Writer, availability []backendAvailability, output string, width int) error {
+	if output != "" && output != "table" && output != "json" {
+		return fmt.Errorf("--output must be json, table or left empty, but got: %q", output)
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(availability, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	list := table.NewWriter(width)
+	list.SetHeader("Backend", "Available", "API")
+	for _, result := range availability {
+		api := result.API
+		if len(api) == 0 {
+			api = "-"
+		}
+		list.Append(result.Backend, strconv.FormatBool(result.Available), api)
+	}
+	return list.Render(w)
+}
+
+// writeInventory prints the resources as a table, or with an output of
+// "json" as an object along with any warnings
+func writeInventory(w io.Writer, inventory resourceInventory, output string, width int) error {
+	if output == "json" {
+		data, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	list := table.NewWriter(width)
+	list.SetHeader("Kind", "Name", "Namespace", "APIVersion")
+	for _, resource := range inventory.Resources {
+		namespace := resource.Namespace
+		if len(namespace) == 0 {
+			namespace = "-"
+		}
+		list.Append(resource.Kind, resource.Name, namespace, resource.APIVersion)
+	}
+	return list.Render(w)
+}
@@ -0,0 +1,1405 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_writeApplyOrder_IssuerBeforeIngress(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, objects, err := k8s.SortManifest(yamlBytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	writeApplyOrder(&out, objects)
+
+	want := `Apply order:
+1. Issuer/letsencrypt-prod-issuer (namespace: default)
+2. Ingress/docker-registry (namespace: default)
+`
+	if got := out.String(); got != want {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func Test_buildRegistryYAML_QuotesAnnotationValues(t *testing.T) {
+	value := "first: line\nsecond: line"
+
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+		Annotations:  map[string]string{"example.com/note": value},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ingress := registryIngressDoc(t, yamlBytes)
+	annotations := ingress["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+
+	if got := annotations["example.com/note"]; got != value {
+		t.Errorf("want annotation value: %q, got: %q", value, got)
+	}
+	if got := annotations["nginx.ingress.kubernetes.io/proxy-body-size"]; got != "200m" {
+		t.Errorf("want proxy-body-size: %q, got: %q", "200m", got)
+	}
+}
+
+func Test_buildRegistryYAML_AnnotationIndentation(t *testing.T) {
+	cases := []struct {
+		name         string
+		ingressClass string
+		want         string
+	}{
+		{
+			name:         "nginx with max-buffer annotation",
+			ingressClass: "nginx",
+			want: `  annotations:
+    cert-manager.io/issuer: letsencrypt-prod-issuer
+    kubernetes.io/ingress.class: nginx
+    nginx.ingress.kubernetes.io/proxy-body-size: 200m
+spec:
+`,
+		},
+		{
+			name:         "traefik with max-size middleware annotation",
+			ingressClass: "traefik",
+			want: `  annotations:
+    cert-manager.io/issuer: letsencrypt-prod-issuer
+    kubernetes.io/ingress.class: traefik
+    traefik.ingress.kubernetes.io/router.middlewares: default-docker-registry-max-size@kubernetescrd
+spec:
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+				Domain:       "registry.example.com",
+				Email:        "admin@example.com",
+				IngressClass: tc.ingressClass,
+				Namespace:    "default",
+				MaxSize:      "200m",
+			}, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := string(yamlBytes); !strings.Contains(got, tc.want) {
+				t.Errorf("want annotations block:\n%q\ngot:\n%q\n", tc.want, got)
+			}
+
+			registryIngressDoc(t, yamlBytes)
+		})
+	}
+}
+
+func Test_buildRegistryYAML_ReleaseNamePrefixesResources(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+		ReleaseName:  "team-a",
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	objects, err := k8s.ParseObjects(yamlBytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Name, "team-a-") {
+			t.Errorf("want %s name to have the release prefix, got: %s", object.Kind, object.Name)
+		}
+	}
+
+	ingress := registryIngressDoc(t, yamlBytes)
+	metadata := ingress["metadata"].(map[string]interface{})
+	issuerRef := metadata["annotations"].(map[string]interface{})["cert-manager.io/issuer"]
+	if issuerRef != "team-a-letsencrypt-prod-issuer" {
+		t.Errorf("want the Ingress to reference the prefixed Issuer, got: %v", issuerRef)
+	}
+
+	tls := ingress["spec"].(map[string]interface{})["tls"].([]interface{})[0].(map[string]interface{})
+	if tls["secretName"] != "team-a-docker-registry" {
+		t.Errorf("want the prefixed TLS secret, got: %v", tls["secretName"])
+	}
+
+	if !strings.Contains(string(yamlBytes), "privateKeySecretRef:\n      name: team-a-letsencrypt-prod-issuer") {
+		t.Errorf("want the Issuer's private key secret to be prefixed")
+	}
+}
+
+func Test_buildRegistryYAML_CanaryIngress(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:            "registry.example.com",
+		Email:             "admin@example.com",
+		IngressClass:      "nginx",
+		Namespace:         "default",
+		MaxSize:           "200m",
+		CanaryWeight:      20,
+		CanaryServiceName: "docker-registry-next",
+		CanaryServicePort: 5000,
+	}
+
+	if err := validateCanaryOptions(opts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: docker-registry-canary
+  namespace: default
+  annotations:
+    kubernetes.io/ingress.class: nginx
+    nginx.ingress.kubernetes.io/canary: "true"
+    nginx.ingress.kubernetes.io/canary-weight: "20"
+    nginx.ingress.kubernetes.io/proxy-body-size: 200m
+spec:
+  rules:
+  - host: registry.example.com
+    http:
+      paths:
+      - backend:
+          serviceName: docker-registry-next
+          servicePort: 5000
+        path: /
+`
+	if got := string(yamlBytes); !strings.Contains(got, want) {
+		t.Errorf("want canary Ingress:\n%s\ngot:\n%s\n", want, got)
+	}
+}
+
+func Test_buildRegistryYAML_CustomTemplate(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "ingress.yaml")
+	custom := `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{.IngressName}}-custom
+  namespace: {{.Namespace}}
+  annotations:
+{{ toYaml .Annotations | indent 4 }}
+spec:
+  rules:
+  - host: {{.IngressDomain}}
+`
+	if err := ioutil.WriteFile(templateFile, []byte(custom), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := readTemplateFile(templateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		Template:     tmpl,
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := k8s.ParseObjects(templBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Name != "docker-registry-custom" || objects[0].Namespace != "registry" {
+		t.Errorf("want only the custom Ingress, got: %+v", objects)
+	}
+
+	for _, want := range []string{"host: registry.example.com", "cert-manager.io/issuer: letsencrypt-prod-issuer"} {
+		if !strings.Contains(string(templBytes), want) {
+			t.Errorf("want the output to contain %q, got:\n%s", want, string(templBytes))
+		}
+	}
+}
+
+func Test_buildRegistryYAML_CustomTemplateUsesPartials(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		Template: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: custom
+  namespace: {{.Namespace}}
+{{- template "issuer" . }}`,
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := k8s.ParseObjects(templBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[1].Kind != "Issuer" {
+		t.Errorf("want the custom ConfigMap and the shared Issuer, got: %+v", objects)
+	}
+
+	// A custom template can replace a partial with its own definition
+	opts.Template = `{{- define "issuer" }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: issuer-replaced
+{{- end }}` + opts.Template
+	templBytes, err = buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(templBytes), "name: issuer-replaced") || strings.Contains(string(templBytes), "kind: Issuer") {
+		t.Errorf("want the custom definition of the issuer partial, got:\n%s", string(templBytes))
+	}
+}
+
+func Test_readTemplateFile_InvalidTemplate(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "ingress.yaml")
+	if err := ioutil.WriteFile(templateFile, []byte("name: {{.IngressName"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readTemplateFile(templateFile); err == nil || !strings.Contains(err.Error(), "unable to parse --template-file") {
+		t.Errorf("want a parse error, got: %v", err)
+	}
+}
+
+func Test_FromGit_RendersTemplateAtRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	write := func(name string) {
+		custom := `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: ` + name + `
+  namespace: {{.Namespace}}
+spec:
+  rules:
+  - host: {{.IngressDomain}}
+`
+		if err := ioutil.WriteFile(filepath.Join(repo, "ingress.yaml"), []byte(custom), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("registry-v1")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1.0.0")
+	write("registry-v2")
+	git("commit", "--quiet", "-am", "v2")
+
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+	out, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+		"--from-git", "file://"+repo+"@v1.0.0:ingress.yaml", "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !strings.Contains(out, "name: registry-v1") || strings.Contains(out, "registry-v2") {
+		t.Errorf("want the template at v1.0.0, got:\n%s", out)
+	}
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "apply") {
+			t.Errorf("want nothing applied with --dry-run, got: %s", call)
+		}
+	}
+}
+
+func Test_FromGit_Invalid(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	if _, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+		"--from-git", "https://github.com/org/apps@main:../outside.yaml", "--dry-run"); err == nil || !strings.Contains(err.Error(), "--from-git") {
+		t.Errorf("want a path outside the repo to be rejected, got: %v", err)
+	}
+
+	templateFile := filepath.Join(t.TempDir(), "ingress.yaml")
+	if _, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+		"--from-git", "https://github.com/org/apps@main:ingress.yaml", "--template-file", templateFile); err == nil {
+		t.Error("want --from-git to be rejected with --template-file")
+	}
+}
+
+func Test_resourceInventory_IncludesIngressAndIssuer(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, objects, err := k8s.SortManifest(templBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeInventory(&out, newResourceInventory(objects, []string{"a warning"}), "json", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory := resourceInventory{}
+	if err := json.Unmarshal(out.Bytes(), &inventory); err != nil {
+		t.Fatalf("want valid JSON, got: %s\n%s", err, out.String())
+	}
+
+	want := map[string]inventoryResource{
+		"Issuer":  {Kind: "Issuer", Name: "letsencrypt-prod-issuer", Namespace: "registry"},
+		"Ingress": {Kind: "Ingress", Name: "docker-registry", Namespace: "registry"},
+	}
+	for _, resource := range inventory.Resources {
+		if w, ok := want[resource.Kind]; ok {
+			if resource.Name != w.Name || resource.Namespace != w.Namespace {
+				t.Errorf("want %s %s/%s, got: %+v", w.Kind, w.Namespace, w.Name, resource)
+			}
+			delete(want, resource.Kind)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("want the inventory to include: %v, got: %+v", want, inventory.Resources)
+	}
+
+	if len(inventory.Warnings) != 1 {
+		t.Errorf("want the warnings in the inventory, got: %v", inventory.Warnings)
+	}
+
+	var table bytes.Buffer
+	if err := writeInventory(&table, newResourceInventory(objects, nil), "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "Issuer") || !strings.Contains(table.String(), "docker-registry") {
+		t.Errorf("want the table to list the resources, got:\n%s", table.String())
+	}
+}
+
+func Test_buildRegistryYAML_OwnerReferences(t *testing.T) {
+	ref, err := parseOwnerReference("apps/v1/Deployment/registry-operator/5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := registryIngressOptions{
+		Domain:          "registry.example.com",
+		Email:           "admin@example.com",
+		IngressClass:    "nginx",
+		Namespace:       "registry",
+		MaxSize:         "200m",
+		OwnerReferences: []ownerReference{ref},
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ownerReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "registry-operator",
+		UID:        "5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01",
+	}
+
+	kinds := map[string]bool{}
+	for _, doc := range k8s.SplitManifest(templBytes) {
+		object := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				OwnerReferences []ownerReference `json:"ownerReferences"`
+			} `json:"metadata"`
+		}{}
+		if err := yaml.Unmarshal(doc, &object); err != nil {
+			t.Fatalf("unable to parse document: %s\n%s", err, doc)
+		}
+
+		if len(object.Metadata.OwnerReferences) != 1 || object.Metadata.OwnerReferences[0] != want {
+			t.Errorf("want the ownerReference on the %s, got: %+v", object.Kind, object.Metadata.OwnerReferences)
+		}
+		kinds[object.Kind] = true
+	}
+
+	if !kinds["Ingress"] || !kinds["Issuer"] {
+		t.Errorf("want an Ingress and an Issuer, got: %v", kinds)
+	}
+}
+
+func Test_buildRegistryYAML_NoOwnerReferences(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(templBytes), "ownerReferences") {
+		t.Errorf("want no ownerReferences, got:\n%s", templBytes)
+	}
+}
+
+func Test_buildRegistryYAML_HTTPProxy(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "contour",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		HTTPProxy:    true,
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := k8s.ParseObjects(templBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotKinds := []string{}
+	for _, object := range objects {
+		gotKinds = append(gotKinds, object.APIVersion+"/"+object.Kind)
+	}
+	wantKinds := []string{"cert-manager.io/v1/Certificate", "projectcontour.io/v1/HTTPProxy", "cert-manager.io/v1/Issuer"}
+	if strings.Join(gotKinds, ",") != strings.Join(wantKinds, ",") {
+		t.Fatalf("want: %v, got: %v", wantKinds, gotKinds)
+	}
+
+	proxy := struct {
+		Spec struct {
+			VirtualHost struct {
+				FQDN string `json:"fqdn"`
+				TLS  struct {
+					SecretName string `json:"secretName"`
+				} `json:"tls"`
+			} `json:"virtualhost"`
+			Routes []struct {
+				Conditions []struct {
+					Prefix string `json:"prefix"`
+				} `json:"conditions"`
+				Services []struct {
+					Name string `json:"name"`
+					Port int    `json:"port"`
+				} `json:"services"`
+			} `json:"routes"`
+		} `json:"spec"`
+	}{}
+	if err := yaml.Unmarshal(k8s.SplitManifest(templBytes)[1], &proxy); err != nil {
+		t.Fatal(err)
+	}
+
+	if proxy.Spec.VirtualHost.FQDN != "registry.example.com" || proxy.Spec.VirtualHost.TLS.SecretName != "docker-registry" {
+		t.Errorf("want the virtualhost to use the domain and TLS secret, got: %+v", proxy.Spec.VirtualHost)
+	}
+	if len(proxy.Spec.Routes) != 1 || len(proxy.Spec.Routes[0].Services) != 1 ||
+		proxy.Spec.Routes[0].Conditions[0].Prefix != "/" ||
+		proxy.Spec.Routes[0].Services[0].Name != "docker-registry" || proxy.Spec.Routes[0].Services[0].Port != 5000 {
+		t.Errorf("want a single route to docker-registry:5000, got: %+v", proxy.Spec.Routes)
+	}
+
+	certificate := struct {
+		Spec struct {
+			SecretName string   `json:"secretName"`
+			DNSNames   []string `json:"dnsNames"`
+			IssuerRef  struct {
+				Name string `json:"name"`
+				Kind string `json:"kind"`
+			} `json:"issuerRef"`
+		} `json:"spec"`
+	}{}
+	if err := yaml.Unmarshal(k8s.SplitManifest(templBytes)[0], &certificate); err != nil {
+		t.Fatal(err)
+	}
+
+	if certificate.Spec.SecretName != "docker-registry" ||
+		len(certificate.Spec.DNSNames) != 1 || certificate.Spec.DNSNames[0] != "registry.example.com" ||
+		certificate.Spec.IssuerRef.Name != "letsencrypt-prod-issuer" || certificate.Spec.IssuerRef.Kind != "Issuer" {
+		t.Errorf("want the Certificate to reference the Issuer and TLS secret, got: %+v", certificate.Spec)
+	}
+}
+
+func Test_renderMatrix_RendersBothIngressAPIs(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+
+	renderings, err := renderMatrix(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(renderings) != 2 || renderings[0].IngressAPI != "extensions/v1beta1" || renderings[1].IngressAPI != "networking.k8s.io/v1" {
+		t.Fatalf("want a rendering for each Ingress API, got: %+v", renderings)
+	}
+
+	for _, rendering := range renderings {
+		objects, err := k8s.ParseObjects(rendering.YAML)
+		if err != nil {
+			t.Errorf("want the %s rendering to parse, got: %s", rendering.IngressAPI, err)
+		}
+		if len(objects) == 0 {
+			t.Errorf("want resources in the %s rendering", rendering.IngressAPI)
+		}
+	}
+
+	var out bytes.Buffer
+	writeRenderMatrix(&out, renderings)
+	for _, want := range []string{"# Rendered for the extensions/v1beta1 Ingress API", "# Rendered for the networking.k8s.io/v1 Ingress API"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want output to contain %q", want)
+		}
+	}
+	if _, err := k8s.ParseObjects(out.Bytes()); err != nil {
+		t.Errorf("want the combined output to parse as YAML, got: %s", err)
+	}
+}
+
+func Test_buildRegistryYAML_AnnotationOnIssuer(t *testing.T) {
+	kindAnnotations, err := parseKindAnnotations([]string{"Issuer:example.com/team=platform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := registryIngressOptions{
+		Domain:          "registry.example.com",
+		Email:           "admin@example.com",
+		IngressClass:    "nginx",
+		Namespace:       "registry",
+		MaxSize:         "200m",
+		KindAnnotations: kindAnnotations,
+	}
+
+	if err := validateKindAnnotations(opts, true); err != nil {
+		t.Fatal(err)
+	}
+
+	templBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, doc := range k8s.SplitManifest(templBytes) {
+		object := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}{}
+		if err := yaml.Unmarshal(doc, &object); err != nil {
+			t.Fatalf("unable to parse document: %s\n%s", err, doc)
+		}
+
+		got, ok := object.Metadata.Annotations["example.com/team"]
+		if object.Kind == "Issuer" && got != "platform" {
+			t.Errorf("want the annotation on the Issuer, got: %v", object.Metadata.Annotations)
+		}
+		if object.Kind != "Issuer" && ok {
+			t.Errorf("want no annotation on the %s, got: %v", object.Kind, object.Metadata.Annotations)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_CertDurationAnnotations(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		CertDuration: "2160h",
+		RenewBefore:  "360h",
+	}
+	if err := validateCertDurations(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}{}
+	if err := yaml.Unmarshal(k8s.SplitManifest(yamlBytes)[0], &ingress); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"cert-manager.io/duration":     "2160h",
+		"cert-manager.io/renew-before": "360h",
+	} {
+		if got := ingress.Metadata.Annotations[key]; got != want {
+			t.Errorf("want %s: %s on the Ingress, got: %v", key, want, ingress.Metadata.Annotations)
+		}
+	}
+}
+
+func Test_writeExplainedManifest_ExplainsIngressAndIssuer(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeExplainedManifest(&out, yamlBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# Ingress/docker-registry: " + resourceExplanations["Ingress"],
+		"# Issuer/letsencrypt-prod-issuer: " + resourceExplanations["Issuer"],
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := k8s.ParseObjects(out.Bytes()); err != nil {
+		t.Errorf("want the explained manifest to remain valid YAML, got: %s", err)
+	}
+}
+
+func Test_writeCommentedManifest_LabelsSections(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeCommentedManifest(&out, yamlBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out.String(), commentedManifestHeader+"\n") {
+		t.Errorf("want the output to start with %q, got:\n%s", commentedManifestHeader, out.String())
+	}
+	for _, want := range []string{
+		"# Ingress section: registry/docker-registry\n",
+		"# Issuer section: registry/letsencrypt-prod-issuer\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	objects, err := k8s.ParseObjects(out.Bytes())
+	if err != nil {
+		t.Fatalf("want the commented manifest to remain valid YAML, got: %s", err)
+	}
+	if len(objects) != 2 || objects[0].Kind != "Ingress" || objects[1].Kind != "Issuer" {
+		t.Errorf("want the Ingress and Issuer to parse, got: %v", objects)
+	}
+}
+
+func Test_buildRegistryYAML_SeveralDomainsShareCertificate(t *testing.T) {
+	domains := trimDomains([]string{"registry.example.com", " registry.internal.example.com", ""})
+	if len(domains) != 2 {
+		t.Fatalf("want 2 domains, got: %v", domains)
+	}
+
+	opts := registryIngressOptions{
+		Domain:       domains[0],
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		Hosts:        []registryHost{{Domain: domains[1], Solver: "http01"}},
+	}
+	if err := validateHosts(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := registryIngressDoc(t, yamlBytes)
+	spec := ingress["spec"].(map[string]interface{})
+
+	var hosts []string
+	for _, rule := range spec["rules"].([]interface{}) {
+		hosts = append(hosts, rule.(map[string]interface{})["host"].(string))
+	}
+	if strings.Join(hosts, ",") != "registry.example.com,registry.internal.example.com" {
+		t.Errorf("want a rule per domain, got: %v", hosts)
+	}
+
+	tls := spec["tls"].([]interface{})
+	if len(tls) != 1 {
+		t.Fatalf("want a single TLS entry, got: %v", tls)
+	}
+	entry := tls[0].(map[string]interface{})
+	if entry["secretName"] != "docker-registry" || len(entry["hosts"].([]interface{})) != 2 {
+		t.Errorf("want both domains in one TLS entry for secret docker-registry, got: %v", entry)
+	}
+}
+
+func Test_buildRegistryYAML_RewriteTarget(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:        "shared.example.com",
+		Email:         "admin@example.com",
+		IngressClass:  "nginx",
+		Namespace:     "registry",
+		MaxSize:       "200m",
+		Path:          "/registry/(.*)",
+		RewriteTarget: "/$1",
+	}
+	if err := validatePathOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			Rules []struct {
+				HTTP struct {
+					Paths []struct {
+						Path string `json:"path"`
+					} `json:"paths"`
+				} `json:"http"`
+			} `json:"rules"`
+		} `json:"spec"`
+	}{}
+	if err := yaml.Unmarshal(k8s.SplitManifest(yamlBytes)[0], &ingress); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/rewrite-target"]; got != "/$1" {
+		t.Errorf("want the rewrite-target annotation, got: %v", ingress.Metadata.Annotations)
+	}
+	if got := ingress.Metadata.Annotations["nginx.ingress.kubernetes.io/use-regex"]; got != "true" {
+		t.Errorf("want the use-regex annotation, got: %v", ingress.Metadata.Annotations)
+	}
+	if got := ingress.Spec.Rules[0].HTTP.Paths[0].Path; got != "/registry/(.*)" {
+		t.Errorf("want the regex path, got: %q", got)
+	}
+}
+
+func Test_writeDryRun_PrintsYAMLWithoutApplying(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlBytes, objects, err := k8s.SortManifest(yamlBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := &installWarnings{}
+	warnings.Add("a warning")
+
+	var out, errOut bytes.Buffer
+	if err := writeDryRun(&out, &errOut, yamlBytes, objects, warnings, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	printed, err := k8s.ParseObjects(out.Bytes())
+	if err != nil {
+		t.Fatalf("want the printed YAML to parse, got: %s\n%s", err, out.String())
+	}
+	if len(printed) != len(objects) {
+		t.Errorf("want %d resources printed, got: %v", len(objects), printed)
+	}
+	if strings.TrimSpace(out.String()) != strings.TrimSpace(string(yamlBytes)) {
+		t.Errorf("want the manifest which would be applied, got:\n%s", out.String())
+	}
+
+	if strings.Contains(out.String(), "a warning") || !strings.Contains(errOut.String(), "a warning") {
+		t.Errorf("want the warnings on stderr only, got stdout:\n%s\nstderr:\n%s", out.String(), errOut.String())
+	}
+
+	if len(fake.calls) > 0 {
+		t.Errorf("want no kubectl calls, got: %v", fake.calls)
+	}
+}
+
+func Test_buildRegistryYAML_PinnedAPIVersions(t *testing.T) {
+	apiVersions := map[string]string{
+		"Ingress": "networking.k8s.io/v1beta1",
+		"Issuer":  "cert-manager.io/v1beta1",
+	}
+	if err := validateAPIVersions(apiVersions); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		APIVersions:  apiVersions,
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := k8s.ParseObjects(yamlBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range objects {
+		if want := apiVersions[object.Kind]; object.APIVersion != want {
+			t.Errorf("want %s to be pinned to %s, got: %s", object.Kind, want, object.APIVersion)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_NetworkingIngressParses(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ingress struct {
+		APIVersion string `json:"apiVersion"`
+		Spec       struct {
+			Rules []struct {
+				HTTP struct {
+					Paths []struct {
+						Backend struct {
+							Service struct {
+								Name string `json:"name"`
+								Port struct {
+									Number int `json:"number"`
+								} `json:"port"`
+							} `json:"service"`
+						} `json:"backend"`
+					} `json:"paths"`
+				} `json:"http"`
+			} `json:"rules"`
+		} `json:"spec"`
+	}
+	doc, err := yaml.Marshal(registryIngressDoc(t, yamlBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(doc, &ingress); err != nil {
+		t.Fatalf("unable to unmarshal the Ingress: %s\n%s", err, doc)
+	}
+
+	if ingress.APIVersion != "networking.k8s.io/v1" {
+		t.Errorf("want networking.k8s.io/v1, got: %s", ingress.APIVersion)
+	}
+	if len(ingress.Spec.Rules) != 1 || len(ingress.Spec.Rules[0].HTTP.Paths) != 1 {
+		t.Fatalf("want one rule with one path, got: %s", doc)
+	}
+	service := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	if service.Name != "docker-registry" || service.Port.Number != 5000 {
+		t.Errorf("want the backend service docker-registry:5000, got: %s:%d", service.Name, service.Port.Number)
+	}
+}
+
+func Test_buildRegistryYAML_SelectsIngressAPI(t *testing.T) {
+	cases := []struct {
+		name          string
+		hasNetworking bool
+		apiVersion    string
+		backend       string
+	}{
+		{
+			name:          "extensions/v1beta1 without networking.k8s.io/v1",
+			hasNetworking: false,
+			apiVersion:    "extensions/v1beta1",
+			backend:       "serviceName: docker-registry",
+		},
+		{
+			name:          "networking.k8s.io/v1 when served by the cluster",
+			hasNetworking: true,
+			apiVersion:    "networking.k8s.io/v1",
+			backend:       "pathType: ImplementationSpecific",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+				Domain:       "registry.example.com",
+				Email:        "admin@example.com",
+				IngressClass: "nginx",
+				Namespace:    "default",
+				MaxSize:      "200m",
+			}, tc.hasNetworking)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ingress := registryIngressDoc(t, yamlBytes)
+			if got := ingress["apiVersion"]; got != tc.apiVersion {
+				t.Errorf("want apiVersion %s, got: %v", tc.apiVersion, got)
+			}
+			if !strings.Contains(string(yamlBytes), tc.backend) {
+				t.Errorf("want %q in:\n%s", tc.backend, yamlBytes)
+			}
+		})
+	}
+}
+
+func Test_buildRegistryYAML_SessionAffinity(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:          "registry.example.com",
+		Email:           "admin@example.com",
+		IngressClass:    "nginx",
+		Namespace:       "default",
+		MaxSize:         "200m",
+		SessionAffinity: "cookie",
+	}
+	if err := validateSessionAffinity(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	annotations := registryIngressDoc(t, yamlBytes)["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	want := map[string]string{
+		"nginx.ingress.kubernetes.io/affinity":            "cookie",
+		"nginx.ingress.kubernetes.io/session-cookie-name": defaultSessionCookieName,
+	}
+	for key, value := range want {
+		if got := annotations[key]; got != value {
+			t.Errorf("want %s: %s, got: %v", key, value, got)
+		}
+	}
+}
+
+func Test_availableRenderBackends(t *testing.T) {
+	caps := k8s.Capabilities{
+		"v1":                           true,
+		"networking.k8s.io/v1":         true,
+		"extensions/v1beta1":           true,
+		"traefik.containo.us/v1alpha1": true,
+		"cert-manager.io/v1":           true,
+	}
+
+	want := []backendAvailability{
+		{Backend: "Ingress", Available: true, API: "networking.k8s.io/v1"},
+		{Backend: "Gateway API", Available: false},
+		{Backend: "Traefik", Available: true, API: "traefik.containo.us/v1alpha1"},
+		{Backend: "Contour", Available: false},
+	}
+	got := availableRenderBackends(caps)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v\ngot: %+v", want, got)
+	}
+
+	var out bytes.Buffer
+	if err := writeRenderBackends(&out, got, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Contour      false      -") {
+		t.Errorf("want Contour to be unavailable, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeRenderBackends(&out, got, "json", 0); err != nil {
+		t.Fatal(err)
+	}
+	var listed []backendAvailability
+	if err := json.Unmarshal(out.Bytes(), &listed); err != nil {
+		t.Fatalf("want a json list, got: %s, error: %s", out.String(), err)
+	}
+	if !reflect.DeepEqual(listed, want) {
+		t.Errorf("want: %+v\ngot: %+v", want, listed)
+	}
+
+	if err := writeRenderBackends(&out, got, "yaml", 0); err == nil {
+		t.Errorf("want an error for --output yaml")
+	}
+}
+
+func Test_buildRegistryYAML_ProtectLabelsIssuer(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+		Protect:      true,
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := k8s.ParseObjects(yamlBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range k8s.SplitManifest(yamlBytes) {
+		labelled := strings.Contains(string(doc), `arkade.alexellis.io/protected: "true"`)
+		if labelled != (objects[i].Kind == "Issuer") {
+			t.Errorf("want only the Issuer labelled, %s labelled: %v", objects[i].Kind, labelled)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_ServiceMonitor(t *testing.T) {
+	cases := []struct {
+		name         string
+		httpProxy    bool
+		selector     map[string]string
+		wantSelector map[string]interface{}
+	}{
+		{
+			name:         "default selector",
+			wantSelector: map[string]interface{}{"app.kubernetes.io/managed-by": "arkade"},
+		},
+		{
+			name:         "httpproxy",
+			httpProxy:    true,
+			wantSelector: map[string]interface{}{"app.kubernetes.io/managed-by": "arkade"},
+		},
+		{
+			name:         "monitor selector",
+			selector:     map[string]string{"app.kubernetes.io/name": "ingress-nginx"},
+			wantSelector: map[string]interface{}{"app.kubernetes.io/name": "ingress-nginx"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+				Domain:          "registry.example.com",
+				Email:           "admin@example.com",
+				IngressClass:    "nginx",
+				Namespace:       "registry",
+				MaxSize:         "200m",
+				HTTPProxy:       tc.httpProxy,
+				ServiceMonitor:  true,
+				MonitorSelector: tc.selector,
+			}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			objects, err := k8s.ParseObjects(yamlBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var monitor map[string]interface{}
+			for i, object := range objects {
+				if object.Kind == "ServiceMonitor" {
+					if err := yaml.Unmarshal(k8s.SplitManifest(yamlBytes)[i], &monitor); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if monitor == nil {
+				t.Fatalf("want a ServiceMonitor, got:\n%s", yamlBytes)
+			}
+
+			if got := monitor["apiVersion"]; got != "monitoring.coreos.com/v1" {
+				t.Errorf("want apiVersion monitoring.coreos.com/v1, got: %v", got)
+			}
+			spec := monitor["spec"].(map[string]interface{})
+			matchLabels := spec["selector"].(map[string]interface{})["matchLabels"]
+			if !reflect.DeepEqual(matchLabels, tc.wantSelector) {
+				t.Errorf("want selector %v, got: %v", tc.wantSelector, matchLabels)
+			}
+		})
+	}
+}
+
+func Test_buildRegistryYAML_NoServiceMonitor(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(yamlBytes), "ServiceMonitor") {
+		t.Errorf("want no ServiceMonitor without --service-monitor, got:\n%s", yamlBytes)
+	}
+}
+
+func Test_buildRegistryYAML_ServiceNameAndPort(t *testing.T) {
+	cases := []struct {
+		name          string
+		hasNetworking bool
+		httpProxy     bool
+		want          []string
+	}{
+		{name: "extensions", want: []string{"serviceName: my-registry-docker-registry", "servicePort: 8443"}},
+		{name: "networking", hasNetworking: true, want: []string{"name: my-registry-docker-registry", "number: 8443"}},
+		{name: "httpproxy", httpProxy: true, want: []string{"- name: my-registry-docker-registry", "port: 8443"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := registryIngressOptions{
+				Domain:       "registry.example.com",
+				Email:        "admin@example.com",
+				IngressClass: "nginx",
+				Namespace:    "default",
+				MaxSize:      "200m",
+				ServiceName:  "my-registry-docker-registry",
+				ServicePort:  8443,
+				HTTPProxy:    tc.httpProxy,
+			}
+			if err := validateService(opts); err != nil {
+				t.Fatal(err)
+			}
+
+			yamlBytes, err := buildRegistryYAML(opts, tc.hasNetworking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(string(yamlBytes), want) {
+					t.Errorf("want %q in:\n%s", want, yamlBytes)
+				}
+			}
+			if strings.Contains(string(yamlBytes), "5000") {
+				t.Errorf("want the default port replaced, got:\n%s", yamlBytes)
+			}
+		})
+	}
+}
+
+func Test_buildRegistryYAML_MinTLSVersion(t *testing.T) {
+	cases := []struct {
+		version string
+		want    string
+		older   []string
+	}{
+		{version: "1.2", want: "TLSv1.2 TLSv1.3", older: []string{"TLSv1 ", "TLSv1.1"}},
+		{version: "1.3", want: "TLSv1.3", older: []string{"TLSv1 ", "TLSv1.1", "TLSv1.2"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.version, func(t *testing.T) {
+			opts := registryIngressOptions{
+				Domain:        "registry.example.com",
+				Email:         "admin@example.com",
+				IngressClass:  "nginx",
+				Namespace:     "default",
+				MaxSize:       "200m",
+				MinTLSVersion: tc.version,
+			}
+			if err := validateMinTLSVersion(opts); err != nil {
+				t.Fatal(err)
+			}
+
+			yamlBytes, err := buildRegistryYAML(opts, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			annotations := registryIngressDoc(t, yamlBytes)["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+			got, _ := annotations["nginx.ingress.kubernetes.io/ssl-protocols"].(string)
+			if got != tc.want {
+				t.Errorf("want ssl-protocols %q, got: %q", tc.want, got)
+			}
+			for _, older := range tc.older {
+				if strings.Contains(got+" ", older) {
+					t.Errorf("want %s excluded, got: %q", strings.TrimSpace(older), got)
+				}
+			}
+		})
+	}
+}
+
+func Test_buildRegistryYAML_TraefikMaxSize(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "traefik",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+	if _, err := normalizeMaxSize(opts.MaxSize); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := registryIngressDoc(t, yamlBytes)
+	annotations := ingress["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if got := annotations["traefik.ingress.kubernetes.io/router.middlewares"]; got != "registry-docker-registry-max-size@kubernetescrd" {
+		t.Errorf("want the Middleware referenced, got: %v", got)
+	}
+	if _, ok := annotations["nginx.ingress.kubernetes.io/proxy-body-size"]; ok {
+		t.Errorf("want no nginx annotation for traefik, got: %v", annotations)
+	}
+
+	want := `kind: Middleware
+metadata:
+  name: docker-registry-max-size
+  namespace: registry
+spec:
+  buffering:
+    maxRequestBodyBytes: 209715200`
+	if !strings.Contains(string(yamlBytes), want) {
+		t.Errorf("want a Middleware:\n%s\ngot:\n%s", want, yamlBytes)
+	}
+}
+
+func Test_buildRegistryYAML_SkipIssuerWithTLSSecret(t *testing.T) {
+	for _, httpProxy := range []bool{false, true} {
+		yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+			Domain:       "registry.example.com",
+			IngressClass: "nginx",
+			Namespace:    "default",
+			MaxSize:      "200m",
+			TLSSecret:    "wildcard-example-com",
+			SkipIssuer:   true,
+			HTTPProxy:    httpProxy,
+		}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var kinds []string
+		for _, doc := range k8s.SplitManifest(yamlBytes) {
+			obj := map[string]interface{}{}
+			if err := yaml.Unmarshal(doc, &obj); err != nil {
+				t.Fatalf("rendered YAML is invalid: %s\n%s", err, doc)
+			}
+			kinds = append(kinds, fmt.Sprint(obj["kind"]))
+		}
+		want := "Ingress"
+		if httpProxy {
+			want = "HTTPProxy"
+		}
+		if strings.Join(kinds, ",") != want {
+			t.Errorf("httpproxy=%v: want only a %s, got: %v", httpProxy, want, kinds)
+		}
+
+		if !strings.Contains(string(yamlBytes), "secretName: wildcard-example-com") {
+			t.Errorf("httpproxy=%v: want the --tls-secret referenced, got:\n%s", httpProxy, yamlBytes)
+		}
+		if strings.Contains(string(yamlBytes), "cert-manager.io/") {
+			t.Errorf("httpproxy=%v: want no cert-manager annotations, got:\n%s", httpProxy, yamlBytes)
+		}
+	}
+}
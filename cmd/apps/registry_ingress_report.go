@@ -0,0 +1,82 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/timestamp"
+)
+
+// registryIngressReport records what was installed, where and when
+type registryIngressReport struct {
+	App       string `json:"app"`
+	Domain    string `json:"domain"`
+	Issuer    string `json:"issuer"`
+	IssuerAPI string `json:"issuerAPI"`
+	Secret    string `json:"secret"`
+	Namespace string `json:"namespace"`
+	Context   string `json:"context"`
+	Timestamp string `json:"timestamp"`
+}
+
+func newRegistryIngressReport(inputData RegInputData, kubeContext string, now time.Time) registryIngressReport {
+	return registryIngressReport{
+		App:       "docker-registry-ingress",
+		Domain:    inputData.IngressDomain,
+		Issuer:    inputData.IssuerName,
+		IssuerAPI: inputData.IssuerAPI,
+		Secret:    inputData.TLSSecretName,
+		Namespace: inputData.Namespace,
+		Context:   kubeContext,
+		Timestamp: timestamp.Format(now),
+	}
+}
+
+// registryIngressResult is printed instead of the install message for
+// --output json, so that automation can read what was installed
+type registryIngressResult struct {
+	App        string   `json:"app"`
+	Namespace  string   `json:"namespace"`
+	IssuerType string   `json:"issuerType"`
+	Hosts      []string `json:"hosts"`
+	Resources  []string `json:"resources"`
+	Warnings   []string `json:"warnings"`
+}
+
+func newRegistryIngressResult(inputData RegInputData, applied, warnings []string) registryIngressResult {
+	result := registryIngressResult{
+		App:        "docker-registry-ingress",
+		Namespace:  inputData.Namespace,
+		IssuerType: inputData.IssuerType,
+		Hosts:      inputData.Hosts,
+		Resources:  []string{},
+		Warnings:   []string{},
+	}
+	result.Resources = append(result.Resources, applied...)
+	result.Warnings = append(result.Warnings, warnings...)
+	return result
+}
+
+func writeRegistryIngressResult(w io.Writer, result registryIngressResult) error {
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+func writeRegistryIngressReport(path string, report registryIngressReport) error {
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(out, '\n'), 0600)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_writeRegistryIngressReport(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"config current-context": {Stdout: "prod-cluster\n"},
+	})
+
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "registry",
+		MaxSize:      "200m",
+	}
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	report := newRegistryIngressReport(newRegInputData(opts), currentKubeContext(), now)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeRegistryIngressReport(path, report); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := map[string]string{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("report is not valid JSON: %s", err)
+	}
+
+	want := map[string]string{
+		"app":       "docker-registry-ingress",
+		"domain":    "registry.example.com",
+		"issuer":    "letsencrypt-prod-issuer",
+		"issuerAPI": "https://acme-v02.api.letsencrypt.org/directory",
+		"secret":    "docker-registry",
+		"namespace": "registry",
+		"context":   "prod-cluster",
+		"timestamp": "2021-07-01T12:00:00Z",
+	}
+
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("field %s, want: %q, got: %q", key, value, got[key])
+		}
+	}
+}
+
+func Test_writeRegistryIngressResult(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:    "registry.example.com",
+		Email:     "admin@example.com",
+		Namespace: "registry",
+		Hosts:     []registryHost{{Domain: "registry.internal.example.com", Solver: "http01"}},
+	}
+	applied := parseAppliedResources(`ingress.networking.k8s.io/docker-registry created
+issuer.cert-manager.io/letsencrypt-prod-issuer unchanged
+`)
+
+	var out bytes.Buffer
+	result := newRegistryIngressResult(newRegInputData(opts), applied, nil)
+	if err := writeRegistryIngressResult(&out, result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "app": "docker-registry-ingress",
+  "namespace": "registry",
+  "issuerType": "letsencrypt-prod-issuer",
+  "hosts": [
+    "registry.example.com",
+    "registry.internal.example.com"
+  ],
+  "resources": [
+    "ingress.networking.k8s.io/docker-registry",
+    "issuer.cert-manager.io/letsencrypt-prod-issuer"
+  ],
+  "warnings": []
+}
+`
+	if out.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), RegistryIngressInstallHeader) {
+		t.Errorf("want no banner in the JSON result")
+	}
+}
+
+func Test_registryIngress_OutputJSON_OnlyWritesTheResult(t *testing.T) {
+	tempHome := t.TempDir()
+	previousHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempHome)
+	defer os.Setenv("HOME", previousHome)
+
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"api-versions":           {Stdout: "networking.k8s.io/v1\ncert-manager.io/v1\n"},
+		"get namespace registry": {Stdout: "namespace/registry"},
+		"apply -f":               {Stdout: "ingress.networking.k8s.io/docker-registry created\n"},
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	out, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+		"--ingress-class", "nginx", "--namespace", "registry", "--output", "json",
+		"--report", reportFile, "--keep-manifests", "--pre-hook", "echo pre-hook output")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(out))
+	result := registryIngressResult{}
+	if err := decoder.Decode(&result); err != nil {
+		t.Fatalf("want the result as JSON, got: %q, error: %s", out, err)
+	}
+	if decoder.More() {
+		t.Errorf("want a single JSON document on stdout, got:\n%s", out)
+	}
+	if len(result.Resources) != 1 || len(result.Warnings) == 0 {
+		t.Errorf("want the applied resources and the warnings in the result, got: %+v", result)
+	}
+	for _, progress := range []string{"Report written to", "Manifest kept at", "pre-hook"} {
+		if strings.Contains(out, progress) {
+			t.Errorf("want %q written to stderr, got stdout:\n%s", progress, out)
+		}
+	}
+}
+
+func Test_registryIngress_OutputJSON_NoResultWritesNothing(t *testing.T) {
+	tempHome := t.TempDir()
+	previousHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempHome)
+	defer os.Setenv("HOME", previousHome)
+
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"api-versions": {Stdout: "networking.k8s.io/v1\ncert-manager.io/v1\n"},
+	})
+
+	out, err := executeRegistryIngress(t, "--domain", "registry.example.com", "--email", "admin@example.com",
+		"--ingress-class", "nginx", "--namespace", "registry", "--output", "json", "--uninstall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.TrimSpace(out)) > 0 {
+		t.Errorf("want nothing on stdout without a result, got:\n%s", out)
+	}
+}
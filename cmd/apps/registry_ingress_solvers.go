@@ -0,0 +1,248 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// acmeSolver is an entry in the Issuer's spec.acme.solvers list
+type acmeSolver struct {
+	Selector *solverSelector `json:"selector,omitempty"`
+	HTTP01   *http01Solver   `json:"http01,omitempty"`
+	DNS01    *dns01Solver    `json:"dns01,omitempty"`
+}
+
+type solverSelector struct {
+	DNSNames []string `json:"dnsNames,omitempty"`
+	DNSZones []string `json:"dnsZones,omitempty"`
+}
+
+type http01Solver struct {
+	Ingress http01Ingress `json:"ingress"`
+}
+
+type http01Ingress struct {
+	Class       string             `json:"class"`
+	ServiceType string             `json:"serviceType,omitempty"`
+	PodTemplate *solverPodTemplate `json:"podTemplate,omitempty"`
+}
+
+// solverPodTemplate customises the pod cert-manager runs to answer the
+// http01 challenge
+type solverPodTemplate struct {
+	Spec solverPodSpec `json:"spec"`
+}
+
+type solverPodSpec struct {
+	SecurityContext solverSecurityContext `json:"securityContext"`
+}
+
+type solverSecurityContext struct {
+	SeccompProfile seccompProfile `json:"seccompProfile"`
+}
+
+type seccompProfile struct {
+	Type string `json:"type"`
+}
+
+// solverSeccompProfiles are the values accepted by --solver-seccomp,
+// Localhost is left out as it needs a profile on every node
+var solverSeccompProfiles = []string{"RuntimeDefault", "Unconfined"}
+
+type dns01Solver struct {
+	Cloudflare   *dns01Cloudflare   `json:"cloudflare,omitempty"`
+	DigitalOcean *dns01DigitalOcean `json:"digitalocean,omitempty"`
+	Route53      *dns01Route53      `json:"route53,omitempty"`
+}
+
+type secretKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type dns01Cloudflare struct {
+	APITokenSecretRef secretKeySelector `json:"apiTokenSecretRef"`
+}
+
+type dns01DigitalOcean struct {
+	TokenSecretRef secretKeySelector `json:"tokenSecretRef"`
+}
+
+// externalAccountBinding links the ACME account to an account with a
+// commercial CA, the HMAC key is read from a secret
+type externalAccountBinding struct {
+	KeyID        string            `json:"keyID"`
+	KeySecretRef secretKeySelector `json:"keySecretRef"`
+}
+
+// dns01Route53 relies on ambient credentials such as an IAM role
+// for the cert-manager service account
+type dns01Route53 struct {
+	Region string `json:"region"`
+}
+
+// validateACMEServer checks --acme-server is an https URL, and is not
+// combined with --staging which picks the Let's Encrypt staging server
+func validateACMEServer(opts registryIngressOptions) error {
+	if len(opts.ACMEServer) == 0 {
+		return nil
+	}
+	if opts.Staging {
+		return errors.New("--acme-server and --staging can't be used together, --staging selects the Let's Encrypt staging server")
+	}
+
+	u, err := url.Parse(opts.ACMEServer)
+	if err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+		return fmt.Errorf("--acme-server must be an https URL such as https://acme.zerossl.com/v2/DV90, got: %q", opts.ACMEServer)
+	}
+	return nil
+}
+
+// validateExternalAccountBinding checks the key ID and its HMAC secret
+// are given together, for a custom --acme-server
+func validateExternalAccountBinding(opts registryIngressOptions) error {
+	if len(opts.EABKeyID) == 0 && len(opts.EABSecret) == 0 {
+		return nil
+	}
+	if len(opts.ACMEServer) == 0 {
+		return errors.New("--eab-key-id and --eab-hmac-secret are only supported with --acme-server, Let's Encrypt does not use External Account Binding")
+	}
+	if len(opts.EABKeyID) == 0 || len(opts.EABSecret) == 0 {
+		return errors.New("--eab-key-id and --eab-hmac-secret must be given together")
+	}
+	if len(opts.EABSecretKey) == 0 {
+		return errors.New("--eab-hmac-secret-key must not be empty")
+	}
+	return nil
+}
+
+// validateSolverSeccomp checks --solver-seccomp is empty or one of the
+// solverSeccompProfiles
+func validateSolverSeccomp(profile string) error {
+	if len(profile) == 0 {
+		return nil
+	}
+	for _, allowed := range solverSeccompProfiles {
+		if profile == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("--solver-seccomp must be one of: %s, but got: %q", strings.Join(solverSeccompProfiles, ", "), profile)
+}
+
+// validateSolverOptions checks a DNS01 provider is known, has the
+// credentials it needs and has zones to tell it apart from HTTP01
+func validateSolverOptions(opts registryIngressOptions) error {
+	switch opts.Challenge {
+	case "", "http01":
+	case "dns01":
+		if len(opts.DNS01Provider) == 0 {
+			return errors.New("--dns-provider is required with --challenge dns01")
+		}
+		if len(opts.SolverSeccomp) > 0 {
+			return errors.New("--solver-seccomp only applies to the http01 challenge")
+		}
+		if len(opts.SolverServiceType) > 0 {
+			return errors.New("--solver-service-type only applies to the http01 challenge")
+		}
+	default:
+		return fmt.Errorf("--challenge must be http01 or dns01, but got: %q", opts.Challenge)
+	}
+
+	switch opts.SolverServiceType {
+	case "", "ClusterIP", "NodePort":
+	default:
+		return fmt.Errorf("--solver-service-type must be ClusterIP or NodePort, but got: %q", opts.SolverServiceType)
+	}
+
+	if len(opts.DNS01Provider) == 0 {
+		return nil
+	}
+
+	switch opts.DNS01Provider {
+	case "cloudflare", "digitalocean":
+		if len(opts.DNS01Secret) == 0 {
+			return fmt.Errorf("--dns01-secret is required for the %s DNS01 provider", opts.DNS01Provider)
+		}
+	case "route53":
+		if len(opts.DNS01Region) == 0 {
+			return errors.New("--dns01-region is required for the route53 DNS01 provider")
+		}
+	default:
+		return fmt.Errorf("unknown --dns-provider %q, use one of: cloudflare, digitalocean, route53", opts.DNS01Provider)
+	}
+
+	if opts.Challenge != "dns01" && len(opts.DNS01Zones) == 0 && len(hostsWithSolver(opts.Hosts, "dns01")) == 0 {
+		return errors.New("--dns01-zones or a --host with dns01 must be set with --dns-provider, so that the DNS01 and HTTP01 solvers can be told apart, or use --challenge dns01")
+	}
+
+	return nil
+}
+
+func hostsWithSolver(hosts []registryHost, solver string) []string {
+	var domains []string
+	for _, host := range hosts {
+		if host.Solver == solver {
+			domains = append(domains, host.Domain)
+		}
+	}
+	return domains
+}
+
+// buildSolvers returns a single http01 solver, or a single dns01 solver
+// for the dns01 challenge. When a DNS01 provider is given with http01,
+// it returns a dns01 solver for its zones and the hosts using dns01, and
+// an http01 solver for the domain and the other hosts, each with a
+// selector
+func buildSolvers(opts registryIngressOptions) []acmeSolver {
+	httpSolver := acmeSolver{
+		HTTP01: &http01Solver{Ingress: http01Ingress{Class: opts.IngressClass, ServiceType: opts.SolverServiceType}},
+	}
+	if len(opts.SolverSeccomp) > 0 {
+		httpSolver.HTTP01.Ingress.PodTemplate = &solverPodTemplate{
+			Spec: solverPodSpec{
+				SecurityContext: solverSecurityContext{
+					SeccompProfile: seccompProfile{Type: opts.SolverSeccomp},
+				},
+			},
+		}
+	}
+
+	if len(opts.DNS01Provider) == 0 {
+		return []acmeSolver{httpSolver}
+	}
+
+	secretRef := secretKeySelector{Name: opts.DNS01Secret, Key: opts.DNS01SecretKey}
+	dnsSolver := acmeSolver{
+		Selector: &solverSelector{
+			DNSNames: hostsWithSolver(opts.Hosts, "dns01"),
+			DNSZones: opts.DNS01Zones,
+		},
+		DNS01: &dns01Solver{},
+	}
+
+	switch opts.DNS01Provider {
+	case "cloudflare":
+		dnsSolver.DNS01.Cloudflare = &dns01Cloudflare{APITokenSecretRef: secretRef}
+	case "digitalocean":
+		dnsSolver.DNS01.DigitalOcean = &dns01DigitalOcean{TokenSecretRef: secretRef}
+	case "route53":
+		dnsSolver.DNS01.Route53 = &dns01Route53{Region: opts.DNS01Region}
+	}
+
+	if opts.Challenge == "dns01" {
+		dnsSolver.Selector = nil
+		return []acmeSolver{dnsSolver}
+	}
+
+	httpSolver.Selector = &solverSelector{
+		DNSNames: append([]string{opts.Domain}, hostsWithSolver(opts.Hosts, "http01")...),
+	}
+
+	return []acmeSolver{dnsSolver, httpSolver}
+}
@@ -0,0 +1,447 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_buildRegistryYAML_HTTP01AndDNS01Solvers(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:         "registry.example.com",
+		Email:          "admin@example.com",
+		IngressClass:   "nginx",
+		Namespace:      "default",
+		MaxSize:        "200m",
+		DNS01Provider:  "cloudflare",
+		DNS01Secret:    "cloudflare-api-token",
+		DNS01SecretKey: "api-token",
+		DNS01Zones:     []string{"example.net"},
+	}
+
+	if err := validateSolverOptions(opts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `    solvers:
+    - dns01:
+        cloudflare:
+          apiTokenSecretRef:
+            key: api-token
+            name: cloudflare-api-token
+      selector:
+        dnsZones:
+        - example.net
+    - http01:
+        ingress:
+          class: nginx
+      selector:
+        dnsNames:
+        - registry.example.com`
+
+	if got := string(yamlBytes); !strings.HasSuffix(got, want) {
+		t.Errorf("want solvers:\n%s\ngot:\n%s\n", want, got)
+	}
+}
+
+func Test_validateSolverOptions_RequiresZones(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:        "registry.example.com",
+		DNS01Provider: "cloudflare",
+		DNS01Secret:   "cloudflare-api-token",
+	}
+
+	err := validateSolverOptions(opts)
+	if err == nil || !strings.Contains(err.Error(), "--dns01-zones") {
+		t.Errorf("want an error about --dns01-zones, got: %v", err)
+	}
+}
+
+func Test_buildRegistryYAML_HostsWithDifferentSolvers(t *testing.T) {
+	var hosts []registryHost
+	for _, value := range []string{"mirror.example.com:http01", "internal.example.net:dns01"} {
+		host, err := parseRegistryHost(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts = append(hosts, host)
+	}
+
+	opts := registryIngressOptions{
+		Domain:         "registry.example.com",
+		Email:          "admin@example.com",
+		IngressClass:   "nginx",
+		Namespace:      "default",
+		MaxSize:        "200m",
+		DNS01Provider:  "cloudflare",
+		DNS01Secret:    "cloudflare-api-token",
+		DNS01SecretKey: "api-token",
+		Hosts:          hosts,
+	}
+
+	if err := validateSolverOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateHosts(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `    solvers:
+    - dns01:
+        cloudflare:
+          apiTokenSecretRef:
+            key: api-token
+            name: cloudflare-api-token
+      selector:
+        dnsNames:
+        - internal.example.net
+    - http01:
+        ingress:
+          class: nginx
+      selector:
+        dnsNames:
+        - registry.example.com
+        - mirror.example.com`
+
+	if got := string(yamlBytes); !strings.HasSuffix(got, want) {
+		t.Errorf("want solvers:\n%s\ngot:\n%s\n", want, got)
+	}
+
+	ingress := registryIngressDoc(t, yamlBytes)
+	rules := ingress["spec"].(map[string]interface{})["rules"].([]interface{})
+	if len(rules) != 3 {
+		t.Errorf("want a rule for the domain and each host, got: %v", rules)
+	}
+}
+
+func Test_buildRegistryYAML_SolverSeccomp(t *testing.T) {
+	yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+		Domain:        "registry.example.com",
+		Email:         "admin@example.com",
+		IngressClass:  "nginx",
+		Namespace:     "default",
+		MaxSize:       "200m",
+		SolverSeccomp: "RuntimeDefault",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			ACME struct {
+				Solvers []acmeSolver `json:"solvers"`
+			} `json:"acme"`
+		} `json:"spec"`
+	}
+	for _, doc := range k8s.SplitManifest(yamlBytes) {
+		if err := yaml.Unmarshal(doc, &issuer); err != nil {
+			t.Fatal(err)
+		}
+		if issuer.Kind == "Issuer" {
+			break
+		}
+	}
+
+	solvers := issuer.Spec.ACME.Solvers
+	if len(solvers) != 1 || solvers[0].HTTP01 == nil || solvers[0].HTTP01.Ingress.PodTemplate == nil {
+		t.Fatalf("want the http01 solver to have a podTemplate, got:\n%s", yamlBytes)
+	}
+	if got := solvers[0].HTTP01.Ingress.PodTemplate.Spec.SecurityContext.SeccompProfile.Type; got != "RuntimeDefault" {
+		t.Errorf("want seccompProfile type RuntimeDefault, got: %q", got)
+	}
+}
+
+func Test_validateSolverSeccomp(t *testing.T) {
+	for _, profile := range []string{"", "RuntimeDefault", "Unconfined"} {
+		if err := validateSolverSeccomp(profile); err != nil {
+			t.Errorf("want %q to be valid, got: %s", profile, err)
+		}
+	}
+	for _, profile := range []string{"runtime/default", "Localhost"} {
+		if err := validateSolverSeccomp(profile); err == nil {
+			t.Errorf("want %q to be rejected", profile)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_SolverServiceType(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:            "registry.example.com",
+		Email:             "admin@example.com",
+		IngressClass:      "nginx",
+		Namespace:         "default",
+		MaxSize:           "200m",
+		SolverServiceType: "ClusterIP",
+	}
+	if err := validateSolverOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `    - http01:
+        ingress:
+          class: nginx
+          serviceType: ClusterIP`
+	if !strings.Contains(string(yamlBytes), want) {
+		t.Errorf("want the solver's serviceType:\n%s\ngot:\n%s", want, yamlBytes)
+	}
+
+	for _, invalid := range []registryIngressOptions{
+		{SolverServiceType: "LoadBalancer"},
+		{SolverServiceType: "ClusterIP", Challenge: "dns01", DNS01Provider: "cloudflare"},
+	} {
+		if err := validateSolverOptions(invalid); err == nil {
+			t.Errorf("want an error for %+v", invalid)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_DNS01Challenge(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:         "registry.example.com",
+		Email:          "admin@example.com",
+		IngressClass:   "nginx",
+		Namespace:      "default",
+		MaxSize:        "200m",
+		Challenge:      "dns01",
+		DNS01Provider:  "cloudflare",
+		DNS01Secret:    "cloudflare-api-token",
+		DNS01SecretKey: "api-token",
+	}
+	if err := validateSolverOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			ACME struct {
+				Solvers []acmeSolver `json:"solvers"`
+			} `json:"acme"`
+		} `json:"spec"`
+	}
+	for _, doc := range k8s.SplitManifest(yamlBytes) {
+		if err := yaml.Unmarshal(doc, &issuer); err != nil {
+			t.Fatal(err)
+		}
+		if issuer.Kind == "Issuer" {
+			break
+		}
+	}
+
+	solvers := issuer.Spec.ACME.Solvers
+	if len(solvers) != 1 {
+		t.Fatalf("want a single solver, got:\n%s", yamlBytes)
+	}
+	if solvers[0].HTTP01 != nil || solvers[0].Selector != nil {
+		t.Errorf("want only a dns01 solver without a selector, got:\n%s", yamlBytes)
+	}
+	if solvers[0].DNS01 == nil || solvers[0].DNS01.Cloudflare == nil {
+		t.Fatalf("want a cloudflare dns01 solver, got:\n%s", yamlBytes)
+	}
+	if got := solvers[0].DNS01.Cloudflare.APITokenSecretRef.Name; got != "cloudflare-api-token" {
+		t.Errorf("want the secret cloudflare-api-token, got: %q", got)
+	}
+}
+
+func Test_validateSolverOptions_Challenge(t *testing.T) {
+	cases := map[string]registryIngressOptions{
+		"unknown challenge":      {Challenge: "tls-alpn01"},
+		"dns01 without provider": {Challenge: "dns01"},
+		"dns01 with seccomp": {
+			Challenge:     "dns01",
+			DNS01Provider: "route53",
+			DNS01Region:   "eu-west-1",
+			SolverSeccomp: "RuntimeDefault",
+		},
+	}
+
+	for name, opts := range cases {
+		if err := validateSolverOptions(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func Test_buildRegistryYAML_IssuerKind(t *testing.T) {
+	cases := []struct {
+		issuerKind string
+		wantKind   string
+		annotation string
+		namespaced bool
+	}{
+		{issuerKind: "", wantKind: "Issuer", annotation: "cert-manager.io/issuer", namespaced: true},
+		{issuerKind: "Issuer", wantKind: "Issuer", annotation: "cert-manager.io/issuer", namespaced: true},
+		{issuerKind: "ClusterIssuer", wantKind: "ClusterIssuer", annotation: "cert-manager.io/cluster-issuer", namespaced: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.wantKind+"/"+tc.issuerKind, func(t *testing.T) {
+			yamlBytes, err := buildRegistryYAML(registryIngressOptions{
+				Domain:       "registry.example.com",
+				Email:        "admin@example.com",
+				IngressClass: "nginx",
+				Namespace:    "registry",
+				MaxSize:      "200m",
+				IssuerKind:   tc.issuerKind,
+			}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			objects, err := k8s.ParseObjects(yamlBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var issuer *k8s.Object
+			for i := range objects {
+				if objects[i].Kind == tc.wantKind {
+					issuer = &objects[i]
+				}
+			}
+			if issuer == nil {
+				t.Fatalf("want a %s, got:\n%s", tc.wantKind, yamlBytes)
+			}
+			if namespaced := len(issuer.Namespace) > 0; namespaced != tc.namespaced {
+				t.Errorf("want namespaced: %t, got namespace: %q", tc.namespaced, issuer.Namespace)
+			}
+
+			annotations := registryIngressDoc(t, yamlBytes)["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+			if got := annotations[tc.annotation]; got != "letsencrypt-prod-issuer" {
+				t.Errorf("want %s: letsencrypt-prod-issuer, got annotations: %v", tc.annotation, annotations)
+			}
+		})
+	}
+}
+
+func Test_buildRegistryYAML_ACMEServer(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+		ACMEServer:   "https://acme.zerossl.com/v2/DV90",
+	}
+	if err := validateACMEServer(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(yamlBytes), "server: https://acme.zerossl.com/v2/DV90") {
+		t.Errorf("want the Issuer to use the ACME server, got:\n%s", yamlBytes)
+	}
+	if strings.Contains(string(yamlBytes), "letsencrypt") {
+		t.Errorf("want no reference to Let's Encrypt, got:\n%s", yamlBytes)
+	}
+}
+
+func Test_validateACMEServer(t *testing.T) {
+	cases := map[string]registryIngressOptions{
+		"with staging": {ACMEServer: "https://acme.zerossl.com/v2/DV90", Staging: true},
+		"http":         {ACMEServer: "http://acme.internal/directory"},
+		"no scheme":    {ACMEServer: "acme.internal/directory"},
+	}
+	for name, opts := range cases {
+		if err := validateACMEServer(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+
+	err := validateACMEServer(registryIngressOptions{ACMEServer: "https://acme.zerossl.com/v2/DV90", Staging: true})
+	if err == nil || !strings.Contains(err.Error(), "--staging") {
+		t.Errorf("want the error to mention --staging, got: %v", err)
+	}
+}
+
+func Test_buildRegistryYAML_ExternalAccountBinding(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "nginx",
+		Namespace:    "default",
+		MaxSize:      "200m",
+		ACMEServer:   "https://acme.zerossl.com/v2/DV90",
+		EABKeyID:     "kid-1234",
+		EABSecret:    "zerossl-eab",
+		EABSecretKey: "secret",
+	}
+	if err := validateExternalAccountBinding(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			ACME struct {
+				ExternalAccountBinding *externalAccountBinding `json:"externalAccountBinding"`
+			} `json:"acme"`
+		} `json:"spec"`
+	}
+	for _, doc := range k8s.SplitManifest(yamlBytes) {
+		if err := yaml.Unmarshal(doc, &issuer); err != nil {
+			t.Fatal(err)
+		}
+		if issuer.Kind == "Issuer" {
+			break
+		}
+	}
+
+	eab := issuer.Spec.ACME.ExternalAccountBinding
+	if eab == nil {
+		t.Fatalf("want an externalAccountBinding, got:\n%s", yamlBytes)
+	}
+	if eab.KeyID != "kid-1234" {
+		t.Errorf("want keyID kid-1234, got: %q", eab.KeyID)
+	}
+	if eab.KeySecretRef.Name != "zerossl-eab" || eab.KeySecretRef.Key != "secret" {
+		t.Errorf("want keySecretRef zerossl-eab/secret, got: %+v", eab.KeySecretRef)
+	}
+}
+
+func Test_validateExternalAccountBinding(t *testing.T) {
+	cases := map[string]registryIngressOptions{
+		"without acme server": {EABKeyID: "kid", EABSecret: "eab", EABSecretKey: "secret"},
+		"without secret":      {ACMEServer: "https://acme.zerossl.com/v2/DV90", EABKeyID: "kid", EABSecretKey: "secret"},
+		"without key ID":      {ACMEServer: "https://acme.zerossl.com/v2/DV90", EABSecret: "eab", EABSecretKey: "secret"},
+	}
+	for name, opts := range cases {
+		if err := validateExternalAccountBinding(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
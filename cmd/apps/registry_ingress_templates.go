@@ -0,0 +1,271 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+// Ingress in extensions/v1beta1 are removed in k8s 1.22+, July 2021
+var registryIngressExtensionsYamlTemplate = `
+apiVersion: {{ or (index .APIVersions "Ingress") "extensions/v1beta1" }}
+kind: Ingress
+metadata:
+  name: {{.IngressName}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+  annotations:
+{{ toYaml .Annotations | indent 4 }}
+spec:
+  rules:
+{{- range .Hosts }}
+  - host: {{.}}
+    http:
+      paths:
+      - backend:
+          serviceName: {{$.ServiceName}}
+          servicePort: {{$.ServicePort}}
+        path: {{ toYaml $.Path }}
+{{- end }}
+  tls:
+  - hosts:
+{{- range .Hosts }}
+    - {{.}}
+{{- end }}
+    secretName: {{.TLSSecretName}}
+{{- if .Canary }}
+---
+apiVersion: {{ or (index .APIVersions "Ingress") "extensions/v1beta1" }}
+kind: Ingress
+metadata:
+  name: {{.Canary.Name}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+  annotations:
+{{ toYaml .Canary.Annotations | indent 4 }}
+spec:
+  rules:
+  - host: {{.IngressDomain}}
+    http:
+      paths:
+      - backend:
+          serviceName: {{.Canary.ServiceName}}
+          servicePort: {{.Canary.ServicePort}}
+        path: {{ toYaml .Path }}
+{{- end }}
+{{- template "middleware" . }}
+{{- template "servicemonitor" . }}
+{{- template "issuer" . }}`
+
+// Ingress in networking.k8s.io/v1 was added in k8s 1.19+
+// this includes the pathType change added in 1.18
+var registryIngressNetworkingYamlTemplate = `
+apiVersion: {{ or (index .APIVersions "Ingress") "networking.k8s.io/v1" }}
+kind: Ingress
+metadata:
+  name: {{.IngressName}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+  annotations:
+{{ toYaml .Annotations | indent 4 }}
+spec:
+  rules:
+{{- range .Hosts }}
+  - host: {{.}}
+    http:
+      paths:
+      - path: {{ toYaml $.Path }}
+        pathType: ImplementationSpecific
+        backend:
+          service:
+            name: {{$.ServiceName}}
+            port:
+              number: {{$.ServicePort}}
+{{- end }}
+  tls:
+  - hosts:
+{{- range .Hosts }}
+    - {{.}}
+{{- end }}
+    secretName: {{.TLSSecretName}}
+{{- if .Canary }}
+---
+apiVersion: {{ or (index .APIVersions "Ingress") "networking.k8s.io/v1" }}
+kind: Ingress
+metadata:
+  name: {{.Canary.Name}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+  annotations:
+{{ toYaml .Canary.Annotations | indent 4 }}
+spec:
+  rules:
+  - host: {{.IngressDomain}}
+    http:
+      paths:
+      - path: {{ toYaml .Path }}
+        pathType: ImplementationSpecific
+        backend:
+          service:
+            name: {{.Canary.ServiceName}}
+            port:
+              number: {{.Canary.ServicePort}}
+{{- end }}
+{{- template "middleware" . }}
+{{- template "servicemonitor" . }}
+{{- template "issuer" . }}`
+
+// HTTPProxy is Contour's alternative to Ingress, cert-manager does not
+// watch HTTPProxy so the Certificate is created explicitly
+var registryHTTPProxyYamlTemplate = `
+{{- if not .SkipIssuer }}
+apiVersion: {{ or (index .APIVersions "Certificate") "cert-manager.io/v1" }}
+kind: Certificate
+metadata:
+  name: {{.TLSSecretName}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+{{- with index .KindAnnotations "Certificate" }}
+  annotations:
+{{ toYaml . | indent 4 }}
+{{- end }}
+spec:
+  secretName: {{.TLSSecretName}}
+{{- if .CertDuration }}
+  duration: {{.CertDuration}}
+{{- end }}
+{{- if .RenewBefore }}
+  renewBefore: {{.RenewBefore}}
+{{- end }}
+  dnsNames:
+  - {{.IngressDomain}}
+  issuerRef:
+    name: {{.IssuerName}}
+    kind: {{.IssuerKind}}
+---
+{{- end }}
+apiVersion: {{ or (index .APIVersions "HTTPProxy") "projectcontour.io/v1" }}
+kind: HTTPProxy
+metadata:
+  name: {{.IngressName}}
+  namespace: {{.Namespace}}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+{{- with index .KindAnnotations "HTTPProxy" }}
+  annotations:
+{{ toYaml . | indent 4 }}
+{{- end }}
+spec:
+  virtualhost:
+    fqdn: {{.IngressDomain}}
+    tls:
+      secretName: {{.TLSSecretName}}
+{{- if .MinTLSVersion }}
+      minimumProtocolVersion: {{ toYaml .MinTLSVersion }}
+{{- end }}
+  routes:
+  - conditions:
+    - prefix: /
+    services:
+    - name: {{.ServiceName}}
+      port: {{.ServicePort}}
+{{- template "servicemonitor" . }}
+{{- template "issuer" . }}`
+
+// registryPartialsYamlTemplate defines the resources which are rendered
+// alongside any of the templates, they are also available to custom
+// templates, which may replace them by defining a template of the same
+// name
+var registryPartialsYamlTemplate = `
+{{- define "middleware" }}
+{{- with .Middleware }}
+---
+apiVersion: {{ or (index $.APIVersions "Middleware") "traefik.io/v1alpha1" }}
+kind: Middleware
+metadata:
+  name: {{.Name}}
+  namespace: {{$.Namespace}}
+{{- if $.OwnerReferences }}
+  ownerReferences:
+{{ toYaml $.OwnerReferences | indent 2 }}
+{{- end }}
+spec:
+  buffering:
+    maxRequestBodyBytes: {{.MaxRequestBodyBytes}}
+{{- end }}
+{{- end }}
+{{- define "servicemonitor" }}
+{{- with .ServiceMonitor }}
+---
+apiVersion: {{ or (index $.APIVersions "ServiceMonitor") "monitoring.coreos.com/v1" }}
+kind: ServiceMonitor
+metadata:
+  name: {{.Name}}
+  namespace: {{$.Namespace}}
+  labels:
+    app.kubernetes.io/managed-by: arkade
+{{- if $.OwnerReferences }}
+  ownerReferences:
+{{ toYaml $.OwnerReferences | indent 2 }}
+{{- end }}
+spec:
+  namespaceSelector:
+    any: true
+  selector:
+    matchLabels:
+{{ toYaml .Selector | indent 6 }}
+  endpoints:
+  - port: metrics
+{{- end }}
+{{- end }}
+{{- define "issuer" }}
+{{- if not .SkipIssuer }}
+---
+apiVersion: {{ or (index .APIVersions .IssuerKind) "cert-manager.io/v1" }}
+kind: {{.IssuerKind}}
+metadata:
+  name: {{.IssuerName}}
+{{- if eq .IssuerKind "Issuer" }}
+  namespace: {{.Namespace}}
+{{- end }}
+  labels:
+    app.kubernetes.io/managed-by: arkade
+{{- if .Protected }}
+    arkade.alexellis.io/protected: "true"
+{{- end }}
+{{- if .OwnerReferences }}
+  ownerReferences:
+{{ toYaml .OwnerReferences | indent 2 }}
+{{- end }}
+{{- with index .KindAnnotations .IssuerKind }}
+  annotations:
+{{ toYaml . | indent 4 }}
+{{- end }}
+spec:
+  acme:
+    email: {{.CertmanagerEmail}}
+    server: {{.IssuerAPI}}
+{{- with .ExternalAccountBinding }}
+    externalAccountBinding:
+{{ toYaml . | indent 6 }}
+{{- end }}
+    privateKeySecretRef:
+      name: {{.IssuerName}}
+    solvers:
+{{ toYaml .Solvers | indent 4 }}
+{{- end }}
+{{- end }}`
@@ -0,0 +1,129 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+)
+
+// protectedLabel is set by --protect on resources which --uninstall only
+// deletes with --force
+const protectedLabel = k8s.ProtectedLabel
+
+type labelledObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+}
+
+// protectedResources lists the live resources from the manifest file
+// which carry protectedLabel, as kind/name
+func protectedResources(file string) ([]string, error) {
+	args := []string{"get", "-f", file, "--ignore-not-found", "-o", "json"}
+	res, err := k8s.KubectlTask(args...)
+	if err != nil {
+		return nil, err
+	}
+	if isNotFoundOnly(res.Stderr) || len(strings.TrimSpace(res.Stdout)) == 0 {
+		return nil, nil
+	}
+	if err := k8s.ResultError(res, args...); err != nil {
+		return nil, err
+	}
+
+	// A single resource is printed as itself, more than one as a List
+	var list struct {
+		labelledObject
+		Items []labelledObject `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(res.Stdout), &list); err != nil {
+		return nil, err
+	}
+	items := list.Items
+	if list.Kind != "List" {
+		items = []labelledObject{list.labelledObject}
+	}
+
+	var protected []string
+	for _, item := range items {
+		if item.Metadata.Labels[protectedLabel] == "true" {
+			protected = append(protected, item.Kind+"/"+item.Metadata.Name)
+		}
+	}
+	return protected, nil
+}
+
+// uninstallRegistryIngress deletes the resources in the manifest, those
+// which no longer exist are not treated as an error. Nothing is deleted
+// when any of them are protected, unless force is set.
+func uninstallRegistryIngress(w io.Writer, manifest []byte, keepManifests, force bool) error {
+	tempFile, err := writeTempFile(manifest, "temp_registry_ingress_uninstall.yaml")
+	if err != nil {
+		return err
+	}
+	defer removeTempFile(w, tempFile, keepManifests)
+
+	if !force {
+		protected, err := protectedResources(tempFile)
+		if err != nil {
+			return fmt.Errorf("unable to check for protected resources: %w", err)
+		}
+		if len(protected) > 0 {
+			return fmt.Errorf("refusing to uninstall as these resources are labelled %s=true: %s, add --force to delete them", protectedLabel, strings.Join(protected, ", "))
+		}
+	}
+
+	args := []string{"delete", "-f", tempFile, "--ignore-not-found"}
+	res, err := k8s.KubectlTask(args...)
+	if err != nil {
+		return err
+	}
+	if !isNotFoundOnly(res.Stderr) {
+		if err := k8s.ResultError(res, args...); err != nil {
+			return fmt.Errorf("unable to uninstall the registry ingress: %w", err)
+		}
+	}
+
+	deleted := strings.TrimSpace(res.Stdout)
+	if len(deleted) == 0 {
+		fmt.Fprintln(w, "Nothing to uninstall, the registry ingress resources were already deleted")
+		return nil
+	}
+	fmt.Fprintln(w, deleted)
+	return nil
+}
+
+// deleteTLSSecret deletes the TLS secret which cert-manager created for
+// the ingress, asking for confirmation first unless yes is set
+func deleteTLSSecret(w io.Writer, in io.Reader, namespace, secretName string, yes bool) error {
+	if !yes {
+		fmt.Fprintf(w, "Delete the TLS secret %s/%s? [y/N] ", namespace, secretName)
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Fprintf(w, "Keeping the TLS secret %s/%s\n", namespace, secretName)
+			return nil
+		}
+	}
+
+	args := []string{"delete", "secret", secretName, "-n", namespace, "--ignore-not-found"}
+	res, err := k8s.KubectlTask(args...)
+	if err != nil {
+		return err
+	}
+	if err := k8s.ResultError(res, args...); err != nil {
+		return fmt.Errorf("unable to delete the TLS secret: %w", err)
+	}
+	fmt.Fprintf(w, "Deleted the TLS secret %s/%s\n", namespace, secretName)
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_uninstallRegistryIngress(t *testing.T) {
+	cases := []struct {
+		name    string
+		result  execute.ExecResult
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "deletes the resources",
+			result: execute.ExecResult{Stdout: "ingress.networking.k8s.io \"docker-registry\" deleted\nissuer.cert-manager.io \"letsencrypt-prod-issuer\" deleted\n"},
+			want:   "issuer.cert-manager.io \"letsencrypt-prod-issuer\" deleted",
+		},
+		{
+			name:   "resources already deleted",
+			result: execute.ExecResult{},
+			want:   "Nothing to uninstall",
+		},
+		{
+			name: "cert-manager CRDs already removed",
+			result: execute.ExecResult{
+				ExitCode: 1,
+				Stderr:   `error: resource mapping not found for name: "letsencrypt-prod-issuer" namespace: "default" from "registry.yaml": no matches for kind "Issuer" in version "cert-manager.io/v1"`,
+			},
+			want: "Nothing to uninstall",
+		},
+		{
+			name: "forbidden",
+			result: execute.ExecResult{
+				ExitCode: 1,
+				Stderr:   `Error from server (Forbidden): ingresses.networking.k8s.io "docker-registry" is forbidden`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"delete -f": tc.result,
+			})
+
+			var out bytes.Buffer
+			err := uninstallRegistryIngress(&out, []byte("kind: Ingress\n"), false, false)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var deletes []string
+			for _, call := range fake.calls {
+				if strings.HasPrefix(call, "delete ") {
+					deletes = append(deletes, call)
+				}
+			}
+			if len(deletes) != 1 || !strings.HasSuffix(deletes[0], "--ignore-not-found") {
+				t.Errorf("want a single delete with --ignore-not-found, got: %v", fake.calls)
+			}
+			if !strings.Contains(out.String(), tc.want) {
+				t.Errorf("want %q in the output, got: %q", tc.want, out.String())
+			}
+		})
+	}
+}
+
+func Test_uninstallRegistryIngress_RefusesProtected(t *testing.T) {
+	live := `{"apiVersion":"v1","kind":"List","items":[
+		{"kind":"Ingress","metadata":{"name":"docker-registry","namespace":"registry"}},
+		{"kind":"Issuer","metadata":{"name":"letsencrypt-prod-issuer","namespace":"registry",
+			"labels":{"app.kubernetes.io/managed-by":"arkade","arkade.alexellis.io/protected":"true"}}}]}`
+
+	for _, force := range []bool{false, true} {
+		fake := useFakeKubectl(t, map[string]execute.ExecResult{
+			"get -f":    {Stdout: live},
+			"delete -f": {Stdout: "ingress.networking.k8s.io \"docker-registry\" deleted\n"},
+		})
+
+		var out bytes.Buffer
+		err := uninstallRegistryIngress(&out, []byte("kind: Ingress\n"), false, force)
+
+		deleted := false
+		for _, call := range fake.calls {
+			if strings.HasPrefix(call, "delete -f") {
+				deleted = true
+			}
+		}
+
+		if force {
+			if err != nil || !deleted {
+				t.Errorf("want --force to delete protected resources, got: %v, calls: %v", err, fake.calls)
+			}
+			continue
+		}
+
+		want := "refusing to uninstall as these resources are labelled arkade.alexellis.io/protected=true: Issuer/letsencrypt-prod-issuer, add --force to delete them"
+		if err == nil || err.Error() != want {
+			t.Errorf("want error %q, got: %v", want, err)
+		}
+		if deleted {
+			t.Errorf("want nothing deleted without --force, calls: %v", fake.calls)
+		}
+	}
+}
+
+func Test_deleteTLSSecret_OnlyWithFlag(t *testing.T) {
+	cases := []struct {
+		name         string
+		deleteSecret bool
+		yes          bool
+		stdin        string
+		wantDelete   bool
+	}{
+		{name: "uninstall without the flag", wantDelete: false},
+		{name: "flag with --yes", deleteSecret: true, yes: true, wantDelete: true},
+		{name: "flag confirmed", deleteSecret: true, stdin: "y\n", wantDelete: true},
+		{name: "flag declined", deleteSecret: true, stdin: "n\n", wantDelete: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeKubectl(t, map[string]execute.ExecResult{
+				"delete -f": {Stdout: "ingress.networking.k8s.io \"docker-registry\" deleted\n"},
+			})
+
+			var out bytes.Buffer
+			if err := uninstallRegistryIngress(&out, []byte("kind: Ingress\n"), false, false); err != nil {
+				t.Fatal(err)
+			}
+			if tc.deleteSecret {
+				if err := deleteTLSSecret(&out, strings.NewReader(tc.stdin), "registry", "docker-registry", tc.yes); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deleted := false
+			for _, call := range fake.calls {
+				if call == "delete secret docker-registry -n registry --ignore-not-found" {
+					deleted = true
+				}
+			}
+			if deleted != tc.wantDelete {
+				t.Errorf("want the secret deleted: %v, calls: %v", tc.wantDelete, fake.calls)
+			}
+		})
+	}
+}
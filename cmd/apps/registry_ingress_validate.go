@@ -0,0 +1,478 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+)
+
+// ownerReference is rendered into the metadata of each resource
+type ownerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+}
+
+var (
+	ownerKindRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	ownerNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	ownerUIDRegex  = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// parseOwnerReference reads apiVersion/kind/name/uid, where the
+// apiVersion may include a group i.e. apps/v1/Deployment/name/uid
+func parseOwnerReference(value string) (ownerReference, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 4 || len(parts) > 5 {
+		return ownerReference{}, fmt.Errorf("--owner-ref %q must be in the form apiVersion/kind/name/uid", value)
+	}
+
+	n := len(parts)
+	ref := ownerReference{
+		APIVersion: strings.Join(parts[:n-3], "/"),
+		Kind:       parts[n-3],
+		Name:       parts[n-2],
+		UID:        parts[n-1],
+	}
+
+	switch {
+	case strings.Contains(ref.APIVersion, "//") || strings.HasPrefix(ref.APIVersion, "/") || len(ref.APIVersion) == 0:
+		return ownerReference{}, fmt.Errorf("--owner-ref %q has an invalid apiVersion", value)
+	case !ownerKindRegex.MatchString(ref.Kind):
+		return ownerReference{}, fmt.Errorf("--owner-ref %q has an invalid kind %q, which must start with an upper case letter", value, ref.Kind)
+	case !ownerNameRegex.MatchString(ref.Name):
+		return ownerReference{}, fmt.Errorf("--owner-ref %q has an invalid name %q", value, ref.Name)
+	case !ownerUIDRegex.MatchString(ref.UID):
+		return ownerReference{}, fmt.Errorf("--owner-ref %q has an invalid uid %q, which must be a UUID", value, ref.UID)
+	}
+	return ref, nil
+}
+
+// parseAnnotations reads key=value pairs, the value may contain "="
+func parseAnnotations(values []string) (map[string]string, error) {
+	annotations := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("incorrect format for annotation `%s`, use key=value", value)
+		}
+		annotations[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return annotations, nil
+}
+
+// pinnableAPIVersions are the versions --api-version accepts for each
+// kind rendered by the templates
+var pinnableAPIVersions = map[string][]string{
+	"Ingress":        {"extensions/v1beta1", "networking.k8s.io/v1beta1", "networking.k8s.io/v1"},
+	"Issuer":         {"cert-manager.io/v1alpha2", "cert-manager.io/v1alpha3", "cert-manager.io/v1beta1", "cert-manager.io/v1"},
+	"ClusterIssuer":  {"cert-manager.io/v1alpha2", "cert-manager.io/v1alpha3", "cert-manager.io/v1beta1", "cert-manager.io/v1"},
+	"Certificate":    {"cert-manager.io/v1alpha2", "cert-manager.io/v1alpha3", "cert-manager.io/v1beta1", "cert-manager.io/v1"},
+	"HTTPProxy":      {"projectcontour.io/v1"},
+	"Middleware":     {"traefik.containo.us/v1alpha1", "traefik.io/v1alpha1"},
+	"ServiceMonitor": {"monitoring.coreos.com/v1"},
+}
+
+// validateAPIVersions checks each --api-version pin is for a known kind
+// and version
+func validateAPIVersions(apiVersions map[string]string) error {
+	for kind, version := range apiVersions {
+		allowed, ok := pinnableAPIVersions[kind]
+		if !ok {
+			return fmt.Errorf("--api-version cannot pin kind %q, use one of: Certificate, ClusterIssuer, HTTPProxy, Ingress, Issuer, Middleware, ServiceMonitor", kind)
+		}
+
+		known := false
+		for _, v := range allowed {
+			if v == version {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("--api-version %s=%s is not supported, use one of: %s", kind, version, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// parseKindAnnotations reads kind:key=value values, giving the
+// annotations for each kind
+func parseKindAnnotations(values []string) (map[string]map[string]string, error) {
+	kindAnnotations := map[string]map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		kind := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || len(kind) == 0 {
+			return nil, fmt.Errorf("incorrect format for --annotation-on `%s`, use kind:key=value", value)
+		}
+
+		annotation, err := parseAnnotations([]string{parts[1]})
+		if err != nil {
+			return nil, fmt.Errorf("incorrect format for --annotation-on `%s`, use kind:key=value", value)
+		}
+
+		if _, ok := kindAnnotations[kind]; !ok {
+			kindAnnotations[kind] = map[string]string{}
+		}
+		for key, annotationValue := range annotation {
+			kindAnnotations[kind][key] = annotationValue
+		}
+	}
+	return kindAnnotations, nil
+}
+
+// validateKindAnnotations checks each kind targeted by --annotation-on
+// is rendered for the options, i.e. there is no Ingress with --httpproxy
+func validateKindAnnotations(opts registryIngressOptions, hasNetworking bool) error {
+	if len(opts.KindAnnotations) == 0 {
+		return nil
+	}
+
+	yamlBytes, err := buildRegistryYAML(opts, hasNetworking)
+	if err != nil {
+		return err
+	}
+	objects, err := k8s.ParseObjects(yamlBytes)
+	if err != nil {
+		return err
+	}
+
+	emitted := map[string]bool{}
+	var kinds []string
+	for _, object := range objects {
+		if !emitted[object.Kind] {
+			emitted[object.Kind] = true
+			kinds = append(kinds, object.Kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	for kind := range opts.KindAnnotations {
+		if !emitted[kind] {
+			return fmt.Errorf("--annotation-on kind %q is not created by this app, use one of: %s", kind, strings.Join(kinds, ", "))
+		}
+	}
+	return nil
+}
+
+// captureGroupRegex finds references to capture groups such as $1
+var captureGroupRegex = regexp.MustCompile(`\$([0-9]+)`)
+
+// validatePathOptions checks the path is absolute and that a rewrite,
+// which only nginx supports, has the capture groups it references
+func validatePathOptions(opts registryIngressOptions) error {
+	path := opts.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("--path must start with /, got: %q", path)
+	}
+	if opts.HTTPProxy && (path != "/" || len(opts.RewriteTarget) > 0) {
+		return errors.New("--path and --rewrite-target are not supported with --httpproxy")
+	}
+
+	if len(opts.RewriteTarget) == 0 {
+		return nil
+	}
+	if opts.IngressClass != "nginx" {
+		return fmt.Errorf("--rewrite-target is only supported with the nginx ingress class, got: %s", opts.IngressClass)
+	}
+
+	pathRegex, err := regexp.Compile(path)
+	if err != nil {
+		return fmt.Errorf("--path must be a valid regular expression with --rewrite-target: %w", err)
+	}
+	for _, match := range captureGroupRegex.FindAllStringSubmatch(opts.RewriteTarget, -1) {
+		group, _ := strconv.Atoi(match[1])
+		if group > pathRegex.NumSubexp() {
+			return fmt.Errorf("--rewrite-target references %s, but --path %q has %d capture group(s), i.e. use --path /registry/(.*)", match[0], path, pathRegex.NumSubexp())
+		}
+	}
+	return nil
+}
+
+// sslProtocols are the protocols nginx accepts for each --min-tls-version
+var sslProtocols = map[string]string{
+	"1.2": "TLSv1.2 TLSv1.3",
+	"1.3": "TLSv1.3",
+}
+
+// validateMinTLSVersion checks --min-tls-version is a version which can
+// be enforced by the ingress class, or by Contour with --httpproxy
+func validateMinTLSVersion(opts registryIngressOptions) error {
+	if len(opts.MinTLSVersion) == 0 {
+		return nil
+	}
+	if _, ok := sslProtocols[opts.MinTLSVersion]; !ok {
+		return fmt.Errorf("--min-tls-version must be 1.2 or 1.3, but got: %q", opts.MinTLSVersion)
+	}
+	if !opts.HTTPProxy && opts.IngressClass != "nginx" {
+		return fmt.Errorf("--min-tls-version is only supported with the nginx ingress class or --httpproxy, got: %s", opts.IngressClass)
+	}
+	return nil
+}
+
+// validateService checks the registry's Service is named and has a
+// valid port
+func validateService(opts registryIngressOptions) error {
+	if len(opts.ServiceName) == 0 {
+		return errors.New("--service-name must be set")
+	}
+	if opts.ServicePort < 1 || opts.ServicePort > 65535 {
+		return fmt.Errorf("--service-port must be between 1 and 65535, got: %d", opts.ServicePort)
+	}
+	return nil
+}
+
+// validateTLSSecret checks --tls-secret is a valid secret name, and that
+// --skip-issuer is not combined with flags which configure the Issuer
+func validateTLSSecret(opts registryIngressOptions) error {
+	if len(opts.TLSSecret) > 0 && (len(opts.TLSSecret) > 253 || !ownerNameRegex.MatchString(opts.TLSSecret)) {
+		return fmt.Errorf("--tls-secret %q must be lower case alphanumeric characters, '-' or '.', starting and ending with an alphanumeric character", opts.TLSSecret)
+	}
+	if !opts.SkipIssuer {
+		return nil
+	}
+
+	if len(opts.ReleaseName) > 0 && len(opts.TLSSecret) == 0 {
+		return errors.New("--skip-issuer with --release-name needs --tls-secret, to name the secret which holds the certificate")
+	}
+	conflicts := map[string]bool{
+		"--staging":       opts.Staging,
+		"--acme-server":   len(opts.ACMEServer) > 0,
+		"--cert-duration": len(opts.CertDuration) > 0,
+		"--renew-before":  len(opts.RenewBefore) > 0,
+	}
+	for _, flag := range []string{"--staging", "--acme-server", "--cert-duration", "--renew-before"} {
+		if conflicts[flag] {
+			return fmt.Errorf("%s is not supported with --skip-issuer, which does not create an Issuer", flag)
+		}
+	}
+	return nil
+}
+
+// defaultSessionCookieName is the cookie set by nginx for
+// --session-affinity, unless --session-cookie-name is given
+const defaultSessionCookieName = "registry-session"
+
+// validateSessionAffinity checks --session-affinity is a type supported
+// by nginx, which is the only ingress class it is rendered for
+func validateSessionAffinity(opts registryIngressOptions) error {
+	if len(opts.SessionAffinity) == 0 {
+		return nil
+	}
+	if opts.SessionAffinity != "cookie" {
+		return fmt.Errorf("--session-affinity must be cookie, but got: %q", opts.SessionAffinity)
+	}
+	if opts.IngressClass != "nginx" || opts.HTTPProxy {
+		return fmt.Errorf("--session-affinity is only supported with the nginx ingress class, got: %s", opts.IngressClass)
+	}
+	return nil
+}
+
+// validateCertDurations checks --cert-duration and --renew-before are
+// durations, and that renewal starts within the certificate's lifetime
+func validateCertDurations(opts registryIngressOptions) error {
+	var duration, renewBefore time.Duration
+	var err error
+
+	if len(opts.CertDuration) > 0 {
+		if duration, err = time.ParseDuration(opts.CertDuration); err != nil || duration <= 0 {
+			return fmt.Errorf("--cert-duration must be a positive duration such as 2160h, got: %q", opts.CertDuration)
+		}
+	}
+	if len(opts.RenewBefore) > 0 {
+		if renewBefore, err = time.ParseDuration(opts.RenewBefore); err != nil || renewBefore <= 0 {
+			return fmt.Errorf("--renew-before must be a positive duration such as 360h, got: %q", opts.RenewBefore)
+		}
+	}
+
+	if duration > 0 && renewBefore >= duration {
+		return fmt.Errorf("--renew-before %s must be shorter than --cert-duration %s", opts.RenewBefore, opts.CertDuration)
+	}
+	return nil
+}
+
+// maxSizeBytes converts a --max-size in nginx's format, such as 200m, to
+// bytes. The suffixes k, m and g are powers of 1024, and 0 is no limit.
+func maxSizeBytes(size string) (int64, error) {
+	units := map[string]int64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30}
+
+	number, multiplier := size, int64(1)
+	if len(size) > 0 {
+		if unit, ok := units[strings.ToLower(size[len(size)-1:])]; ok {
+			number, multiplier = size[:len(size)-1], unit
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("--max-size must be a number of bytes with an optional k, m or g suffix such as 200m, but got: %q", size)
+	}
+	return value * multiplier, nil
+}
+
+// maxSizeRegex matches the sizes accepted by nginx's client_max_body_size
+// which are useful for a registry, a bare number is a size in bytes and
+// so only 0 is accepted without a suffix
+var maxSizeRegex = regexp.MustCompile(`^(0|[0-9]+[kmg])$`)
+
+// normalizeMaxSize checks --max-size is a size such as 200m, 1g or 0 for
+// no limit, and gives it with a lower case suffix
+func normalizeMaxSize(size string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(size))
+	if !maxSizeRegex.MatchString(normalized) {
+		return "", fmt.Errorf("--max-size must be a number with a k, m or g suffix such as 200m, or 0 for no limit, but got: %q", size)
+	}
+	return normalized, nil
+}
+
+// validateCanaryOptions checks the weight is a percentage and that there
+// is a service to send the traffic to, canaries are only supported by
+// the nginx ingress controller
+func validateCanaryOptions(opts registryIngressOptions) error {
+	if opts.CanaryWeight < 0 && len(opts.CanaryServiceName) == 0 {
+		return nil
+	}
+
+	if opts.HTTPProxy {
+		return errors.New("--canary-weight is not supported with --httpproxy")
+	}
+
+	if opts.CanaryWeight < 0 || opts.CanaryWeight > 100 {
+		return fmt.Errorf("--canary-weight must be between 0 and 100, got: %d", opts.CanaryWeight)
+	}
+
+	if opts.IngressClass != "nginx" {
+		return fmt.Errorf("--canary-weight is only supported with the nginx ingress class, got: %s", opts.IngressClass)
+	}
+
+	if len(opts.CanaryServiceName) == 0 {
+		return errors.New("--canary-service-name is required with --canary-weight")
+	}
+
+	if opts.CanaryServicePort < 1 || opts.CanaryServicePort > 65535 {
+		return fmt.Errorf("--canary-service-port must be between 1 and 65535, got: %d", opts.CanaryServicePort)
+	}
+
+	return nil
+}
+
+// hostnameRegex matches a DNS name made of labels of letters, digits
+// and hyphens, with no leading or trailing hyphen
+var hostnameRegex = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([-A-Za-z0-9]{0,61}[A-Za-z0-9])?$`)
+
+// validateDomain checks a domain is a bare hostname, as used for the
+// host of the Ingress, listing everything which needs to be removed.
+// Let's Encrypt only issues wildcard certificates via dns01.
+func validateDomain(flag, domain string, http01 bool) error {
+	var problems []string
+	host := domain
+
+	if i := strings.Index(host, "://"); i > -1 {
+		problems = append(problems, fmt.Sprintf("remove the scheme %q", host[:i+3]))
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i > -1 {
+		problems = append(problems, fmt.Sprintf("remove the path %q", host[i:]))
+		host = host[:i]
+	}
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		problems = append(problems, fmt.Sprintf("remove the port %q", port))
+		host = hostname
+	}
+	if strings.HasPrefix(host, "*.") {
+		if http01 {
+			problems = append(problems, "wildcard hosts can't be solved with http01, use dns01 instead")
+		}
+		host = host[2:]
+	}
+	if len(host) > 253 || !hostnameRegex.MatchString(host) {
+		problems = append(problems, fmt.Sprintf("%q is not a valid hostname", host))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s %q must be a bare hostname such as registry.example.com: %s", flag, domain, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// parseRegistryHost reads a --host value of domain or domain:solver
+func parseRegistryHost(value string) (registryHost, error) {
+	host := registryHost{Domain: value, Solver: "http01"}
+	if i := strings.LastIndex(value, ":"); i > -1 {
+		host.Domain, host.Solver = value[:i], value[i+1:]
+	}
+
+	if len(host.Domain) == 0 {
+		return registryHost{}, fmt.Errorf("incorrect format for --host `%s`, use domain or domain:solver", value)
+	}
+	if host.Solver != "http01" && host.Solver != "dns01" {
+		return registryHost{}, fmt.Errorf("--host %s has an unknown solver %q, use http01 or dns01", host.Domain, host.Solver)
+	}
+	return host, nil
+}
+
+// validateHosts checks each --host is unique and that a DNS01 provider
+// is configured for hosts which use dns01
+func validateHosts(opts registryIngressOptions) error {
+	if len(opts.Hosts) == 0 {
+		return nil
+	}
+
+	if opts.HTTPProxy {
+		return errors.New("--host and more than one --domain are not supported with --httpproxy")
+	}
+
+	seen := map[string]bool{opts.Domain: true}
+	for _, host := range opts.Hosts {
+		if seen[host.Domain] {
+			return fmt.Errorf("%s is given more than once with --domain or --host", host.Domain)
+		}
+		seen[host.Domain] = true
+
+		if host.Solver == "dns01" && len(opts.DNS01Provider) == 0 {
+			return fmt.Errorf("--host %s uses dns01, which needs --dns-provider", host.Domain)
+		}
+		if err := validateDomain("--host", host.Domain, host.Solver == "http01" && opts.Challenge != "dns01"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultMonitorSelector selects the Services labelled by arkade, so that
+// the ServiceMonitor only targets the controller which arkade installed
+func defaultMonitorSelector() map[string]string {
+	parts := strings.SplitN(k8s.ManagedBySelector, "=", 2)
+	return map[string]string{parts[0]: parts[1]}
+}
+
+// validateServiceMonitor checks --monitor-selector is only given with
+// --service-monitor, and that each label has a key and a value
+func validateServiceMonitor(opts registryIngressOptions) error {
+	if len(opts.MonitorSelector) == 0 {
+		return nil
+	}
+	if !opts.ServiceMonitor {
+		return errors.New("--monitor-selector is only used with --service-monitor")
+	}
+	for key, value := range opts.MonitorSelector {
+		if len(strings.TrimSpace(key)) == 0 || len(value) == 0 {
+			return fmt.Errorf("--monitor-selector %q must be given as key=value", key+"="+value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_parseAnnotations(t *testing.T) {
+	got, err := parseAnnotations([]string{"a=b", "c=d=e"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["a"] != "b" || got["c"] != "d=e" {
+		t.Errorf("unexpected annotations: %v", got)
+	}
+
+	if _, err := parseAnnotations([]string{"no-value"}); err == nil {
+		t.Errorf("want an error for an annotation without a value")
+	}
+}
+
+func Test_validateCanaryOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    registryIngressOptions
+		wantErr string
+	}{
+		{
+			name: "no canary",
+			opts: registryIngressOptions{IngressClass: "traefik", CanaryWeight: -1},
+		},
+		{
+			name:    "weight out of range",
+			opts:    registryIngressOptions{IngressClass: "nginx", CanaryWeight: 101, CanaryServiceName: "next", CanaryServicePort: 5000},
+			wantErr: "--canary-weight must be between 0 and 100",
+		},
+		{
+			name:    "missing service",
+			opts:    registryIngressOptions{IngressClass: "nginx", CanaryWeight: 10, CanaryServicePort: 5000},
+			wantErr: "--canary-service-name is required",
+		},
+		{
+			name:    "not nginx",
+			opts:    registryIngressOptions{IngressClass: "traefik", CanaryWeight: 10, CanaryServiceName: "next", CanaryServicePort: 5000},
+			wantErr: "only supported with the nginx ingress class",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCanaryOptions(tc.opts)
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("want error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_parseOwnerReference_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"v1/ConfigMap/settings",
+		"v1/configmap/settings/5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01",
+		"v1/ConfigMap/Settings/5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01",
+		"v1/ConfigMap/settings/not-a-uid",
+		"/ConfigMap/settings/5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01",
+	} {
+		if _, err := parseOwnerReference(value); err == nil {
+			t.Errorf("want %q to be rejected", value)
+		}
+	}
+
+	ref, err := parseOwnerReference("v1/ConfigMap/settings/5f0c8b2a-9d0e-4a7b-8c11-2b6b1f4a9e01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.APIVersion != "v1" || ref.Kind != "ConfigMap" {
+		t.Errorf("want a core v1 ConfigMap, got: %+v", ref)
+	}
+}
+
+func Test_validateCanaryOptions_HTTPProxy(t *testing.T) {
+	opts := registryIngressOptions{
+		IngressClass:      "nginx",
+		CanaryWeight:      10,
+		CanaryServiceName: "registry-next",
+		CanaryServicePort: 5000,
+		HTTPProxy:         true,
+	}
+
+	if err := validateCanaryOptions(opts); err == nil {
+		t.Errorf("want canaries to be rejected with --httpproxy")
+	}
+}
+
+func Test_validateKindAnnotations_UnknownKind(t *testing.T) {
+	opts := registryIngressOptions{
+		Domain:       "registry.example.com",
+		Email:        "admin@example.com",
+		IngressClass: "contour",
+		Namespace:    "registry",
+		HTTPProxy:    true,
+		KindAnnotations: map[string]map[string]string{
+			"Ingress": {"example.com/team": "platform"},
+		},
+	}
+
+	err := validateKindAnnotations(opts, true)
+	if err == nil {
+		t.Fatal("want an error as no Ingress is created with --httpproxy")
+	}
+	if !strings.Contains(err.Error(), "Certificate, HTTPProxy, Issuer") {
+		t.Errorf("want the created kinds listed, got: %s", err)
+	}
+}
+
+func Test_parseKindAnnotations_Invalid(t *testing.T) {
+	for _, value := range []string{"example.com/team=platform", ":key=value", "Issuer:key"} {
+		if _, err := parseKindAnnotations([]string{value}); err == nil {
+			t.Errorf("want %q to be rejected", value)
+		}
+	}
+}
+
+func Test_validateCertDurations(t *testing.T) {
+	cases := []struct {
+		duration    string
+		renewBefore string
+		wantErr     bool
+	}{
+		{duration: "2160h", renewBefore: "360h"},
+		{renewBefore: "360h"},
+		{duration: "90d", wantErr: true},
+		{renewBefore: "-1h", wantErr: true},
+		{duration: "24h", renewBefore: "48h", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		err := validateCertDurations(registryIngressOptions{CertDuration: tc.duration, RenewBefore: tc.renewBefore})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("duration %q renew-before %q, want error: %t, got: %v", tc.duration, tc.renewBefore, tc.wantErr, err)
+		}
+	}
+}
+
+func Test_validateHosts(t *testing.T) {
+	cases := []struct {
+		name string
+		opts registryIngressOptions
+	}{
+		{
+			name: "dns01 without a provider",
+			opts: registryIngressOptions{Domain: "registry.example.com", Hosts: []registryHost{{Domain: "mirror.example.com", Solver: "dns01"}}},
+		},
+		{
+			name: "same as --domain",
+			opts: registryIngressOptions{Domain: "registry.example.com", Hosts: []registryHost{{Domain: "registry.example.com", Solver: "http01"}}},
+		},
+		{
+			name: "with --httpproxy",
+			opts: registryIngressOptions{Domain: "registry.example.com", HTTPProxy: true, Hosts: []registryHost{{Domain: "mirror.example.com", Solver: "http01"}}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateHosts(tc.opts); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+
+	if _, err := parseRegistryHost("mirror.example.com:tls-alpn01"); err == nil {
+		t.Error("want an unknown solver to be rejected")
+	}
+}
+
+func Test_validatePathOptions(t *testing.T) {
+	cases := []struct {
+		name string
+		opts registryIngressOptions
+	}{
+		{name: "no capture group", opts: registryIngressOptions{IngressClass: "nginx", Path: "/registry", RewriteTarget: "/$1"}},
+		{name: "relative path", opts: registryIngressOptions{IngressClass: "nginx", Path: "registry"}},
+		{name: "traefik", opts: registryIngressOptions{IngressClass: "traefik", Path: "/registry/(.*)", RewriteTarget: "/$1"}},
+		{name: "httpproxy", opts: registryIngressOptions{IngressClass: "contour", HTTPProxy: true, Path: "/registry"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validatePathOptions(tc.opts); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
+
+func Test_validateAPIVersions_Invalid(t *testing.T) {
+	for _, apiVersions := range []map[string]string{
+		{"Ingress": "networking.k8s.io/v2"},
+		{"Deployment": "apps/v1"},
+	} {
+		if err := validateAPIVersions(apiVersions); err == nil {
+			t.Errorf("want %v to be rejected", apiVersions)
+		}
+	}
+}
+
+func Test_validateSessionAffinity(t *testing.T) {
+	cases := map[string]registryIngressOptions{
+		"unknown type":  {IngressClass: "nginx", SessionAffinity: "balanced", SessionCookieName: "route"},
+		"traefik class": {IngressClass: "traefik", SessionAffinity: "cookie", SessionCookieName: "route"},
+		"httpproxy":     {IngressClass: "nginx", HTTPProxy: true, SessionAffinity: "cookie"},
+	}
+	for name, opts := range cases {
+		if err := validateSessionAffinity(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func Test_validateDomain(t *testing.T) {
+	cases := []struct {
+		domain  string
+		http01  bool
+		wantErr []string
+	}{
+		{domain: "registry.example.com", http01: true},
+		{domain: "*.example.com", http01: false},
+		{domain: "https://registry.example.com", http01: true, wantErr: []string{`remove the scheme "https://"`}},
+		{domain: "registry.example.com/", http01: true, wantErr: []string{`remove the path "/"`}},
+		{domain: "registry.example.com:5000", http01: true, wantErr: []string{`remove the port "5000"`}},
+		{domain: "https://registry.example.com:443/v2/", http01: true, wantErr: []string{"scheme", "port", `path "/v2/"`}},
+		{domain: "*.example.com", http01: true, wantErr: []string{"wildcard hosts can't be solved with http01"}},
+		{domain: "registry_example.com", http01: true, wantErr: []string{"not a valid hostname"}},
+	}
+
+	for _, tc := range cases {
+		err := validateDomain("--domain", tc.domain, tc.http01)
+		if len(tc.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s: want no error, got: %s", tc.domain, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: want an error", tc.domain)
+			continue
+		}
+		for _, want := range tc.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: want %q in the error, got: %s", tc.domain, want, err)
+			}
+		}
+	}
+}
+
+func Test_validateService_Port(t *testing.T) {
+	for _, port := range []int{0, -1, 65536} {
+		if err := validateService(registryIngressOptions{ServiceName: "docker-registry", ServicePort: port}); err == nil {
+			t.Errorf("want port %d to be rejected", port)
+		}
+	}
+	if err := validateService(registryIngressOptions{ServiceName: "docker-registry", ServicePort: 65535}); err != nil {
+		t.Errorf("want port 65535 to be valid, got: %s", err)
+	}
+}
+
+func Test_validateMinTLSVersion(t *testing.T) {
+	cases := map[string]registryIngressOptions{
+		"unknown version": {IngressClass: "nginx", MinTLSVersion: "1.1"},
+		"traefik class":   {IngressClass: "traefik", MinTLSVersion: "1.2"},
+	}
+	for name, opts := range cases {
+		if err := validateMinTLSVersion(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+
+	if err := validateMinTLSVersion(registryIngressOptions{IngressClass: "contour", HTTPProxy: true, MinTLSVersion: "1.3"}); err != nil {
+		t.Errorf("want --httpproxy to support a minimum version, got: %s", err)
+	}
+}
+
+func Test_normalizeMaxSize(t *testing.T) {
+	cases := []struct {
+		size    string
+		want    string
+		wantErr bool
+	}{
+		{size: "200m", want: "200m"},
+		{size: "200M", want: "200m"},
+		{size: " 1g ", want: "1g"},
+		{size: "512k", want: "512k"},
+		{size: "0", want: "0"},
+		{size: "200", wantErr: true},
+		{size: "200mb", wantErr: true},
+		{size: "1.5g", wantErr: true},
+		{size: "-1m", wantErr: true},
+		{size: "m", wantErr: true},
+		{size: "", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.size, func(t *testing.T) {
+			got, err := normalizeMaxSize(tc.size)
+			if tc.wantErr {
+				want := fmt.Sprintf("--max-size must be a number with a k, m or g suffix such as 200m, or 0 for no limit, but got: %q", tc.size)
+				if err == nil || err.Error() != want {
+					t.Fatalf("want error %q, got: %v", want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_maxSizeBytes(t *testing.T) {
+	cases := map[string]int64{"0": 0, "1024": 1024, "8k": 8192, "200m": 209715200, "1G": 1073741824}
+	for size, want := range cases {
+		got, err := maxSizeBytes(size)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", size, err)
+		}
+		if got != want {
+			t.Errorf("%s: want %d, got %d", size, want, got)
+		}
+	}
+
+	for _, size := range []string{"", "m", "200mb", "-1"} {
+		if _, err := maxSizeBytes(size); err == nil {
+			t.Errorf("want %q to be rejected", size)
+		}
+	}
+}
+
+func Test_validateTLSSecret(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    registryIngressOptions
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "tls secret only", opts: registryIngressOptions{TLSSecret: "wildcard.example.com"}},
+		{name: "skip issuer", opts: registryIngressOptions{TLSSecret: "wildcard", SkipIssuer: true}},
+		{name: "skip issuer with default secret", opts: registryIngressOptions{SkipIssuer: true}},
+		{
+			name:    "invalid name",
+			opts:    registryIngressOptions{TLSSecret: "Wildcard_Cert"},
+			wantErr: `--tls-secret "Wildcard_Cert" must be lower case alphanumeric characters, '-' or '.', starting and ending with an alphanumeric character`,
+		},
+		{
+			name:    "release name without secret",
+			opts:    registryIngressOptions{SkipIssuer: true, ReleaseName: "team-a"},
+			wantErr: "--skip-issuer with --release-name needs --tls-secret, to name the secret which holds the certificate",
+		},
+		{
+			name:    "staging",
+			opts:    registryIngressOptions{SkipIssuer: true, Staging: true},
+			wantErr: "--staging is not supported with --skip-issuer, which does not create an Issuer",
+		},
+		{
+			name:    "renew before",
+			opts:    registryIngressOptions{SkipIssuer: true, RenewBefore: "360h"},
+			wantErr: "--renew-before is not supported with --skip-issuer, which does not create an Issuer",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTLSSecret(tc.opts)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("want error %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_validateServiceMonitor(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    registryIngressOptions
+		wantErr string
+	}{
+		{name: "default selector", opts: registryIngressOptions{ServiceMonitor: true}},
+		{
+			name: "selector",
+			opts: registryIngressOptions{ServiceMonitor: true, MonitorSelector: map[string]string{"app.kubernetes.io/name": "ingress-nginx"}},
+		},
+		{
+			name:    "selector without service monitor",
+			opts:    registryIngressOptions{MonitorSelector: map[string]string{"app.kubernetes.io/name": "ingress-nginx"}},
+			wantErr: "--monitor-selector is only used with --service-monitor",
+		},
+		{
+			name:    "empty value",
+			opts:    registryIngressOptions{ServiceMonitor: true, MonitorSelector: map[string]string{"app": ""}},
+			wantErr: `--monitor-selector "app=" must be given as key=value`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateServiceMonitor(tc.opts)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("want error %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/get"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/alexellis/arkade/pkg/retry"
+	"github.com/alexellis/arkade/pkg/timestamp"
+)
+
+// certManagerNamespace is where cert-manager is installed by default,
+// which is also its cluster resource namespace
+const certManagerNamespace = "cert-manager"
+
+// certManagerPollInterval is how often the cert-manager logs and the
+// Certificate's status are checked when watching
+var certManagerPollInterval = time.Second * 5
+
+// watchCertManager prints the cert-manager log lines which mention the
+// namespace or domain, until the Certificate is Ready or ctx is done
+func watchCertManager(ctx context.Context, w io.Writer, namespace, certName, domain string, interval time.Duration) error {
+	fmt.Fprintf(w, "Watching cert-manager logs for %s, press Control+C to stop\n", domain)
+
+	since := time.Now().Add(-interval)
+	for {
+		next := time.Now()
+		res, err := k8s.KubectlTask("logs", "-n", certManagerNamespace, "deploy/cert-manager",
+			"--since-time="+timestamp.Format(since))
+		if err != nil {
+			return err
+		}
+		since = next
+
+		for _, line := range strings.Split(res.Stdout, "\n") {
+			if strings.Contains(line, namespace+"/") || strings.Contains(line, domain) {
+				fmt.Fprintln(w, line)
+			}
+		}
+
+		ready, err := certificateReady(namespace, certName)
+		if err != nil {
+			return err
+		}
+		if ready {
+			fmt.Fprintf(w, "Certificate %s/%s is Ready\n", namespace, certName)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(w, "Stopped watching cert-manager")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// certificateReady reports whether the Certificate has a Ready condition
+// with a status of True
+func certificateReady(namespace, name string) (bool, error) {
+	return resourceReady("certificate", namespace, name)
+}
+
+// resourceReady reports whether a resource such as an Issuer or
+// Certificate has a Ready condition with a status of True
+func resourceReady(kind, namespace, name string) (bool, error) {
+	ready, err := checkResourceReady(kind, namespace, name)
+	if isTransientError(err) {
+		return false, nil
+	}
+	return ready, err
+}
+
+// checkResourceReady is resourceReady, but gives errTransientKubectl
+// when kubectl fails for a reason such as the API server being briefly
+// unreachable, so that the check can be retried
+func checkResourceReady(kind, namespace, name string) (bool, error) {
+	res, err := k8s.KubectlTask("get", kind, name, "-n", namespace,
+		"-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+	if err != nil {
+		return false, err
+	}
+
+	if res.ExitCode != 0 && isTransientKubectlError(res.Stderr) {
+		return false, fmt.Errorf("%w: %s", errTransientKubectl, strings.TrimSpace(res.Stderr))
+	}
+
+	return res.ExitCode == 0 && strings.TrimSpace(res.Stdout) == "True", nil
+}
+
+// readyWait is a resource to wait for with --wait, each has its own
+// timeout so that a slow Issuer does not use up the Certificate's
+type readyWait struct {
+	Kind    string
+	Name    string
+	Timeout time.Duration
+}
+
+// newPollPolicy backs off from min to max between readiness checks, so
+// that a quick Issuer is seen promptly, without frequent checks during
+// a slow ACME issuance
+func newPollPolicy(min, max time.Duration) retry.Policy {
+	return retry.Policy{
+		Initial:    min,
+		Max:        max,
+		Multiplier: 2,
+	}
+}
+
+// troubleshootingBanner prints the tips from RegistryIngressInfoMsg
+// once, when --wait has gone on for After without everything being Ready
+type troubleshootingBanner struct {
+	After time.Duration
+
+	started time.Time
+	printed bool
+	now     func() time.Time
+}
+
+// newTroubleshootingBanner starts the clock for the banner, a zero
+// duration gives a banner which is never printed
+func newTroubleshootingBanner(after time.Duration) *troubleshootingBanner {
+	return &troubleshootingBanner{After: after, started: time.Now(), now: time.Now}
+}
+
+// check prints the banner if it is due and hasn't been printed yet
+func (b *troubleshootingBanner) check(w io.Writer) {
+	if b == nil || b.After <= 0 || b.printed {
+		return
+	}
+
+	if elapsed := b.now().Sub(b.started); elapsed >= b.After {
+		fmt.Fprintf(w, "\nStill waiting after %s, whilst you wait you can troubleshoot with:\n\n%s\n\n", elapsed.Round(time.Second), RegistryIngressInfoMsg)
+		b.printed = true
+	}
+}
+
+// waitForResources waits for each resource in turn to become Ready,
+// polling as per the poll policy's delays, until its own timeout or
+// ctx's deadline. Transient kubectl errors are retried from budget. The
+// banner, if any, is checked after each poll.
+func waitForResources(ctx context.Context, w io.Writer, namespace string, waits []readyWait, poll retry.Policy, budget *retry.Budget, banner *troubleshootingBanner) error {
+	for _, wait := range waits {
+		if err := waitForReady(ctx, w, namespace, wait, poll, budget, banner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func waitForReady(parent context.Context, w io.Writer, namespace string, wait readyWait, poll retry.Policy, budget *retry.Budget, banner *troubleshootingBanner) error {
+	ctx, cancel := context.WithTimeout(parent, wait.Timeout)
+	defer cancel()
+
+	fmt.Fprintf(w, "Waiting up to %s for %s %s/%s to be Ready\n", wait.Timeout, wait.Kind, namespace, wait.Name)
+	for polls := 1; ; polls++ {
+		var ready bool
+		err := budget.Do(ctx, isTransientError, func() error {
+			var err error
+			ready, err = checkResourceReady(strings.ToLower(wait.Kind), namespace, wait.Name)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if ready {
+			fmt.Fprintf(w, "%s %s/%s is Ready\n", wait.Kind, namespace, wait.Name)
+			return nil
+		}
+
+		delay := poll.Delay(polls)
+		fmt.Fprintf(w, "%s %s/%s is not Ready, checking again in %s\n", wait.Kind, namespace, wait.Name, delay)
+		banner.check(w)
+
+		select {
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return fmt.Errorf("--wait-timeout was reached waiting for %s %s/%s to be Ready", wait.Kind, namespace, wait.Name)
+			}
+			return fmt.Errorf("timed out after %s waiting for %s %s/%s to be Ready", wait.Timeout, wait.Kind, namespace, wait.Name)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// letsEncryptOrganization is part of the issuer's organization in both
+// the production and staging certificates
+const letsEncryptOrganization = "Let's Encrypt"
+
+// newTLSVerifyClient gives a client for verifyTLS. The chain is checked
+// against caFile when given, otherwise against the system roots, unless
+// the certificate comes from the Let's Encrypt staging server or a
+// custom --acme-server, whose roots are not publicly trusted. Then only
+// the hostname, expiry and issuer are checked by verifyTLS.
+func newTLSVerifyClient(opts registryIngressOptions, caFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if len(caFile) > 0 {
+		bundle, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read --verify-tls-ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM certificates were found in --verify-tls-ca %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	} else if opts.Staging || len(opts.ACMEServer) > 0 {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+// verifyTLSUntil retries verifyTLS until it succeeds or ctx is done,
+// giving the last error
+func verifyTLSUntil(ctx context.Context, client *http.Client, baseURL, domain, issuerOrganization string, interval time.Duration) error {
+	for {
+		err := verifyTLS(client, baseURL, domain, issuerOrganization)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
+
+// verifyTLS requests the registry's /v2/ endpoint and checks the served
+// certificate is valid for the domain and was issued by an organization
+// containing issuerOrganization. Any HTTP status is accepted, since the
+// registry may require authentication.
+func verifyTLS(client *http.Client, baseURL, domain, issuerOrganization string) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/v2/", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", get.UserAgent())
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.TLS == nil || len(res.TLS.PeerCertificates) == 0 {
+		return fmt.Errorf("no certificate was served by %s", baseURL)
+	}
+
+	cert := res.TLS.PeerCertificates[0]
+	if err := cert.VerifyHostname(domain); err != nil {
+		return err
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate for %s is only valid from %s until %s", domain, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+
+	for _, organization := range cert.Issuer.Organization {
+		if strings.Contains(organization, issuerOrganization) {
+			return nil
+		}
+	}
+	return fmt.Errorf("certificate was issued by %q, not %s", cert.Issuer.String(), issuerOrganization)
+}
@@ -0,0 +1,330 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexellis/arkade/pkg/retry"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_watchCertManager_StopsWhenReady(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{})
+	fake.queue("logs -n cert-manager deploy/cert-manager",
+		execute.ExecResult{Stdout: "I0701 challenges.go:100] registry/docker-registry-1 presenting challenge\nI0701 other-ns/app unrelated\n"},
+		execute.ExecResult{Stdout: "I0701 certificates.go:50] registry.example.com issued\n"},
+	)
+	fake.queue("get certificate docker-registry -n registry",
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "True"},
+	)
+
+	var out bytes.Buffer
+	err := watchCertManager(context.Background(), &out, "registry", "docker-registry", "registry.example.com", time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"registry/docker-registry-1 presenting challenge",
+		"registry.example.com issued",
+		"Certificate registry/docker-registry is Ready",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "unrelated") {
+		t.Errorf("want log lines for other namespaces to be filtered, got:\n%s", got)
+	}
+
+	logCalls := 0
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "logs ") {
+			logCalls++
+		}
+	}
+	if logCalls != 2 {
+		t.Errorf("want the logs to be tailed until Ready, 2 calls, got: %d", logCalls)
+	}
+}
+
+func Test_waitForResources_EachWaitHasItsOwnTimeout(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get certificate docker-registry -n registry": {Stdout: "True"},
+	})
+	// The Issuer takes several polls, longer than the Certificate's
+	// timeout, but within its own
+	fake.queue("get issuer letsencrypt-prod-issuer -n registry",
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "True"},
+	)
+
+	waits := []readyWait{
+		{Kind: "Issuer", Name: "letsencrypt-prod-issuer", Timeout: time.Second * 5},
+		{Kind: "Certificate", Name: "docker-registry", Timeout: time.Millisecond * 20},
+	}
+
+	var out bytes.Buffer
+	if err := waitForResources(context.Background(), &out, "registry", waits, newPollPolicy(time.Millisecond*10, time.Millisecond*10), retry.NewBudget(0, 0), nil); err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out.String())
+	}
+
+	for _, want := range []string{"Issuer registry/letsencrypt-prod-issuer is Ready", "Certificate registry/docker-registry is Ready"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func Test_waitForResources_TimesOutOnSlowResource(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get issuer letsencrypt-prod-issuer -n registry": {Stdout: "True"},
+		"get certificate docker-registry -n registry":    {Stdout: "False"},
+	})
+
+	waits := []readyWait{
+		{Kind: "Issuer", Name: "letsencrypt-prod-issuer", Timeout: time.Millisecond * 20},
+		{Kind: "Certificate", Name: "docker-registry", Timeout: time.Millisecond * 50},
+	}
+
+	start := time.Now()
+	err := waitForResources(context.Background(), ioutil.Discard, "registry", waits, newPollPolicy(time.Millisecond*5, time.Millisecond*5), retry.NewBudget(0, 0), nil)
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms waiting for Certificate registry/docker-registry") {
+		t.Fatalf("want the Certificate to time out, got: %v", err)
+	}
+	if elapsed < time.Millisecond*50 {
+		t.Errorf("want the Certificate to get its full 50ms, returned after: %s", elapsed)
+	}
+
+	if got := strings.Count(strings.Join(fake.calls, "\n"), "get issuer"); got != 1 {
+		t.Errorf("want the Issuer to be checked once, got %d calls: %v", got, fake.calls)
+	}
+}
+
+func Test_verifyTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/" {
+			t.Errorf("want a request to /v2/, got: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	// The test server's certificate is for example.com and *.example.com, issued by Acme Co
+	cases := []struct {
+		name         string
+		domain       string
+		organization string
+		wantErr      string
+	}{
+		{name: "matching certificate", domain: "example.com", organization: "Acme Co"},
+		{name: "wrong domain", domain: "registry.example.org", organization: "Acme Co", wantErr: "registry.example.org"},
+		{name: "wrong issuer", domain: "example.com", organization: letsEncryptOrganization, wantErr: "not Let's Encrypt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyTLS(server.Client(), server.URL, tc.domain, tc.organization)
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("want verification to pass, got: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("want an error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_newTLSVerifyClient_PrivateCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, bundle, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	acme := registryIngressOptions{ACMEServer: "https://acme.internal.example.com/directory"}
+	cases := []struct {
+		name    string
+		opts    registryIngressOptions
+		caFile  string
+		wantErr bool
+	}{
+		{name: "public ACME server is checked against the system roots", opts: registryIngressOptions{}, wantErr: true},
+		{name: "private ACME server checks the hostname only", opts: acme},
+		{name: "private ACME server with its CA bundle", opts: acme, caFile: caFile},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := newTLSVerifyClient(tc.opts, tc.caFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = verifyTLS(client, server.URL, "example.com", "")
+			if tc.wantErr != (err != nil) {
+				t.Errorf("want an error: %t, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTLSVerifyClient(acme, notPEM); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Errorf("want an error for a bundle without certificates, got: %v", err)
+	}
+}
+
+func Test_verifyTLSUntil_GivesUpAfterTimeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+
+	err := verifyTLSUntil(ctx, server.Client(), server.URL, "registry.example.org", "Acme Co", time.Millisecond*5)
+	if err == nil {
+		t.Fatal("want an error once the timeout passes")
+	}
+}
+
+func Test_waitForResources_PrintsTipsAfterBanner(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get certificate docker-registry -n registry": {Stdout: "True"},
+	})
+	fake.queue("get certificate docker-registry -n registry",
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+	)
+
+	// Each poll is made to look like it took 30s of slow issuance
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	banner := &troubleshootingBanner{After: time.Minute, started: now, now: func() time.Time {
+		now = now.Add(time.Second * 30)
+		return now
+	}}
+
+	waits := []readyWait{{Kind: "Certificate", Name: "docker-registry", Timeout: time.Second * 5}}
+
+	var out bytes.Buffer
+	if err := waitForResources(context.Background(), &out, "registry", waits, newPollPolicy(time.Millisecond, time.Millisecond), retry.NewBudget(0, 0), banner); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Count(out.String(), RegistryIngressInfoMsg); got != 1 {
+		t.Fatalf("want the tips printed once, got %d times:\n%s", got, out.String())
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	notReady := 0
+	for _, line := range lines {
+		if strings.Contains(line, "is not Ready") {
+			notReady++
+		}
+		if strings.HasPrefix(line, "Still waiting after 1m0s") {
+			break
+		}
+	}
+	if notReady != 2 {
+		t.Errorf("want the tips after the second poll, when 60s had passed, got them after %d:\n%s", notReady, out.String())
+	}
+}
+
+func Test_waitForResources_NoTipsWhenReadyInTime(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get certificate docker-registry -n registry": {Stdout: "True"},
+	})
+
+	banner := newTroubleshootingBanner(time.Minute)
+	waits := []readyWait{{Kind: "Certificate", Name: "docker-registry", Timeout: time.Second * 5}}
+
+	var out bytes.Buffer
+	if err := waitForResources(context.Background(), &out, "registry", waits, newPollPolicy(time.Millisecond, time.Millisecond), retry.NewBudget(0, 0), banner); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out.String(), RegistryIngressInfoMsg) {
+		t.Errorf("want no tips when the Certificate was Ready straight away, got:\n%s", out.String())
+	}
+}
+
+func Test_waitForResources_WaitTimeoutCapsEveryWait(t *testing.T) {
+	useFakeKubectl(t, map[string]execute.ExecResult{
+		"get issuer letsencrypt-prod-issuer -n registry": {Stdout: "True"},
+		"get certificate docker-registry -n registry":    {Stdout: "False"},
+	})
+
+	waits := []readyWait{
+		{Kind: "Issuer", Name: "letsencrypt-prod-issuer", Timeout: time.Second * 5},
+		{Kind: "Certificate", Name: "docker-registry", Timeout: time.Second * 5},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+
+	start := time.Now()
+	err := waitForResources(ctx, ioutil.Discard, "registry", waits, newPollPolicy(time.Millisecond*5, time.Millisecond*5), retry.NewBudget(0, 0), nil)
+	if err == nil || !strings.Contains(err.Error(), "--wait-timeout was reached waiting for Certificate registry/docker-registry") {
+		t.Fatalf("want the overall timeout for the Certificate, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("want the wait to stop at --wait-timeout, took: %s", elapsed)
+	}
+}
+
+func Test_waitForResources_PollsWithBackoff(t *testing.T) {
+	fake := useFakeKubectl(t, map[string]execute.ExecResult{
+		"get certificate docker-registry -n registry": {Stdout: "True"},
+	})
+	fake.queue("get certificate docker-registry -n registry",
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+		execute.ExecResult{Stdout: "False"},
+	)
+
+	waits := []readyWait{{Kind: "Certificate", Name: "docker-registry", Timeout: time.Second * 5}}
+
+	var out bytes.Buffer
+	if err := waitForResources(context.Background(), &out, "registry", waits, newPollPolicy(time.Millisecond, time.Millisecond*4), retry.NewBudget(0, 0), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if i := strings.Index(line, "checking again in "); i > -1 {
+			got = append(got, strings.TrimPrefix(line[i:], "checking again in "))
+		}
+	}
+
+	want := []string{"1ms", "2ms", "4ms", "4ms"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want poll intervals %v, got: %v\n%s", want, got, out.String())
+	}
+}
@@ -60,7 +60,7 @@ func MakeInstallSealedSecrets() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(SealedSecretsPostInstallMsg)
+		fmt.Println(pkg.InstallMessage(SealedSecretsPostInstallMsg))
 		return nil
 	}
 	return command
@@ -50,7 +50,7 @@ func MakeInstallTekton() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(TektonInstallMsg)
+		fmt.Println(pkg.InstallMessage(TektonInstallMsg))
 
 		return nil
 	}
@@ -105,7 +105,7 @@ func MakeInstallTraefik2() *cobra.Command {
 			return err
 		}
 
-		fmt.Println(traefikInstallMsg)
+		fmt.Println(pkg.InstallMessage(traefikInstallMsg))
 		return nil
 	}
 
@@ -125,4 +125,5 @@ kubectl get svc -n kube-system traefik`
 const traefikInstallMsg = `=======================================================================
 =                  traefik2 has been installed                        =
 =======================================================================
- ` + pkg.ThanksForUsing + Traefik2InfoMsg
+
+` + Traefik2InfoMsg + "\n\n" + pkg.ThanksForUsing
@@ -0,0 +1,64 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/alexellis/arkade/pkg/color"
+)
+
+// installWarnings collects the warnings raised during an install, so
+// that they are printed together at the end instead of being lost in
+// the rest of the output
+type installWarnings struct {
+	Warnings []string `json:"warnings"`
+
+	// Strict makes any warning fail the install, see Err
+	Strict bool `json:"-"`
+}
+
+// Add records a warning
+func (w *installWarnings) Add(format string, a ...interface{}) {
+	w.Warnings = append(w.Warnings, fmt.Sprintf(format, a...))
+}
+
+// Err gives an error when Strict is set and there are warnings, so that
+// a misconfiguration fails a CI pipeline rather than being printed
+func (w installWarnings) Err() error {
+	if !w.Strict || len(w.Warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d warning(s) were found and --strict is set", len(w.Warnings))
+}
+
+// Write prints a "Warnings:" section, or for an output of "json" an
+// object with a list of warnings. Nothing is printed as text when there
+// are no warnings.
+func (w installWarnings) Write(out io.Writer, output string) error {
+	if output == "json" {
+		warnings := w
+		if warnings.Warnings == nil {
+			warnings.Warnings = []string{}
+		}
+		data, err := json.MarshalIndent(warnings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	if len(w.Warnings) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out, color.Yellow("Warnings:"))
+	for _, warning := range w.Warnings {
+		fmt.Fprintf(out, "  - %s\n", warning)
+	}
+	return nil
+}
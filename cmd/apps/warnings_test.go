@@ -0,0 +1,58 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func Test_installWarnings_PrintedTogether(t *testing.T) {
+	warnings := &installWarnings{}
+	warnings.Add("unknown ingress class %q", "haproxy")
+	warnings.Add("quota %s is exhausted", "count/ingresses")
+
+	var out bytes.Buffer
+	if err := warnings.Write(&out, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `Warnings:
+  - unknown ingress class "haproxy"
+  - quota count/ingresses is exhausted
+`
+	if out.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func Test_installWarnings_JSON(t *testing.T) {
+	warnings := &installWarnings{}
+	warnings.Add("first")
+	warnings.Add("second")
+
+	var out bytes.Buffer
+	if err := warnings.Write(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := installWarnings{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("want valid JSON, got: %s", err)
+	}
+	if len(got.Warnings) != 2 || got.Warnings[0] != "first" || got.Warnings[1] != "second" {
+		t.Errorf("want both warnings, got: %v", got.Warnings)
+	}
+}
+
+func Test_installWarnings_NoneWritesNothing(t *testing.T) {
+	var out bytes.Buffer
+	if err := (&installWarnings{}).Write(&out, ""); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("want no output, got: %q", out.String())
+	}
+}
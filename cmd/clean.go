@@ -0,0 +1,89 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alexellis/arkade/pkg/config"
+	"github.com/alexellis/arkade/pkg/k8s"
+	"github.com/spf13/cobra"
+)
+
+// MakeClean creates the clean command for removing resources which
+// arkade created and which are no longer used
+func MakeClean() *cobra.Command {
+	command := &cobra.Command{
+		Use:          "clean",
+		Short:        "Remove unused resources created by arkade",
+		Long:         `Remove resources which arkade created and which are no longer in use.`,
+		Example:      `  arkade clean issuers -n registry`,
+		SilenceUsage: true,
+	}
+
+	command.RunE = func(cmd *cobra.Command, args []string) error {
+		return cmd.Usage()
+	}
+
+	command.AddCommand(MakeCleanIssuers())
+	return command
+}
+
+// MakeCleanIssuers deletes the Issuers labelled as managed by arkade
+// which no Ingress or Certificate refers to any more
+func MakeCleanIssuers() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "issuers",
+		Short: "Delete cert-manager Issuers created by arkade which are no longer used",
+		Long: `Delete cert-manager Issuers in a namespace which carry the label
+app.kubernetes.io/managed-by=arkade and which no Ingress or Certificate
+refers to, such as those left behind after renaming a domain. Issuers
+labelled arkade.alexellis.io/protected=true are kept unless --force is
+given.`,
+		Example: `  arkade clean issuers -n registry --dry-run
+  arkade clean issuers -n registry`,
+		SilenceUsage: true,
+	}
+
+	command.Flags().StringP("namespace", "n", "default", "Namespace to clean")
+	command.Flags().Bool("dry-run", false, "Print the Issuers which would be deleted, without deleting them")
+	command.Flags().Bool("force", false, "Also delete Issuers labelled "+k8s.ProtectedLabel+"=true")
+	command.Flags().String("kubeconfig", "", "Local path for your kubeconfig file")
+
+	command.RunE = func(cmd *cobra.Command, args []string) error {
+		kubeConfigPath, _ := cmd.Flags().GetString("kubeconfig")
+		if err := config.SetKubeconfig(kubeConfigPath); err != nil {
+			return err
+		}
+
+		namespace, _ := cmd.Flags().GetString("namespace")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+
+		orphaned, protected, err := k8s.OrphanedIssuers(namespace, force)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range protected {
+			fmt.Printf("Keeping issuer %s/%s as it is labelled %s=true, add --force to delete it\n", namespace, name, k8s.ProtectedLabel)
+		}
+
+		if len(orphaned) == 0 {
+			fmt.Printf("No unused Issuers created by arkade in namespace %s\n", namespace)
+			return nil
+		}
+
+		if dryRun {
+			for _, name := range orphaned {
+				fmt.Printf("Would delete issuer %s/%s\n", namespace, name)
+			}
+			return nil
+		}
+
+		return k8s.DeleteIssuers(namespace, orphaned)
+	}
+
+	return command
+}
@@ -4,23 +4,31 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"os/signal"
-	"sort"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 
+	"github.com/alexellis/arkade/pkg/config"
 	"github.com/alexellis/arkade/pkg/env"
 	"github.com/alexellis/arkade/pkg/get"
+	"github.com/alexellis/arkade/pkg/table"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 // MakeGet creates the Get command to download software
 func MakeGet() *cobra.Command {
-	tools := get.MakeTools()
-	sort.Sort(tools)
+	userTools, warnings := get.LoadUserTools(path.Join(config.GetUserDir(), "tools.d"))
+	tools, mergeWarnings := get.MergeTools(get.MakeTools(), userTools)
+	for _, warning := range append(warnings, mergeWarnings...) {
+		fmt.Printf("[Warning] %s\n", warning)
+	}
 	var validToolOptions []string = make([]string, len(tools))
 	for _, t := range tools {
 		validToolOptions = append(validToolOptions, t.Name)
@@ -31,55 +39,155 @@ func MakeGet() *cobra.Command {
 		Short: `The get command downloads a tool`,
 		Long: `The get command downloads a CLI or application from the specific tool's
 releases or downloads page. The tool is usually downloaded in binary format
-and provides a fast and easy alternative to a package manager.`,
+and provides a fast and easy alternative to a package manager.
+
+Extra tools can be defined in YAML files in $HOME/.arkade/tools.d/ with the
+fields: name, urlTemplate, platforms and optionally description, owner,
+repo, version, binaryTemplate and noExtension.`,
 		Example: `  arkade get helm
   arkade get linkerd2 --stash=false
   arkade get terraform --version=0.12.0
-  arkade get kubectl --progress=false
+  arkade get kubectl --no-progress
+  arkade get faas-cli --channel beta
+  arkade get helm --uninstall
+  arkade get helm --compare-versions
+  arkade get kubectx --no-verify
+
+  # Download from an internal mirror of the GitHub releases:
+  arkade get kubectl --mirror https://artifacts.example.com/github --verbose
+
+  # Download from a mirror with a self-signed certificate, through the
+  # proxy given by HTTPS_PROXY and NO_PROXY:
+  HTTPS_PROXY=http://proxy.example.com:3128 \
+    arkade get kubectl --mirror https://mirror.internal/github --insecure-skip-tls-verify
+
+  # Download several tools at once:
+  arkade get kubectl helm faas-cli --parallel 2
 
   # Get a complete list of CLIs to download:
-  arkade get --help`,
+  arkade get --help
+
+  # List the tools with their repos and platforms:
+  arkade get --output wide`,
 		SilenceUsage: true,
 		Aliases:      []string{"g", "d", "download"},
 		ValidArgs:    validToolOptions,
 	}
 
-	command.Flags().Bool("progress", true, "Display a progress bar")
-	command.Flags().StringP("output", "o", "", "Output format of the list of tools (table/markdown)")
+	command.Flags().Bool("progress", true, "Display a progress bar when stdout is a terminal and not running in CI")
+	command.Flags().Bool("no-progress", false, "Never display a progress bar")
+	command.Flags().StringP("output", "o", "", "Output format of the list of tools (table/wide/markdown/json), of --list-platforms (table/json) or of --compare-versions (json)")
+	command.Flags().Bool("list-platforms", false, "List the platforms the tool can be downloaded for, instead of downloading it")
 	command.Flags().Bool("stash", true, "When set to true, stash binary in HOME/.arkade/bin/, otherwise store in /tmp/")
 	command.Flags().StringP("version", "v", "", "Download a specific version")
+	command.Flags().String("install-mode", string(get.CopyMode), "How a stashed binary is placed into HOME/.arkade/bin/: copy, or symlink to the download kept in HOME/.arkade/cache/<tool>/<version>/")
+	command.Flags().Bool("compare-versions", false, "Show the installed and latest versions of a tool and whether an update is available")
+	command.Flags().Bool("uninstall", false, "Remove a tool previously stashed in HOME/.arkade/bin/")
+	command.Flags().BoolP("yes", "y", false, "Do not ask for confirmation before uninstalling")
+	command.Flags().String("mirror", "", "Download from this mirror of the tools' release URLs, keeping their paths, overrides "+get.MirrorEnv)
+	command.Flags().Bool("insecure-skip-tls-verify", false, "Skip verifying the TLS certificate of the server downloads come from, such as an internal mirror with a self-signed certificate")
+	command.Flags().Bool("verbose", false, "Print each URL downloaded from, such as to check the rewrites made for --mirror")
+	command.Flags().Int("parallel", get.DefaultParallel, "How many tools to download at once, when more than one is given")
+	command.Flags().Bool("no-verify", false, "Skip verifying the SHA256 of the download against the checksums file published with it")
+	command.Flags().String("channel", string(get.StableChannel), "Release channel used when no version is given, stable or beta to include pre-releases")
 
 	command.RunE = func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			output, _ := command.Flags().GetString("output")
-			fmt.Println(output)
-			if len(output) > 0 {
-				if get.TableFormat(output) == get.MarkdownStyle {
-					get.CreateToolsTable(tools, get.MarkdownStyle)
-				} else {
-					get.CreateToolsTable(tools, get.TableStyle)
-				}
-			} else {
-				get.CreateToolsTable(tools, get.TableStyle)
+			format := get.TableFormat(output)
+			switch format {
+			case "":
+				format = get.TableStyle
+			case get.TableStyle, get.WideStyle, get.MarkdownStyle, get.JSONStyle:
+			default:
+				return fmt.Errorf("--output for the list of tools must be one of: table, wide, markdown, json, but got: %q", output)
 			}
-			return nil
+			return get.WriteToolsList(os.Stdout, tools, format, table.TerminalWidth())
 		}
 
-		var tool *get.Tool
-
-		if len(args) == 1 {
+		var selected []get.Tool
+		for _, name := range args {
+			found := false
 			for _, t := range tools {
-				if t.Name == args[0] {
-					tool = &t
+				if t.Name == name {
+					selected = append(selected, t)
+					found = true
 					break
 				}
 			}
+			if !found {
+				return fmt.Errorf("cannot get tool: %s", name)
+			}
+		}
+		tool := &selected[0]
+
+		if len(selected) > 1 {
+			for _, flag := range []string{"list-platforms", "compare-versions", "uninstall", "version"} {
+				if command.Flags().Changed(flag) {
+					return fmt.Errorf("--%s is only supported when getting a single tool", flag)
+				}
+			}
 		}
-		if tool == nil {
-			return fmt.Errorf("cannot get tool: %s", args[0])
+
+		channelValue, _ := command.Flags().GetString("channel")
+		channel, err := get.ParseChannel(channelValue)
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("Downloading %s\n", tool.Name)
+		if listPlatforms, _ := command.Flags().GetBool("list-platforms"); listPlatforms {
+			downloads, err := get.ListPlatforms(*tool)
+			if err != nil {
+				return err
+			}
+			output, _ := command.Flags().GetString("output")
+			return get.WritePlatforms(os.Stdout, tool.Name, downloads, get.TableFormat(output))
+		}
+
+		if compare, _ := command.Flags().GetBool("compare-versions"); compare {
+			comparison, err := get.CompareVersions(get.InstalledManifestPath(), *tool, func(t get.Tool) (string, error) {
+				return get.LatestRelease(t, channel)
+			})
+			if err != nil {
+				return err
+			}
+			output, _ := command.Flags().GetString("output")
+			return get.WriteVersionComparison(os.Stdout, comparison, get.TableFormat(output))
+		}
+
+		if uninstall, _ := command.Flags().GetBool("uninstall"); uninstall {
+			binaryPath, err := get.FindInstalled(get.InstalledManifestPath(), path.Join(config.GetUserDir(), "bin"), tool.Name)
+			if err != nil {
+				return err
+			}
+
+			if yes, _ := command.Flags().GetBool("yes"); !yes {
+				fmt.Printf("Delete %s? [y/N] ", binaryPath)
+				answer, _ := bufio.NewReader(command.InOrStdin()).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					return fmt.Errorf("uninstall of %s cancelled", tool.Name)
+				}
+			}
+
+			removed, err := get.Uninstall(get.InstalledManifestPath(), path.Join(config.GetUserDir(), "bin"), tool.Name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s\n", removed)
+			return nil
+		}
+
+		if command.Flags().Changed("mirror") {
+			mirror, _ := command.Flags().GetString("mirror")
+			if err := get.SetMirror(mirror); err != nil {
+				return fmt.Errorf("--mirror: %w", err)
+			}
+		}
+		verbose, _ := command.Flags().GetBool("verbose")
+		get.SetVerbose(verbose)
+		insecure, _ := command.Flags().GetBool("insecure-skip-tls-verify")
+		get.SetInsecureSkipTLSVerify(insecure)
 
 		arch, operatingSystem := env.GetClientArch()
 		version := ""
@@ -88,6 +196,12 @@ and provides a fast and easy alternative to a package manager.`,
 			version, _ = command.Flags().GetString("version")
 		}
 
+		installModeValue, _ := command.Flags().GetString("install-mode")
+		installMode, err := get.ParseInstallMode(installModeValue)
+		if err != nil {
+			return err
+		}
+
 		stash, _ := command.Flags().GetBool("stash")
 		progress, _ := command.Flags().GetBool("progress")
 		if p, ok := os.LookupEnv("ARKADE_PROGRESS"); ok {
@@ -99,6 +213,21 @@ and provides a fast and easy alternative to a package manager.`,
 			progress = b
 		}
 
+		if noProgress, _ := command.Flags().GetBool("no-progress"); noProgress {
+			progress = false
+		}
+		// Progress bars from concurrent downloads would overwrite each
+		// other, so only a line per tool is printed
+		if len(selected) > 1 {
+			progress = false
+		}
+		progress = get.ShowProgress(progress)
+
+		parallel, _ := command.Flags().GetInt("parallel")
+		if parallel < 1 {
+			return fmt.Errorf("--parallel must be 1 or more, got: %d", parallel)
+		}
+
 		dlMode := get.DownloadTempDir
 		if stash {
 			dlMode = get.DownloadArkadeDir
@@ -114,10 +243,71 @@ and provides a fast and easy alternative to a package manager.`,
 			}
 		}()
 
-		outFilePath, finalName, err := get.Download(tool, arch, operatingSystem, version, dlMode, progress)
+		noVerify, _ := command.Flags().GetBool("no-verify")
+
+		// Status lines are written by each download, which may run
+		// concurrently, through the same writer
+		out := get.NewSyncWriter(os.Stdout)
+		get.SetOutput(out)
+
+		// The manifest of installed tools is read and rewritten by each
+		// download, which may run concurrently
+		var recordMu sync.Mutex
+		download := func(t get.Tool) (string, string, error) {
+			version := version
+			if channel == get.BetaChannel && len(version) == 0 && len(t.Version) == 0 && len(t.Repo) > 0 {
+				var err error
+				version, err = get.FindRelease(t.Owner, t.Repo, channel)
+				if err != nil {
+					return "", "", err
+				}
+				fmt.Fprintf(out, "Using %s %s from the %s channel\n", t.Name, version, channel)
+			}
+
+			outFilePath, finalName, err := get.DownloadWithInstallMode(&t, arch, operatingSystem, version, dlMode, progress, installMode, !noVerify)
+			if errors.Is(err, get.ErrNoChecksums) {
+				return "", "", fmt.Errorf("%s, use --no-verify to download %s without verifying it", err, t.Name)
+			}
+			if err != nil {
+				return "", "", errors.Wrap(err, "check with the vendor whether this tool is available for your system")
+			}
 
+			if dlMode == get.DownloadArkadeDir {
+				installed := get.InstalledTool{Name: t.Name, Version: version, Path: outFilePath}
+				if len(installed.Version) == 0 {
+					// Record the version just downloaded, so that it can be
+					// compared with --compare-versions later
+					installed.Version, _ = get.LatestRelease(t, channel)
+				}
+				recordMu.Lock()
+				err := get.RecordInstall(get.InstalledManifestPath(), installed)
+				recordMu.Unlock()
+				if err != nil {
+					fmt.Fprintf(out, "[Warning] unable to record %s as installed: %s\n", t.Name, err)
+				}
+			}
+			return outFilePath, finalName, nil
+		}
+
+		if len(selected) > 1 {
+			fmt.Printf("Downloading %d tools, %d at a time\n", len(selected), parallel)
+			results := get.DownloadAll(out, selected, parallel, download)
+			fmt.Println()
+			get.WriteDownloadSummary(os.Stdout, results)
+
+			if failed := get.FailedDownloads(results); failed > 0 {
+				return fmt.Errorf("%d of %d tools failed to download", failed, len(results))
+			}
+			if dlMode == get.DownloadArkadeDir {
+				fmt.Printf("\n# Add the tools to your PATH variable\nexport PATH=$PATH:$HOME/.arkade/bin/\n")
+			}
+			return nil
+		}
+
+		fmt.Printf("Downloading %s\n", tool.Name)
+		outFilePath, finalName, err := download(*tool)
 		if err != nil {
-			return errors.Wrap(err, "check with the vendor whether this tool is available for your system")
+			return err
 		}
 
 		fmt.Printf("Tool written to: %s\n\n", outFilePath)
@@ -4,9 +4,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/alexellis/arkade/cmd/apps"
+	"github.com/alexellis/arkade/pkg/table"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +33,7 @@ command.
 You can also find the post-install message for each app with the "info"
 command.`,
 		Example: `  arkade install
+  arkade install --output wide
   arkade install openfaas  --gateways=2
   arkade install inlets-operator --token-file $HOME/do-token`,
 		SilenceUsage: false,
@@ -34,10 +41,16 @@ command.`,
 
 	command.PersistentFlags().String("kubeconfig", "", "Local path for your kubeconfig file")
 	command.PersistentFlags().Bool("wait", false, "If we should wait for the resource to be ready before returning (helm3 only, default false)")
+	command.Flags().StringP("output", "o", "", "List the apps instead of installing one, as a table, wide with aliases or json")
 
 	command.RunE = func(command *cobra.Command, args []string) error {
 
 		if len(args) == 0 {
+			if command.Flags().Changed("output") {
+				output, _ := command.Flags().GetString("output")
+				return writeAppList(os.Stdout, GetApps(), output, table.TerminalWidth())
+			}
+
 			fmt.Printf(
 				`To see a complete list of apps run:
 
@@ -115,6 +128,62 @@ func GetApps() map[string]ArkadeApp {
 	return arkadeApps
 }
 
+// appListing is an app as listed by arkade install --output
+type appListing struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// writeAppList writes the apps sorted by name, wide adds the aliases
+// of each app
+func writeAppList(w io.Writer, appList map[string]ArkadeApp, output string, width int) error {
+	if output != "table" && output != "wide" && output != "json" {
+		return fmt.Errorf("--output must be one of: table, wide, json, but got: %q", output)
+	}
+
+	listings := []appListing{}
+	for name, app := range appList {
+		installer := app.Installer()
+		listings = append(listings, appListing{
+			Name:        name,
+			Description: installer.Short,
+			Aliases:     installer.Aliases,
+		})
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		return listings[i].Name < listings[j].Name
+	})
+
+	if output == "json" {
+		out, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	list := table.NewWriter(width)
+	if output == "wide" {
+		list.SetHeader("App", "Aliases", "Description")
+	} else {
+		list.SetHeader("App", "Description")
+	}
+	for _, listing := range listings {
+		if output == "wide" {
+			aliases := strings.Join(listing.Aliases, ",")
+			if len(aliases) == 0 {
+				aliases = "-"
+			}
+			list.Append(listing.Name, aliases, listing.Description)
+		} else {
+			list.Append(listing.Name, listing.Description)
+		}
+	}
+	return list.Render(w)
+}
+
 func NewArkadeApp(cmd func() *cobra.Command, msg string) ArkadeApp {
 	return ArkadeApp{
 		Installer:   cmd,
@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/alexellis/arkade/pkg/color"
 	"github.com/morikuni/aec"
 	"github.com/spf13/cobra"
 )
@@ -15,8 +16,19 @@ var (
 	GitCommit string
 )
 
+// UserAgent identifies arkade in HTTP requests, i.e. arkade/0.7.0
+func UserAgent() string {
+	if len(Version) == 0 {
+		return "arkade/dev"
+	}
+	return "arkade/" + Version
+}
+
 func PrintArkadeASCIIArt() {
-	arkadeLogo := aec.BlueF.Apply(arkadeFigletStr)
+	arkadeLogo := arkadeFigletStr
+	if color.Enabled() {
+		arkadeLogo = aec.BlueF.Apply(arkadeFigletStr)
+	}
 	fmt.Print(arkadeLogo)
 }
 
@@ -6,11 +6,14 @@ require (
 	github.com/Masterminds/semver v1.5.0
 	github.com/alexellis/go-execute v0.5.0
 	github.com/cheggaaa/pb/v3 v3.0.8
+	github.com/mattn/go-isatty v0.0.12
 	github.com/morikuni/aec v1.0.0
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/pkg/errors v0.9.1
 	github.com/sethvargo/go-password v0.2.0
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
 	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
 	golang.org/x/mod v0.4.2
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -4,35 +4,98 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/alexellis/arkade/cmd"
 	"github.com/alexellis/arkade/cmd/venafi"
+	"github.com/alexellis/arkade/pkg"
+	"github.com/alexellis/arkade/pkg/color"
+	"github.com/alexellis/arkade/pkg/get"
+	"github.com/alexellis/arkade/pkg/k8s"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	printarkadeASCIIArt := cmd.PrintArkadeASCIIArt
 
+	// auditFile is opened by --audit-log and closed once the command
+	// has run, whether or not it failed
+	var auditFile *os.File
+
 	var rootCmd = &cobra.Command{
 		Use: "arkade",
 		Run: func(cmd *cobra.Command, args []string) {
 			printarkadeASCIIArt()
 			cmd.Help()
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			value, _ := cmd.Flags().GetString("color")
+			mode, err := color.ParseMode(value)
+			if err != nil {
+				return err
+			}
+			color.SetMode(mode)
+
+			userAgent, _ := cmd.Flags().GetString("user-agent")
+			get.SetUserAgent(userAgent)
+
+			kubeContext, _ := cmd.Flags().GetString("kube-context")
+			k8s.SetKubeContext(kubeContext)
+
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			pkg.SetQuiet(quiet || os.Getenv(pkg.NoBannerEnv) == "1")
+
+			if err := get.SetMirror(os.Getenv(get.MirrorEnv)); err != nil {
+				return fmt.Errorf("%s: %w", get.MirrorEnv, err)
+			}
+
+			if auditLog, _ := cmd.Flags().GetString("audit-log"); len(auditLog) > 0 {
+				file, err := os.OpenFile(auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+				if err != nil {
+					return fmt.Errorf("unable to open --audit-log: %w", err)
+				}
+				auditFile = file
+				k8s.SetRunner(k8s.NewAuditRunner(k8s.ExecRunner{}, file))
+			}
+			return nil
+		},
 	}
 
+	rootCmd.PersistentFlags().String("user-agent", cmd.UserAgent(), "User-Agent sent with HTTP requests such as downloads")
+	rootCmd.PersistentFlags().String("audit-log", "", "Append each kubectl and helm command run, with its exit code and duration, to this file as JSON lines, secrets in flags are redacted")
+	rootCmd.PersistentFlags().String("kube-context", "", "Name of the kubeconfig context for kubectl and helm to use, instead of the current context")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Omit the banner and === borders printed after installing apps, also set with "+pkg.NoBannerEnv+"=1")
+	rootCmd.PersistentFlags().String("color", string(color.Auto), "Colored output: auto, always or never, auto is disabled when stdout is not a terminal or NO_COLOR is set")
+
 	rootCmd.AddCommand(cmd.MakeInstall())
 	rootCmd.AddCommand(cmd.MakeVersion())
 	rootCmd.AddCommand(cmd.MakeInfo())
 	rootCmd.AddCommand(cmd.MakeUpdate())
 	rootCmd.AddCommand(cmd.MakeGet())
 	rootCmd.AddCommand(cmd.MakeUninstall())
+	rootCmd.AddCommand(cmd.MakeClean())
 	rootCmd.AddCommand(cmd.MakeShellCompletion())
 
 	rootCmd.AddCommand(venafi.MakeVenafi())
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if auditFile != nil {
+		if closeErr := closeAuditLog(auditFile); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to write --audit-log: %s\n", closeErr)
+			os.Exit(1)
+		}
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
+
+// closeAuditLog flushes the audit log to disk before closing it
+func closeAuditLog(file *os.File) error {
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,84 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package color decides whether arkade writes ANSI colors, as set by
+// the global --color flag.
+package color
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Mode is the value of the --color flag
+type Mode string
+
+const (
+	// Auto colors output when stdout is a terminal and NO_COLOR is unset
+	Auto Mode = "auto"
+
+	// Always colors output
+	Always Mode = "always"
+
+	// Never colors output
+	Never Mode = "never"
+)
+
+// ParseMode validates the value of the --color flag
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case Auto, Always, Never:
+		return Mode(value), nil
+	}
+	return "", fmt.Errorf("--color must be one of: %s, %s, %s, but got: %q", Auto, Always, Never, value)
+}
+
+var enabled = false
+
+// SetMode enables or disables colored output for the rest of the
+// command, the auto mode looks at NO_COLOR and whether stdout is a
+// terminal
+func SetMode(mode Mode) {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	terminal := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	enabled = enabledFor(mode, terminal, noColor)
+}
+
+func enabledFor(mode Mode, terminal, noColor bool) bool {
+	switch mode {
+	case Always:
+		return true
+	case Never:
+		return false
+	}
+	return terminal && !noColor
+}
+
+// Enabled reports whether output should be colored
+func Enabled() bool {
+	return enabled
+}
+
+const (
+	bold   = "1"
+	yellow = "33"
+)
+
+func wrap(code, s string) string {
+	if !enabled {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// Bold makes s bold when colors are enabled
+func Bold(s string) string {
+	return wrap(bold, s)
+}
+
+// Yellow colors s yellow when colors are enabled, as used for warnings
+func Yellow(s string) string {
+	return wrap(yellow, s)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package color
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_enabledFor(t *testing.T) {
+	cases := []struct {
+		name     string
+		mode     Mode
+		terminal bool
+		noColor  bool
+		want     bool
+	}{
+		{name: "auto on a terminal", mode: Auto, terminal: true, want: true},
+		{name: "auto when piped", mode: Auto, terminal: false, want: false},
+		{name: "auto with NO_COLOR", mode: Auto, terminal: true, noColor: true, want: false},
+		{name: "always when piped", mode: Always, terminal: false, noColor: true, want: true},
+		{name: "never on a terminal", mode: Never, terminal: true, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := enabledFor(tc.mode, tc.terminal, tc.noColor); got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_SetMode_ControlsEscapeCodes(t *testing.T) {
+	defer SetMode(Never)
+
+	SetMode(Always)
+	if got := Yellow("Warnings:"); !strings.Contains(got, "\x1b[33m") {
+		t.Errorf("want color codes with --color always, got: %q", got)
+	}
+
+	SetMode(Never)
+	if got := Yellow("Warnings:"); got != "Warnings:" {
+		t.Errorf("want no color codes with --color never, got: %q", got)
+	}
+}
+
+func Test_ParseMode(t *testing.T) {
+	if _, err := ParseMode("sometimes"); err == nil {
+		t.Errorf("want an invalid mode to be rejected")
+	}
+}
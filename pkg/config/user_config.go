@@ -0,0 +1,61 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"sigs.k8s.io/yaml"
+)
+
+// UserConfig is read from $HOME/.arkade/config.yaml
+type UserConfig struct {
+	// ProtectedContexts are patterns for kubectl context names, as
+	// matched by path.Match, which need confirming before applying to
+	ProtectedContexts []string `json:"protectedContexts,omitempty"`
+}
+
+// UserConfigPath is the location of the user's config file
+func UserConfigPath() string {
+	return path.Join(GetUserDir(), "config.yaml")
+}
+
+// LoadUserConfig reads the config file, a missing file gives an empty
+// config
+func LoadUserConfig(file string) (UserConfig, error) {
+	config := UserConfig{}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return config, fmt.Errorf("unable to parse %s: %w", file, err)
+	}
+
+	for _, pattern := range config.ProtectedContexts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return config, fmt.Errorf("invalid protectedContexts pattern %q in %s: %w", pattern, file, err)
+		}
+	}
+	return config, nil
+}
+
+// ProtectedContext gives the first pattern matching the context name, or
+// an empty string when the context is not protected
+func (c UserConfig) ProtectedContext(name string) string {
+	for _, pattern := range c.ProtectedContexts {
+		if matched, _ := path.Match(pattern, name); matched {
+			return pattern
+		}
+	}
+	return ""
+}
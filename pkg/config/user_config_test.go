@@ -0,0 +1,39 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LoadUserConfig_ProtectedContexts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("protectedContexts:\n- prod-*\n- live\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadUserConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := config.ProtectedContext("prod-eu-west-1"); got != "prod-*" {
+		t.Errorf("want prod-eu-west-1 to match prod-*, got: %q", got)
+	}
+	if got := config.ProtectedContext("staging"); got != "" {
+		t.Errorf("want staging to be unprotected, got: %q", got)
+	}
+}
+
+func Test_LoadUserConfig_MissingFile(t *testing.T) {
+	config, err := LoadUserConfig(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.ProtectedContexts) != 0 {
+		t.Errorf("want no protected contexts, got: %v", config.ProtectedContexts)
+	}
+}
@@ -0,0 +1,96 @@
+package get
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// binaryFormat is the executable format of a downloaded file
+type binaryFormat string
+
+const (
+	formatUnknown binaryFormat = ""
+	formatELF     binaryFormat = "ELF"
+	formatMachO   binaryFormat = "Mach-O"
+	formatPE      binaryFormat = "PE"
+)
+
+// errBinaryMismatch is returned when a downloaded binary was built for
+// a different OS than the one requested, usually due to a mistake in
+// the tool's URL template
+var errBinaryMismatch = errors.New("downloaded binary does not match the target OS")
+
+var binaryMagic = []struct {
+	magic  []byte
+	format binaryFormat
+}{
+	{magic: []byte{0x7f, 'E', 'L', 'F'}, format: formatELF},
+	{magic: []byte{0xfe, 0xed, 0xfa, 0xce}, format: formatMachO},
+	{magic: []byte{0xfe, 0xed, 0xfa, 0xcf}, format: formatMachO},
+	{magic: []byte{0xce, 0xfa, 0xed, 0xfe}, format: formatMachO},
+	{magic: []byte{0xcf, 0xfa, 0xed, 0xfe}, format: formatMachO},
+	{magic: []byte{0xca, 0xfe, 0xba, 0xbe}, format: formatMachO},
+	{magic: []byte{'M', 'Z'}, format: formatPE},
+}
+
+// detectBinaryFormat reads the magic number from the start of r, files
+// which are not executables such as scripts give formatUnknown
+func detectBinaryFormat(r io.Reader) (binaryFormat, error) {
+	header := make([]byte, 4)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return formatUnknown, err
+	}
+	header = header[:n]
+
+	for _, m := range binaryMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format, nil
+		}
+	}
+	return formatUnknown, nil
+}
+
+// expectedBinaryFormat gives the executable format for an OS as reported
+// by env.GetClientArch
+func expectedBinaryFormat(operatingSystem string) binaryFormat {
+	name := strings.ToLower(operatingSystem)
+	switch {
+	case name == "linux":
+		return formatELF
+	case name == "darwin":
+		return formatMachO
+	case strings.HasPrefix(name, "ming"):
+		return formatPE
+	}
+	return formatUnknown
+}
+
+// verifyBinaryFormat returns errBinaryMismatch when the file at filePath
+// is an executable for another OS. Unrecognised files are not rejected.
+func verifyBinaryFormat(filePath, operatingSystem string) error {
+	want := expectedBinaryFormat(operatingSystem)
+	if want == formatUnknown {
+		return nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	got, err := detectBinaryFormat(f)
+	if err != nil {
+		return err
+	}
+
+	if got != formatUnknown && got != want {
+		return fmt.Errorf("%w: found a %s binary, but %s needs %s", errBinaryMismatch, got, operatingSystem, want)
+	}
+	return nil
+}
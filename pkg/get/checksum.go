@@ -0,0 +1,158 @@
+package get
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrNoChecksums is returned when no checksums file is published
+// alongside a download, so that it can't be verified
+var ErrNoChecksums = errors.New("no checksums file was found")
+
+// checksumURLs lists the places a checksums file is usually published
+// next to a download, such as the checksums.txt made by goreleaser
+func checksumURLs(tool *Tool, downloadURL string) []string {
+	dir, fileName := path.Split(downloadURL)
+	versionNumber := strings.TrimPrefix(path.Base(dir), "v")
+
+	name := tool.Repo
+	if len(name) == 0 {
+		name = tool.Name
+	}
+
+	return []string{
+		dir + "checksums.txt",
+		dir + fmt.Sprintf("%s_%s_checksums.txt", name, versionNumber),
+		dir + fmt.Sprintf("%s_checksums.txt", name),
+		dir + fileName + ".sha256",
+		dir + fileName + ".sha256sum",
+	}
+}
+
+// verifyChecksum compares the SHA256 of the downloaded file with the
+// digest published for it, the file is deleted when they differ
+func verifyChecksum(tool *Tool, downloadURL, filePath string) error {
+	_, fileName := path.Split(downloadURL)
+
+	var expected string
+	for _, checksumURL := range checksumURLs(tool, downloadURL) {
+		if verbose {
+			fmt.Fprintf(output, "Looking for checksums at: %s\n", checksumURL)
+		}
+		body, err := fetchChecksums(checksumURL)
+		if err != nil {
+			var statusErr *statusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return fmt.Errorf("unable to fetch checksums from %s: %w", checksumURL, err)
+		}
+
+		expected, err = parseChecksums(body, fileName)
+		if err != nil {
+			return fmt.Errorf("unable to verify %s with %s: %w", fileName, checksumURL, err)
+		}
+		break
+	}
+	if len(expected) == 0 {
+		return fmt.Errorf("%w for %s", ErrNoChecksums, fileName)
+	}
+
+	actual, err := fileSHA256(filePath)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		if err := os.Remove(filePath); err != nil {
+			return err
+		}
+		return fmt.Errorf("checksum mismatch for %s, expected SHA256: %s, but got: %s", fileName, expected, actual)
+	}
+
+	if verbose {
+		fmt.Fprintf(output, "Verified SHA256 of %s: %s\n", fileName, actual)
+	}
+	return nil
+}
+
+func fetchChecksums(checksumURL string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, checksumURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", UserAgent())
+
+	client := makeDownloadClient()
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", &statusError{StatusCode: res.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// parseChecksums finds the digest of fileName in the output of
+// sha256sum, a file with a single digest is taken to be for fileName
+func parseChecksums(body, fileName string) (string, error) {
+	var lines [][]string
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+
+	for _, fields := range lines {
+		if len(fields) == 1 && len(lines) == 1 {
+			return normalizeDigest(fields[0])
+		}
+		if len(fields) == 2 && path.Base(strings.TrimPrefix(fields[1], "*")) == fileName {
+			return normalizeDigest(fields[0])
+		}
+	}
+	return "", fmt.Errorf("no checksum is listed for %s", fileName)
+}
+
+func normalizeDigest(digest string) (string, error) {
+	digest = strings.ToLower(digest)
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("%q is not a SHA256 digest", digest)
+	}
+	return digest, nil
+}
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
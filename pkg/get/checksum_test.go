@@ -0,0 +1,125 @@
+package get
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDownloadedFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "faas-cli")
+	if err := ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func checksumServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_verifyChecksum_Matches(t *testing.T) {
+	server := checksumServer(t, map[string]string{
+		"/download/0.13.0/faas-cli_0.13.0_checksums.txt": sha256Hex("other") + "  faas-cli-darwin\n" +
+			sha256Hex("binary") + "  faas-cli\n",
+	})
+	filePath := writeDownloadedFile(t, "binary")
+
+	tool := &Tool{Name: "faas-cli", Repo: "faas-cli"}
+	if err := verifyChecksum(tool, server.URL+"/download/0.13.0/faas-cli", filePath); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_verifyChecksum_MismatchDeletesFile(t *testing.T) {
+	server := checksumServer(t, map[string]string{
+		"/download/0.13.0/faas-cli.sha256": sha256Hex("published"),
+	})
+	filePath := writeDownloadedFile(t, "tampered")
+
+	err := verifyChecksum(&Tool{Name: "faas-cli"}, server.URL+"/download/0.13.0/faas-cli", filePath)
+	if err == nil {
+		t.Fatal("want an error for a mismatched checksum")
+	}
+
+	want := "checksum mismatch for faas-cli, expected SHA256: " + sha256Hex("published") + ", but got: " + sha256Hex("tampered")
+	if err.Error() != want {
+		t.Errorf("want error %q, got: %q", want, err.Error())
+	}
+	if _, statErr := os.Stat(filePath); !os.IsNotExist(statErr) {
+		t.Errorf("want the downloaded file deleted, got: %v", statErr)
+	}
+}
+
+func Test_verifyChecksum_NoChecksumsPublished(t *testing.T) {
+	server := checksumServer(t, map[string]string{})
+	filePath := writeDownloadedFile(t, "binary")
+
+	err := verifyChecksum(&Tool{Name: "kubectx"}, server.URL+"/download/v0.9.4/kubectx", filePath)
+	if !errors.Is(err, ErrNoChecksums) {
+		t.Fatalf("want ErrNoChecksums, got: %v", err)
+	}
+	if _, statErr := os.Stat(filePath); statErr != nil {
+		t.Errorf("want the downloaded file kept, got: %s", statErr)
+	}
+}
+
+func Test_parseChecksums(t *testing.T) {
+	digest := sha256Hex("binary")
+
+	cases := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "sha256sum output", body: sha256Hex("other") + "  kind-darwin\n" + digest + "  kind-linux\n", want: digest},
+		{name: "binary mode", body: digest + " *kind-linux\n", want: digest},
+		{name: "single digest", body: strings.ToUpper(digest) + "\n", want: digest},
+		{name: "not listed", body: digest + "  kind-darwin\n" + digest + "  kind-windows\n", wantErr: "no checksum is listed for kind-linux"},
+		{name: "not a digest", body: "abc123  kind-linux\n", wantErr: `"abc123" is not a SHA256 digest`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseChecksums(tc.body, "kind-linux")
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
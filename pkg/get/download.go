@@ -1,6 +1,10 @@
 package get
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,11 +12,14 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/alexellis/arkade/pkg/archive"
 	"github.com/alexellis/arkade/pkg/config"
 	"github.com/alexellis/arkade/pkg/env"
+	"github.com/alexellis/arkade/pkg/retry"
 	"github.com/cheggaaa/pb/v3"
+	"github.com/mattn/go-isatty"
 )
 
 const (
@@ -20,18 +27,87 @@ const (
 	DownloadArkadeDir = iota
 )
 
+var output io.Writer = os.Stdout
+
+// SetOutput sets where the status of each download is written, which
+// must be safe for concurrent use when tools are downloaded in parallel
+func SetOutput(w io.Writer) {
+	output = w
+}
+
+// errNoPlatform is returned when a tool lists its platforms, but not
+// the one requested
+var errNoPlatform = errors.New("tool is not available for this platform")
+
+// InstallMode controls how a downloaded binary is placed into
+// $HOME/.arkade/bin/
+type InstallMode string
+
+const (
+	// CopyMode copies the binary, which works across filesystems
+	CopyMode InstallMode = "copy"
+
+	// SymlinkMode links to the downloaded binary, which is kept in
+	// $HOME/.arkade/cache/<tool>/<version>/
+	SymlinkMode InstallMode = "symlink"
+)
+
+// ParseInstallMode validates the value of the --install-mode flag
+func ParseInstallMode(value string) (InstallMode, error) {
+	switch InstallMode(value) {
+	case CopyMode, SymlinkMode:
+		return InstallMode(value), nil
+	}
+	return "", fmt.Errorf("install mode must be one of: %s, %s, but got: %q", CopyMode, SymlinkMode, value)
+}
+
+// Download fetches a tool without verifying its checksum, which is used
+// by the apps for the pinned versions of their CLIs
 func Download(tool *Tool, arch, operatingSystem, version string, downloadMode int, displayProgress bool) (string, string, error) {
+	return DownloadWithInstallMode(tool, arch, operatingSystem, version, downloadMode, displayProgress, CopyMode, false)
+}
+
+// DownloadWithInstallMode downloads a tool as per Download, placing the
+// binary into $HOME/.arkade/bin/ with the given InstallMode. When verify
+// is set, the download's SHA256 must match the checksums file published
+// alongside it.
+func DownloadWithInstallMode(tool *Tool, arch, operatingSystem, version string, downloadMode int, displayProgress bool, installMode InstallMode, verify bool) (string, string, error) {
+	if !tool.SupportsPlatform(operatingSystem, arch) {
+		return "", "", fmt.Errorf("%w: %s supports %s", errNoPlatform, PlatformName(operatingSystem, arch), strings.Join(tool.Platforms, ", "))
+	}
 
 	downloadURL, err := GetDownloadURL(tool, strings.ToLower(operatingSystem), strings.ToLower(arch), version)
 	if err != nil {
 		return "", "", err
 	}
 
-	fmt.Println(downloadURL)
-	outFilePath, err := downloadFile(downloadURL, displayProgress)
+	if mirrored := MirrorURL(downloadURL); mirrored != downloadURL {
+		if verbose {
+			fmt.Fprintf(output, "Using the mirror for: %s\n", downloadURL)
+		}
+		downloadURL = mirrored
+	}
+
+	fmt.Fprintln(output, downloadURL)
+
+	// Each download has its own directory, so that archives and the
+	// files untarred from them don't clash when run in parallel
+	workDir, err := os.MkdirTemp("", "arkade-get-")
 	if err != nil {
 		return "", "", err
 	}
+	defer os.RemoveAll(workDir)
+
+	outFilePath, err := downloadFile(downloadURL, workDir, displayProgress)
+	if err != nil {
+		return "", "", err
+	}
+
+	if verify {
+		if err := verifyChecksum(tool, downloadURL, outFilePath); err != nil {
+			return "", "", err
+		}
+	}
 
 	if tool.IsArchive() {
 		archiveFile, err := os.Open(outFilePath)
@@ -65,7 +141,7 @@ func Download(tool *Tool, arch, operatingSystem, version string, downloadMode in
 				return "", "", err
 			}
 
-			fmt.Println("name", fInfo.Name(), "size: ", fInfo.Size())
+			fmt.Fprintln(output, "name", fInfo.Name(), "size: ", fInfo.Size())
 
 			unzipErr := archive.Unzip(archiveFile, fInfo.Size(), outFilePathDir)
 			if unzipErr != nil {
@@ -74,11 +150,34 @@ func Download(tool *Tool, arch, operatingSystem, version string, downloadMode in
 		}
 	}
 
+	if err := verifyBinaryFormat(outFilePath, operatingSystem); err != nil {
+		return "", "", err
+	}
+
 	finalName := tool.Name
 	if strings.Contains(strings.ToLower(operatingSystem), "mingw") && tool.NoExtension == false {
 		finalName = finalName + ".exe"
 	}
 
+	switch {
+	case downloadMode == DownloadTempDir:
+		// The binary is left for the user to install, so it outlives
+		// workDir
+		kept := filepath.Join(os.TempDir(), finalName)
+		if err := os.Rename(outFilePath, kept); err != nil {
+			return "", "", err
+		}
+		outFilePath = kept
+	case installMode == SymlinkMode:
+		// $HOME/.arkade/bin/ links to the binary, so it is kept where a
+		// reboot or another download won't remove it
+		cached, err := cacheBinary(outFilePath, cachedBinaryPath(tool.Name, getToolVersion(tool, version), downloadURL, finalName))
+		if err != nil {
+			return "", "", err
+		}
+		outFilePath = cached
+	}
+
 	if downloadMode == DownloadArkadeDir {
 
 		_, err := config.InitUserDir()
@@ -88,8 +187,7 @@ func Download(tool *Tool, arch, operatingSystem, version string, downloadMode in
 
 		localPath := env.LocalBinary(finalName, "")
 
-		_, err = copyFile(path.Join(outFilePath), localPath)
-		if err != nil {
+		if err := installBinary(outFilePath, localPath, installMode); err != nil {
 			return "", "", err
 		}
 		outFilePath = localPath
@@ -98,8 +196,56 @@ func Download(tool *Tool, arch, operatingSystem, version string, downloadMode in
 	return outFilePath, finalName, nil
 }
 
-func downloadFile(downloadURL string, displayProgress bool) (string, error) {
-	res, err := http.DefaultClient.Get(downloadURL)
+// downloadRetryPolicy is used for tool downloads, only network errors
+// and server-side HTTP errors are retried
+var downloadRetryPolicy = retry.Policy{
+	Attempts:   3,
+	Initial:    time.Second,
+	Max:        time.Second * 5,
+	Multiplier: 2,
+	Jitter:     0.2,
+	Retryable:  isRetryableDownloadError,
+}
+
+// statusError is returned when a download gives an unexpected HTTP status
+type statusError struct {
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("incorrect status for downloading tool: %d", e.StatusCode)
+}
+
+func isRetryableDownloadError(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// downloadFile writes the file at downloadURL into dir
+func downloadFile(downloadURL, dir string, displayProgress bool) (string, error) {
+	var outFilePath string
+
+	err := retry.Do(context.Background(), downloadRetryPolicy, func() error {
+		var err error
+		outFilePath, err = tryDownloadFile(downloadURL, dir, displayProgress)
+		return err
+	})
+
+	return outFilePath, err
+}
+
+func tryDownloadFile(downloadURL, dir string, displayProgress bool) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", UserAgent())
+
+	client := makeDownloadClient()
+	res, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -109,12 +255,11 @@ func downloadFile(downloadURL string, displayProgress bool) (string, error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("incorrect status for downloading tool: %d", res.StatusCode)
+		return "", &statusError{StatusCode: res.StatusCode}
 	}
 
 	_, fileName := path.Split(downloadURL)
-	tmp := os.TempDir()
-	outFilePath := path.Join(tmp, fileName)
+	outFilePath := path.Join(dir, fileName)
 	wrappedReader := withProgressBar(res.Body, int(res.ContentLength), displayProgress)
 	out, err := os.Create(outFilePath)
 	if err != nil {
@@ -131,6 +276,57 @@ func downloadFile(downloadURL string, displayProgress bool) (string, error) {
 	return outFilePath, nil
 }
 
+// cachedBinaryPath gives $HOME/.arkade/cache/<tool>/<version>/<binary>,
+// a version which was not given is named after the download URL
+func cachedBinaryPath(name, version, downloadURL, finalName string) string {
+	if len(version) == 0 {
+		sum := sha256.Sum256([]byte(downloadURL))
+		version = hex.EncodeToString(sum[:])[:12]
+	}
+	return path.Join(os.Getenv("HOME"), ".arkade", "cache", name, version, finalName)
+}
+
+// cacheBinary copies src to dst, which may be on another filesystem. It
+// is copied alongside then renamed, as a link may point at dst while
+// the binary is running.
+func cacheBinary(src, dst string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return "", err
+	}
+
+	tmp := dst + ".download"
+	if _, err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dst, nil
+}
+
+// installBinary places src at dst, replacing any existing file
+func installBinary(src, dst string, mode InstallMode) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if mode == SymlinkMode {
+		absSrc, err := filepath.Abs(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(absSrc, 0700); err != nil {
+			return err
+		}
+		return os.Symlink(absSrc, dst)
+	}
+
+	_, err := copyFile(src, dst)
+	return err
+}
+
 func copyFile(src, dst string) (int64, error) {
 	sourceFileStat, err := os.Stat(src)
 	if err != nil {
@@ -162,6 +358,26 @@ func withProgressBar(r io.ReadCloser, length int, displayProgress bool) io.ReadC
 		return r
 	}
 
-	bar := pb.Simple.New(length).Start()
+	// Bytes, total and speed go to stderr, so that output piped from
+	// stdout is not corrupted
+	bar := pb.Default.New(length).
+		Set(pb.Bytes, true).
+		SetWriter(os.Stderr).
+		Start()
 	return bar.NewProxyReader(r)
 }
+
+// ShowProgress decides whether to display the progress bar, it is only
+// shown when requested, stdout is a terminal and not running under CI
+func ShowProgress(requested bool) bool {
+	_, ci := os.LookupEnv("CI")
+	return showProgress(requested, isTerminal(os.Stdout), ci)
+}
+
+func showProgress(requested, terminal, ci bool) bool {
+	return requested && terminal && !ci
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
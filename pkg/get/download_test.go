@@ -0,0 +1,376 @@
+package get
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_isRetryableDownloadError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: errors.New("connection reset by peer"), want: true},
+		{name: "server error", err: &statusError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "not found", err: &statusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "forbidden", err: &statusError{StatusCode: http.StatusForbidden}, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableDownloadError(tc.err); got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_LoadUserTools_MergesIntoBuiltIns(t *testing.T) {
+	dir := t.TempDir()
+
+	definition := `name: acme-cli
+description: CLI for the ACME platform.
+version: v1.2.3
+urlTemplate: https://downloads.example.com/acme-cli/{{.Version}}/acme-cli-{{.OS}}-{{.Arch}}
+platforms:
+- linux/x86_64
+- darwin/x86_64
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "acme.yaml"), []byte(definition), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := "name: broken\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.yaml"), []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	userTools, warnings := LoadUserTools(dir)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "missing required fields: urlTemplate, platforms") {
+		t.Errorf("want a warning for the invalid definition, got: %v", warnings)
+	}
+
+	tools, mergeWarnings := MergeTools(MakeTools(), userTools)
+	if len(mergeWarnings) != 0 {
+		t.Errorf("want no conflicts, got: %v", mergeWarnings)
+	}
+
+	tool := getTool("acme-cli", tools)
+	if tool == nil {
+		t.Fatalf("want acme-cli in the resolved tools")
+	}
+
+	got, err := tool.GetURL("linux", "x86_64", tool.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://downloads.example.com/acme-cli/v1.2.3/acme-cli-linux-x86_64"
+	if got != want {
+		t.Errorf("want URL: %s, got: %s", want, got)
+	}
+
+	if !tool.SupportsPlatform("Linux", "x86_64") || tool.SupportsPlatform("Linux", "aarch64") {
+		t.Errorf("want only the listed platforms to be supported")
+	}
+}
+
+func Test_MergeTools_WarnsOnConflict(t *testing.T) {
+	user := Tools{{Name: "helm", URLTemplate: "https://example.com/helm", Platforms: []string{"linux/x86_64"}}}
+
+	tools, warnings := MergeTools(MakeTools(), user)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "helm") {
+		t.Errorf("want a warning about helm, got: %v", warnings)
+	}
+
+	if tool := getTool("helm", tools); tool == nil || tool.URLTemplate != "https://example.com/helm" {
+		t.Errorf("want the user's helm definition to be used")
+	}
+}
+
+func Test_showProgress(t *testing.T) {
+	cases := []struct {
+		name      string
+		requested bool
+		terminal  bool
+		ci        bool
+		want      bool
+	}{
+		{name: "terminal", requested: true, terminal: true, want: true},
+		{name: "piped output", requested: true, terminal: false, want: false},
+		{name: "running in CI", requested: true, terminal: true, ci: true, want: false},
+		{name: "disabled", requested: false, terminal: true, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := showProgress(tc.requested, tc.terminal, tc.ci); got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_isTerminal_FalseForFile(t *testing.T) {
+	f, err := ioutil.TempFile(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if isTerminal(f) {
+		t.Errorf("want a regular file not to be a terminal")
+	}
+}
+
+func Test_verifyBinaryFormat(t *testing.T) {
+	headers := map[string][]byte{
+		"elf":    {0x7f, 'E', 'L', 'F', 0x02, 0x01},
+		"macho":  {0xcf, 0xfa, 0xed, 0xfe, 0x07, 0x00},
+		"pe":     {'M', 'Z', 0x90, 0x00},
+		"script": []byte("#!/bin/sh\necho hi\n"),
+	}
+
+	cases := []struct {
+		file     string
+		os       string
+		mismatch bool
+	}{
+		{file: "elf", os: "Linux"},
+		{file: "elf", os: "Darwin", mismatch: true},
+		{file: "elf", os: "MINGW64_NT-10.0", mismatch: true},
+		{file: "macho", os: "Darwin"},
+		{file: "macho", os: "Linux", mismatch: true},
+		{file: "pe", os: "MINGW64_NT-10.0"},
+		{file: "pe", os: "Linux", mismatch: true},
+		{file: "script", os: "Linux"},
+	}
+
+	dir := t.TempDir()
+	for name, header := range headers {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), header, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.file+" on "+tc.os, func(t *testing.T) {
+			err := verifyBinaryFormat(filepath.Join(dir, tc.file), tc.os)
+			if got := errors.Is(err, errBinaryMismatch); got != tc.mismatch {
+				t.Errorf("want mismatch: %t, got error: %v", tc.mismatch, err)
+			}
+		})
+	}
+}
+
+func Test_installBinary(t *testing.T) {
+	cases := []struct {
+		mode    InstallMode
+		symlink bool
+	}{
+		{mode: CopyMode, symlink: false},
+		{mode: SymlinkMode, symlink: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "download")
+			dst := filepath.Join(dir, "helm")
+			if err := ioutil.WriteFile(src, []byte("binary"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			// An existing install is replaced
+			if err := ioutil.WriteFile(dst, []byte("old"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := installBinary(src, dst, tc.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Lstat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode()&os.ModeSymlink != 0; got != tc.symlink {
+				t.Errorf("want symlink: %t, got: %t", tc.symlink, got)
+			}
+
+			data, err := ioutil.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "binary" {
+				t.Errorf("want the downloaded contents, got: %q", string(data))
+			}
+		})
+	}
+}
+
+func Test_ParseInstallMode(t *testing.T) {
+	if _, err := ParseInstallMode("hardlink"); err == nil {
+		t.Errorf("want hardlink to be rejected")
+	}
+}
+
+func Test_downloadFile_SendsUserAgent(t *testing.T) {
+	previous := UserAgent()
+	defer SetUserAgent(previous)
+	SetUserAgent("arkade/0.7.0")
+
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Write([]byte("binary"))
+	}))
+	defer server.Close()
+
+	if _, err := tryDownloadFile(server.URL+"/arkade-user-agent-test", t.TempDir(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "arkade/0.7.0" {
+		t.Errorf("want User-Agent: arkade/0.7.0, got: %q", got)
+	}
+}
+
+// tarGz gives a tar.gz holding a single executable file
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_DownloadWithInstallMode_ParallelArchivesDontClash(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	previous := output
+	SetOutput(NewSyncWriter(ioutil.Discard))
+	defer SetOutput(previous)
+
+	// Both tools publish an archive of the same name, holding a binary
+	// of the same name
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tool := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		w.Write(tarGz(t, "bin", "#!/bin/sh\necho "+tool+"\n"))
+	}))
+	defer server.Close()
+
+	tools := []Tool{
+		{Name: "first", Version: "1.0.0", URLTemplate: server.URL + "/first/release.tar.gz", BinaryTemplate: "bin"},
+		{Name: "second", Version: "1.0.0", URLTemplate: server.URL + "/second/release.tar.gz", BinaryTemplate: "bin"},
+	}
+
+	var wg sync.WaitGroup
+	paths := make([]string, len(tools))
+	errs := make([]error, len(tools))
+	for i := range tools {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], _, errs[i] = DownloadWithInstallMode(&tools[i], "x86_64", "Linux", "", DownloadTempDir, false, CopyMode, false)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, tool := range tools {
+		if errs[i] != nil {
+			t.Fatalf("%s: %s", tool.Name, errs[i])
+		}
+		if want := filepath.Join(tmp, tool.Name); paths[i] != want {
+			t.Errorf("%s: want the binary at %s, got: %s", tool.Name, want, paths[i])
+		}
+		content, err := ioutil.ReadFile(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "#!/bin/sh\necho " + tool.Name + "\n"; string(content) != want {
+			t.Errorf("%s: want %q, got: %q", tool.Name, want, content)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "first,second" {
+		t.Errorf("want only the binaries left in the temporary directory, got: %v", names)
+	}
+}
+
+func Test_DownloadWithInstallMode_SymlinkTargetIsCached(t *testing.T) {
+	home := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", tmp)
+
+	previous := output
+	SetOutput(ioutil.Discard)
+	defer SetOutput(previous)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("#!/bin/sh\necho " + path.Base(path.Dir(r.URL.Path)) + "\n"))
+	}))
+	defer server.Close()
+
+	tool := Tool{Name: "faas-cli", URLTemplate: server.URL + "/{{.Version}}/faas-cli"}
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		linkPath, _, err := DownloadWithInstallMode(&tool, "x86_64", "Linux", version, DownloadArkadeDir, false, SymlinkMode, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(home, ".arkade", "cache", "faas-cli", version, "faas-cli"); target != want {
+			t.Errorf("want the link to point into the cache at %s, got: %s", want, target)
+		}
+	}
+
+	// The link to 2.0.0 did not replace the binary of 1.0.0
+	content, err := ioutil.ReadFile(filepath.Join(home, ".arkade", "cache", "faas-cli", "1.0.0", "faas-cli"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "#!/bin/sh\necho 1.0.0\n" {
+		t.Errorf("want the 1.0.0 binary kept, got: %q", content)
+	}
+
+	if entries, _ := ioutil.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("want nothing left in the temporary directory, got %d entries", len(entries))
+	}
+}
@@ -49,6 +49,93 @@ type Tool struct {
 	// NoExtension is required for tooling such as kubectx
 	// which at time of writing is a bash script.
 	NoExtension bool
+
+	// Platforms lists the supported os/arch pairs such as
+	// linux/x86_64, when empty any platform is attempted.
+	Platforms []string
+}
+
+// SupportsPlatform returns true when the tool has no list of
+// platforms, or lists the given os and arch
+func (tool Tool) SupportsPlatform(os, arch string) bool {
+	if len(tool.Platforms) == 0 {
+		return true
+	}
+
+	want := PlatformName(os, arch)
+	for _, platform := range tool.Platforms {
+		if strings.ToLower(platform) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// PlatformDownload is where a tool is downloaded from on a platform
+type PlatformDownload struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+}
+
+// commonPlatforms are shown for tools which don't list their platforms
+var commonPlatforms = []string{
+	"linux/x86_64",
+	"linux/aarch64",
+	"linux/armv7l",
+	"darwin/x86_64",
+	"darwin/arm64",
+	"windows/x86_64",
+}
+
+// ListPlatforms renders the download URL of the tool for each of its
+// platforms, or for common platforms when it doesn't list any. When
+// the tool has no pinned version, "latest" is shown in its place.
+func ListPlatforms(tool Tool) ([]PlatformDownload, error) {
+	platforms := tool.Platforms
+	if len(platforms) == 0 {
+		platforms = commonPlatforms
+	}
+
+	version := tool.Version
+	if len(version) == 0 {
+		version = "latest"
+	}
+
+	var downloads []PlatformDownload
+	for _, platform := range platforms {
+		parts := strings.SplitN(strings.ToLower(platform), "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("platform %q must be in the form os/arch", platform)
+		}
+
+		os, arch := parts[0], parts[1]
+		if os == "windows" {
+			// Templates detect Windows through Git Bash's uname
+			os = "mingw64_nt-10.0"
+		}
+
+		url, err := tool.GetURL(os, arch, version)
+		if err != nil {
+			return nil, err
+		}
+
+		downloads = append(downloads, PlatformDownload{
+			Platform: PlatformName(os, arch),
+			URL:      url,
+		})
+	}
+
+	return downloads, nil
+}
+
+// PlatformName gives the os/arch name of a platform as reported by
+// uname, with Git Bash and MinGW reported as windows
+func PlatformName(os, arch string) string {
+	os = strings.ToLower(os)
+	if strings.HasPrefix(os, "ming") {
+		os = "windows"
+	}
+	return os + "/" + strings.ToLower(arch)
 }
 
 var templateFuncs = map[string]interface{}{
@@ -175,6 +262,7 @@ func findGitHubRelease(owner, repo string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	req.Header.Set("User-Agent", UserAgent())
 
 	res, err := client.Do(req)
 	if err != nil {
@@ -237,34 +325,60 @@ func makeHTTPClient(timeout *time.Duration, tlsInsecure bool) http.Client {
 func makeHTTPClientWithDisableKeepAlives(timeout *time.Duration, tlsInsecure bool, disableKeepAlives bool) http.Client {
 	client := http.Client{}
 
-	if timeout != nil || tlsInsecure {
-		tr := &http.Transport{
-			Proxy:             http.ProxyFromEnvironment,
-			DisableKeepAlives: disableKeepAlives,
-		}
-
-		if timeout != nil {
-			client.Timeout = *timeout
-			tr.DialContext = (&net.Dialer{
-				Timeout: *timeout,
-			}).DialContext
-
-			tr.IdleConnTimeout = 120 * time.Millisecond
-			tr.ExpectContinueTimeout = 1500 * time.Millisecond
-		}
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY are read from the environment
+	// for every client, so that downloads work behind a corporate proxy
+	tr := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: disableKeepAlives,
+	}
 
-		if tlsInsecure {
-			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
-		}
+	if timeout != nil {
+		client.Timeout = *timeout
+		tr.DialContext = (&net.Dialer{
+			Timeout: *timeout,
+		}).DialContext
 
-		tr.DisableKeepAlives = disableKeepAlives
+		tr.IdleConnTimeout = 120 * time.Millisecond
+		tr.ExpectContinueTimeout = 1500 * time.Millisecond
+	}
 
-		client.Transport = tr
+	if tlsInsecure {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
 	}
 
+	client.Transport = tr
+
 	return client
 }
 
+var insecureSkipTLSVerify bool
+
+// SetInsecureSkipTLSVerify skips verifying the TLS certificate of the
+// servers downloads and their checksums come from, such as a mirror
+// with a self-signed certificate
+func SetInsecureSkipTLSVerify(value bool) {
+	insecureSkipTLSVerify = value
+}
+
+// makeDownloadClient makes the client for downloads and checksums, it
+// has no timeout as large files can take a while to download
+func makeDownloadClient() http.Client {
+	return makeHTTPClient(nil, insecureSkipTLSVerify)
+}
+
+var userAgent = "arkade"
+
+// SetUserAgent sets the User-Agent sent with each HTTP request, such as
+// arkade/0.7.0
+func SetUserAgent(value string) {
+	userAgent = value
+}
+
+// UserAgent gives the User-Agent sent with each HTTP request
+func UserAgent() string {
+	return userAgent
+}
+
 func getToolVersion(tool *Tool, version string) string {
 	ver := tool.Version
 	if len(version) > 0 {
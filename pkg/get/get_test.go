@@ -2,6 +2,9 @@ package get
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
 	"reflect"
 	"regexp"
 	"sort"
@@ -1900,3 +1903,60 @@ func Test_DownloadPorterCli(t *testing.T) {
 	}
 
 }
+
+func Test_makeDownloadClient_UsesProxyFromEnvironment(t *testing.T) {
+	// http.ProxyFromEnvironment reads the environment once per process,
+	// so the test is run again in a process with the proxy set
+	if os.Getenv("ARKADE_TEST_PROXY") != "1" {
+		cmd := exec.Command(os.Args[0], "-test.run=^Test_makeDownloadClient_UsesProxyFromEnvironment$")
+		cmd.Env = append(os.Environ(),
+			"ARKADE_TEST_PROXY=1",
+			"HTTP_PROXY=http://proxy.example.com:3128",
+			"HTTPS_PROXY=http://proxy.example.com:3128",
+			"NO_PROXY=mirror.internal")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s\n%s", err, out)
+		}
+		return
+	}
+
+	client := makeDownloadClient()
+	tr, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("want a *http.Transport, got: %T", client.Transport)
+	}
+
+	cases := map[string]string{
+		"https://github.com/openfaas/faas-cli/releases/download/0.13.0/faas-cli": "http://proxy.example.com:3128",
+		"http://get.helm.sh/helm-v3.5.2-linux-amd64.tar.gz":                      "http://proxy.example.com:3128",
+		"https://mirror.internal/github/openfaas/faas-cli":                       "",
+	}
+	for downloadURL, want := range cases {
+		req, _ := http.NewRequest(http.MethodGet, downloadURL, nil)
+		proxyURL, err := tr.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := ""
+		if proxyURL != nil {
+			got = proxyURL.String()
+		}
+		if got != want {
+			t.Errorf("%s: want proxy %q, got: %q", downloadURL, want, got)
+		}
+	}
+}
+
+func Test_makeDownloadClient_InsecureSkipTLSVerify(t *testing.T) {
+	SetInsecureSkipTLSVerify(true)
+	t.Cleanup(func() {
+		SetInsecureSkipTLSVerify(false)
+	})
+
+	client := makeDownloadClient()
+	tr := client.Transport.(*http.Transport)
+	if tr.TLSClientConfig == nil || !tr.TLSClientConfig.InsecureSkipVerify {
+		t.Errorf("want TLS verification to be skipped")
+	}
+}
@@ -0,0 +1,177 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"github.com/alexellis/arkade/pkg/config"
+)
+
+// InstalledTool is an entry in the installed-tools manifest, written
+// whenever a tool is stashed in $HOME/.arkade/bin/
+type InstalledTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path"`
+}
+
+// InstalledManifestPath is the location of the installed-tools manifest
+func InstalledManifestPath() string {
+	return path.Join(config.GetUserDir(), "installed.json")
+}
+
+// LoadInstalled reads the installed-tools manifest, a missing manifest
+// gives an empty list
+func LoadInstalled(manifestPath string) ([]InstalledTool, error) {
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []InstalledTool{}, nil
+		}
+		return nil, err
+	}
+
+	var installed []InstalledTool
+	if err := json.Unmarshal(data, &installed); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", manifestPath, err)
+	}
+	return installed, nil
+}
+
+func saveInstalled(manifestPath string, installed []InstalledTool) error {
+	sort.Slice(installed, func(i, j int) bool {
+		return installed[i].Name < installed[j].Name
+	})
+
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestPath, data, 0600)
+}
+
+// RecordInstall adds or replaces the manifest entry for a tool
+func RecordInstall(manifestPath string, tool InstalledTool) error {
+	installed, err := LoadInstalled(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	updated := []InstalledTool{tool}
+	for _, t := range installed {
+		if t.Name != tool.Name {
+			updated = append(updated, t)
+		}
+	}
+	return saveInstalled(manifestPath, updated)
+}
+
+// FindInstalled returns the path of an installed tool, tools stashed
+// before the manifest existed are looked up in binDir
+func FindInstalled(manifestPath, binDir, name string) (string, error) {
+	installed, err := LoadInstalled(manifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, t := range installed {
+		if t.Name == name {
+			return t.Path, nil
+		}
+	}
+
+	for _, fileName := range []string{name, name + ".exe"} {
+		candidate := path.Join(binDir, fileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not installed", name)
+}
+
+// Uninstall deletes an installed tool's binary and removes it from the
+// manifest, the path of the deleted binary is returned
+func Uninstall(manifestPath, binDir, name string) (string, error) {
+	binaryPath, err := FindInstalled(manifestPath, binDir, name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(binaryPath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	installed, err := LoadInstalled(manifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	remaining := []InstalledTool{}
+	for _, t := range installed {
+		if t.Name != name {
+			remaining = append(remaining, t)
+		}
+	}
+
+	if len(remaining) != len(installed) {
+		if err := saveInstalled(manifestPath, remaining); err != nil {
+			return "", err
+		}
+	}
+	return binaryPath, nil
+}
+
+// VersionComparison compares the installed version of a tool with its
+// latest release
+type VersionComparison struct {
+	Tool            string `json:"tool"`
+	Installed       string `json:"installed"`
+	Latest          string `json:"latest"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+}
+
+// ReleaseResolver gives the latest version of a tool
+type ReleaseResolver func(tool Tool) (string, error)
+
+// CompareVersions looks up the installed version of a tool in the
+// manifest and compares it with the latest release given by resolve
+func CompareVersions(manifestPath string, tool Tool, resolve ReleaseResolver) (VersionComparison, error) {
+	installed, err := LoadInstalled(manifestPath)
+	if err != nil {
+		return VersionComparison{}, err
+	}
+
+	comparison := VersionComparison{Tool: tool.Name}
+	for _, t := range installed {
+		if t.Name == tool.Name {
+			comparison.Installed = t.Version
+		}
+	}
+	if len(comparison.Installed) == 0 {
+		return VersionComparison{}, fmt.Errorf("no installed version of %s was recorded, install it with: arkade get %s", tool.Name, tool.Name)
+	}
+
+	comparison.Latest, err = resolve(tool)
+	if err != nil {
+		return VersionComparison{}, err
+	}
+
+	comparison.UpdateAvailable = isNewer(comparison.Latest, comparison.Installed)
+	return comparison, nil
+}
+
+// isNewer compares semantic versions, falling back to whether the
+// versions differ when either cannot be parsed
+func isNewer(latest, installed string) bool {
+	latestVersion, latestErr := semver.NewVersion(latest)
+	installedVersion, installedErr := semver.NewVersion(installed)
+	if latestErr != nil || installedErr != nil {
+		return latest != installed
+	}
+	return latestVersion.GreaterThan(installedVersion)
+}
@@ -0,0 +1,136 @@
+package get
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Uninstall_RemovesBinaryAndManifestEntry(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "installed.json")
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"helm", "kubectl"} {
+		binaryPath := filepath.Join(binDir, name)
+		if err := ioutil.WriteFile(binaryPath, []byte("binary"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordInstall(manifestPath, InstalledTool{Name: name, Version: "v1.0.0", Path: binaryPath}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Uninstall(manifestPath, binDir, "helm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != filepath.Join(binDir, "helm") {
+		t.Errorf("want helm's path to be returned, got: %s", removed)
+	}
+	if _, err := os.Stat(removed); !os.IsNotExist(err) {
+		t.Errorf("want helm's binary to be deleted, got: %v", err)
+	}
+
+	installed, err := LoadInstalled(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 1 || installed[0].Name != "kubectl" {
+		t.Errorf("want only kubectl in the manifest, got: %v", installed)
+	}
+}
+
+func Test_Uninstall_FallsBackToBinDir(t *testing.T) {
+	dir := t.TempDir()
+	binaryPath := filepath.Join(dir, "faas-cli")
+	if err := ioutil.WriteFile(binaryPath, []byte("binary"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Uninstall(filepath.Join(dir, "installed.json"), dir, "faas-cli"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(binaryPath); !os.IsNotExist(err) {
+		t.Errorf("want the binary to be deleted, got: %v", err)
+	}
+}
+
+func Test_Uninstall_NotInstalled(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Uninstall(filepath.Join(dir, "installed.json"), dir, "helm"); err == nil {
+		t.Errorf("want an error for a tool which is not installed")
+	}
+}
+
+func Test_CompareVersions(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "installed.json")
+	if err := RecordInstall(manifestPath, InstalledTool{Name: "helm", Version: "v3.5.0", Path: "/home/user/.arkade/bin/helm"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		latest     string
+		wantUpdate bool
+		wantText   string
+	}{
+		{name: "up to date", latest: "v3.5.0", wantUpdate: false, wantText: "Installed: v3.5.0\nLatest:    v3.5.0\nhelm is up to date\n"},
+		{name: "outdated", latest: "v3.6.1", wantUpdate: true, wantText: "Installed: v3.5.0\nLatest:    v3.6.1\nAn update is available, run: arkade get helm\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolve := func(tool Tool) (string, error) {
+				return tc.latest, nil
+			}
+
+			comparison, err := CompareVersions(manifestPath, Tool{Name: "helm"}, resolve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if comparison.UpdateAvailable != tc.wantUpdate {
+				t.Errorf("want update available: %t, got: %t", tc.wantUpdate, comparison.UpdateAvailable)
+			}
+
+			var text bytes.Buffer
+			if err := WriteVersionComparison(&text, comparison, TableStyle); err != nil {
+				t.Fatal(err)
+			}
+			if text.String() != tc.wantText {
+				t.Errorf("want:\n%s\ngot:\n%s", tc.wantText, text.String())
+			}
+
+			var out bytes.Buffer
+			if err := WriteVersionComparison(&out, comparison, JSONStyle); err != nil {
+				t.Fatal(err)
+			}
+			got := VersionComparison{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != comparison {
+				t.Errorf("want JSON: %+v, got: %+v", comparison, got)
+			}
+		})
+	}
+}
+
+func Test_CompareVersions_NotInstalled(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "installed.json")
+	resolve := func(tool Tool) (string, error) {
+		return "v1.0.0", nil
+	}
+
+	if _, err := CompareVersions(manifestPath, Tool{Name: "helm"}, resolve); err == nil {
+		t.Errorf("want an error when no version was recorded")
+	}
+}
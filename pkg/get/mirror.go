@@ -0,0 +1,59 @@
+package get
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MirrorEnv names the environment variable which sets the mirror for
+// downloads, the --mirror flag of arkade get takes precedence
+const MirrorEnv = "ARKADE_DOWNLOAD_MIRROR"
+
+var mirror *url.URL
+
+// SetMirror makes downloads come from the mirror, such as an internal
+// artifact server, by replacing the scheme and host of each URL. Any
+// path in the mirror is prepended to the path of the URL. An empty
+// value downloads from the original URLs.
+func SetMirror(value string) error {
+	if len(value) == 0 {
+		mirror = nil
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 || len(u.RawQuery) > 0 {
+		return fmt.Errorf("the mirror must be an http or https URL such as https://artifacts.example.com/github, got: %q", value)
+	}
+	mirror = u
+	return nil
+}
+
+// MirrorURL rewrites a download URL to come from the mirror set with
+// SetMirror, the URL is returned unchanged when there is no mirror
+func MirrorURL(downloadURL string) string {
+	if mirror == nil {
+		return downloadURL
+	}
+
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return downloadURL
+	}
+
+	u.Scheme = mirror.Scheme
+	u.Host = mirror.Host
+	u.User = mirror.User
+	u.Path = strings.TrimSuffix(mirror.Path, "/") + u.Path
+	u.RawPath = ""
+	return u.String()
+}
+
+var verbose bool
+
+// SetVerbose prints where each file is downloaded from, such as when
+// checking the URLs given by a mirror
+func SetVerbose(value bool) {
+	verbose = value
+}
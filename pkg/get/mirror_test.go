@@ -0,0 +1,64 @@
+package get
+
+import (
+	"testing"
+)
+
+func useMirror(t *testing.T, value string) {
+	t.Helper()
+
+	if err := SetMirror(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetMirror("")
+	})
+}
+
+func Test_MirrorURL(t *testing.T) {
+	downloadURL := "https://github.com/openfaas/faas-cli/releases/download/0.13.0/faas-cli"
+
+	cases := []struct {
+		mirror string
+		want   string
+	}{
+		{mirror: "", want: downloadURL},
+		{mirror: "https://artifacts.example.com", want: "https://artifacts.example.com/openfaas/faas-cli/releases/download/0.13.0/faas-cli"},
+		{mirror: "http://10.0.0.5:8080/github/", want: "http://10.0.0.5:8080/github/openfaas/faas-cli/releases/download/0.13.0/faas-cli"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.mirror, func(t *testing.T) {
+			useMirror(t, tc.mirror)
+
+			if got := MirrorURL(downloadURL); got != tc.want {
+				t.Errorf("want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_SetMirror_RejectsInvalidURLs(t *testing.T) {
+	t.Cleanup(func() {
+		SetMirror("")
+	})
+
+	for _, value := range []string{"artifacts.example.com", "ftp://artifacts.example.com", "https://", "https://artifacts.example.com/?token=1"} {
+		if err := SetMirror(value); err == nil {
+			t.Errorf("want %q to be rejected", value)
+		}
+	}
+}
+
+func Test_verifyChecksum_FromMirror(t *testing.T) {
+	server := checksumServer(t, map[string]string{
+		"/github/openfaas/faas-cli/releases/download/0.13.0/checksums.txt": sha256Hex("binary") + "  faas-cli\n",
+	})
+	useMirror(t, server.URL+"/github")
+	filePath := writeDownloadedFile(t, "binary")
+
+	downloadURL := MirrorURL("https://github.com/openfaas/faas-cli/releases/download/0.13.0/faas-cli")
+	if err := verifyChecksum(&Tool{Name: "faas-cli", Repo: "faas-cli"}, downloadURL, filePath); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+package get
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// DefaultParallel is the number of tools downloaded at once when more
+// than one is given to arkade get
+const DefaultParallel = 4
+
+// DownloadResult is the outcome of downloading one tool with
+// DownloadAll
+type DownloadResult struct {
+	Tool      string
+	Path      string
+	FinalName string
+	Err       error
+}
+
+// SyncWriter serialises writes to an io.Writer, so that each line written
+// by a download is not interleaved with those of the others
+type SyncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSyncWriter wraps w for concurrent use
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+func (s *SyncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// DownloadFunc downloads a single tool, returning its path and the
+// name of the binary as per Download
+type DownloadFunc func(tool Tool) (string, string, error)
+
+// DownloadAll downloads the tools using at most parallel downloads at
+// once. A failure does not stop the other tools being downloaded, the
+// results are returned in the order of tools.
+func DownloadAll(w io.Writer, tools []Tool, parallel int, download DownloadFunc) []DownloadResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]DownloadResult, len(tools))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < parallel && i < len(tools); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				tool := tools[index]
+				path, finalName, err := download(tool)
+				results[index] = DownloadResult{Tool: tool.Name, Path: path, FinalName: finalName, Err: err}
+
+				// Whole lines are written under the lock, so that the
+				// status of each tool is readable
+				mu.Lock()
+				if err != nil {
+					fmt.Fprintf(w, "Failed to download %s: %s\n", tool.Name, err)
+				} else {
+					fmt.Fprintf(w, "Downloaded %s to %s\n", tool.Name, path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for index := range tools {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// WriteDownloadSummary writes a table with the result of each download
+func WriteDownloadSummary(w io.Writer, results []DownloadResult) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Tool", "Status", "Path"})
+	table.SetAutoWrapText(false)
+
+	for _, result := range results {
+		if result.Err != nil {
+			table.Append([]string{result.Tool, "failed", result.Err.Error()})
+			continue
+		}
+		table.Append([]string{result.Tool, "ok", result.Path})
+	}
+
+	table.Render()
+}
+
+// FailedDownloads counts the results which have an error
+func FailedDownloads(results []DownloadResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
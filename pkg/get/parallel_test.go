@@ -0,0 +1,112 @@
+package get
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_DownloadAll_BoundedAndCollectsFailures(t *testing.T) {
+	tools := []Tool{{Name: "kubectl"}, {Name: "helm"}, {Name: "faas-cli"}, {Name: "kind"}, {Name: "k3d"}}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	download := func(tool Tool) (string, string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond * 10)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if tool.Name == "helm" {
+			return "", "", errors.New("incorrect status for downloading tool: 404")
+		}
+		return "/home/user/.arkade/bin/" + tool.Name, tool.Name, nil
+	}
+
+	var out bytes.Buffer
+	results := DownloadAll(&out, tools, 2, download)
+
+	if maxRunning > 2 {
+		t.Errorf("want at most 2 downloads at once, got: %d", maxRunning)
+	}
+	if len(results) != len(tools) {
+		t.Fatalf("want a result for each tool, got: %v", results)
+	}
+	for i, result := range results {
+		if result.Tool != tools[i].Name {
+			t.Errorf("want results in the order given, got %s at %d", result.Tool, i)
+		}
+	}
+	if results[1].Err == nil || results[4].Path != "/home/user/.arkade/bin/k3d" {
+		t.Errorf("want helm to fail and the others to complete, got: %v", results)
+	}
+	if got := FailedDownloads(results); got != 1 {
+		t.Errorf("want 1 failed download, got: %d", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(tools) {
+		t.Fatalf("want a line per tool, got:\n%s", out.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "Downloaded ") && !strings.HasPrefix(line, "Failed to download ") {
+			t.Errorf("want whole status lines, got: %q", line)
+		}
+	}
+}
+
+func Test_SyncWriter_KeepsLinesWhole(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewSyncWriter(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				fmt.Fprintf(out, "Downloaded tool-%d to /tmp/tool-%d\n", i, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("want 1000 lines, got: %d", len(lines))
+	}
+	for _, line := range lines {
+		var i, j int
+		if _, err := fmt.Sscanf(line, "Downloaded tool-%d to /tmp/tool-%d", &i, &j); err != nil || i != j {
+			t.Fatalf("want whole lines, got: %q", line)
+		}
+	}
+}
+
+func Test_WriteDownloadSummary(t *testing.T) {
+	results := []DownloadResult{
+		{Tool: "kubectl", Path: "/home/user/.arkade/bin/kubectl"},
+		{Tool: "helm", Err: errors.New("incorrect status for downloading tool: 404")},
+	}
+
+	var out bytes.Buffer
+	WriteDownloadSummary(&out, results)
+
+	for _, want := range []string{"kubectl", "ok", "/home/user/.arkade/bin/kubectl", "helm", "failed", "incorrect status for downloading tool: 404"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want summary to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
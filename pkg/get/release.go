@@ -0,0 +1,108 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Channel selects which releases of a tool are considered when no
+// version is given
+type Channel string
+
+const (
+	// StableChannel only considers full releases
+	StableChannel Channel = "stable"
+
+	// BetaChannel also considers pre-releases
+	BetaChannel Channel = "beta"
+)
+
+// ParseChannel validates the value of the --channel flag
+func ParseChannel(value string) (Channel, error) {
+	switch Channel(value) {
+	case StableChannel, BetaChannel:
+		return Channel(value), nil
+	}
+	return "", fmt.Errorf("channel must be one of: %s, %s, but got: %q", StableChannel, BetaChannel, value)
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
+}
+
+// FindRelease resolves the newest version of a tool published to GitHub
+// on the given channel
+func FindRelease(owner, repo string, channel Channel) (string, error) {
+	if channel != BetaChannel {
+		return findGitHubRelease(owner, repo)
+	}
+
+	releases, err := listGitHubReleases(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return selectRelease(releases, channel)
+}
+
+// LatestRelease gives the version of a tool which would be downloaded
+// on the channel, a version pinned by arkade takes precedence
+func LatestRelease(tool Tool, channel Channel) (string, error) {
+	if len(tool.Version) > 0 {
+		return tool.Version, nil
+	}
+	if len(tool.Repo) == 0 {
+		return "", fmt.Errorf("unable to find the latest release of %s, it has no GitHub repo", tool.Name)
+	}
+	return FindRelease(tool.Owner, tool.Repo, channel)
+}
+
+// selectRelease picks the first release for the channel from a list
+// ordered newest first, as returned by the GitHub API
+func selectRelease(releases []githubRelease, channel Channel) (string, error) {
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		if release.Prerelease && channel != BetaChannel {
+			continue
+		}
+		return release.TagName, nil
+	}
+	return "", fmt.Errorf("no releases found for channel: %s", channel)
+}
+
+func listGitHubReleases(owner, repo string) ([]githubRelease, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", owner, repo)
+
+	timeout := time.Second * 5
+	client := makeHTTPClient(&timeout, false)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent())
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("incorrect status code: %d", res.StatusCode)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(res.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("unable to parse releases for %s/%s: %w", owner, repo, err)
+	}
+	return releases, nil
+}
@@ -0,0 +1,52 @@
+package get
+
+import "testing"
+
+func Test_selectRelease(t *testing.T) {
+	releases := []githubRelease{
+		{TagName: "v0.14.0-rc2", Draft: true},
+		{TagName: "v0.14.0-rc1", Prerelease: true},
+		{TagName: "v0.13.2"},
+		{TagName: "v0.13.1"},
+	}
+
+	cases := []struct {
+		channel Channel
+		want    string
+	}{
+		{channel: StableChannel, want: "v0.13.2"},
+		{channel: BetaChannel, want: "v0.14.0-rc1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.channel), func(t *testing.T) {
+			got, err := selectRelease(releases, tc.channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_selectRelease_NoStableRelease(t *testing.T) {
+	releases := []githubRelease{{TagName: "v0.1.0-beta", Prerelease: true}}
+
+	if _, err := selectRelease(releases, StableChannel); err == nil {
+		t.Errorf("want an error when only pre-releases exist")
+	}
+}
+
+func Test_ParseChannel(t *testing.T) {
+	for _, value := range []string{"stable", "beta"} {
+		if _, err := ParseChannel(value); err != nil {
+			t.Errorf("want %s to be valid, got: %s", value, err)
+		}
+	}
+
+	if _, err := ParseChannel("nightly"); err == nil {
+		t.Errorf("want nightly to be rejected")
+	}
+}
@@ -1,8 +1,15 @@
 package get
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 
+	"strings"
+
+	"github.com/alexellis/arkade/pkg/color"
+	"github.com/alexellis/arkade/pkg/table"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -11,11 +18,76 @@ type TableFormat string
 const (
 	TableStyle    TableFormat = "table"
 	MarkdownStyle TableFormat = "markdown"
+	JSONStyle     TableFormat = "json"
+	WideStyle     TableFormat = "wide"
 )
 
+// ToolListing is a tool as listed by --output wide or json
+type ToolListing struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Repo        string   `json:"repo,omitempty"`
+	Platforms   []string `json:"platforms,omitempty"`
+}
+
+func newToolListing(t Tool) ToolListing {
+	listing := ToolListing{
+		Name:        t.Name,
+		Description: t.Description,
+		Platforms:   t.Platforms,
+	}
+	if len(t.Repo) > 0 {
+		listing.Repo = t.Owner + "/" + t.Repo
+	}
+	return listing
+}
+
+// WriteToolsList writes the tools as a table, the wide format adds the
+// repo and platforms and is fitted to width, zero for no limit
+func WriteToolsList(w io.Writer, tools Tools, format TableFormat, width int) error {
+	switch format {
+	case JSONStyle:
+		listings := []ToolListing{}
+		for _, t := range tools {
+			listings = append(listings, newToolListing(t))
+		}
+		out, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case WideStyle:
+		list := table.NewWriter(width)
+		list.SetHeader("Tool", "Repo", "Platforms", "Description")
+		for _, t := range tools {
+			listing := newToolListing(t)
+			platforms := strings.Join(listing.Platforms, ",")
+			if len(platforms) == 0 {
+				platforms = "-"
+			}
+			repo := listing.Repo
+			if len(repo) == 0 {
+				repo = "-"
+			}
+			list.Append(listing.Name, repo, platforms, listing.Description)
+		}
+		return list.Render(w)
+	}
+
+	WriteToolsTable(w, tools, format)
+	return nil
+}
+
 // CreateToolTable creates table to show the avaiable CLI tools
 func CreateToolsTable(tools Tools, format TableFormat) {
-	table := tablewriter.NewWriter(os.Stdout)
+	WriteToolsTable(os.Stdout, tools, format)
+}
+
+// WriteToolsTable writes the table of tools to w, it is only colored
+// when enabled with the --color flag
+func WriteToolsTable(w io.Writer, tools Tools, format TableFormat) {
+	table := tablewriter.NewWriter(w)
 	table.SetHeader([]string{"Tool", "Description"})
 	table.SetCaption(true, "Use 'arkade get TOOL' to download a tool or application.")
 	if format == MarkdownStyle {
@@ -25,8 +97,10 @@ func CreateToolsTable(tools Tools, format TableFormat) {
 	} else {
 		table.SetRowLine(true)
 		table.SetColWidth(60)
-		table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{})
-		table.SetColumnColor(tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor}, tablewriter.Colors{})
+		if color.Enabled() {
+			table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{})
+			table.SetColumnColor(tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor}, tablewriter.Colors{})
+		}
 	}
 
 	for _, t := range tools {
@@ -35,3 +109,50 @@ func CreateToolsTable(tools Tools, format TableFormat) {
 
 	table.Render()
 }
+
+// WritePlatforms writes the platforms a tool can be downloaded for as
+// a table or as JSON
+func WritePlatforms(w io.Writer, toolName string, downloads []PlatformDownload, format TableFormat) error {
+	if format == JSONStyle {
+		out, err := json.MarshalIndent(downloads, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Platform", "URL"})
+	table.SetCaption(true, fmt.Sprintf("Platforms for %s", toolName))
+	table.SetAutoWrapText(false)
+
+	for _, download := range downloads {
+		table.Append([]string{download.Platform, download.URL})
+	}
+
+	table.Render()
+	return nil
+}
+
+// WriteVersionComparison writes the installed and latest versions of a
+// tool as text or as JSON
+func WriteVersionComparison(w io.Writer, comparison VersionComparison, format TableFormat) error {
+	if format == JSONStyle {
+		out, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	fmt.Fprintf(w, "Installed: %s\n", comparison.Installed)
+	fmt.Fprintf(w, "Latest:    %s\n", comparison.Latest)
+	if comparison.UpdateAvailable {
+		fmt.Fprintf(w, "An update is available, run: arkade get %s\n", comparison.Tool)
+	} else {
+		fmt.Fprintf(w, "%s is up to date\n", comparison.Tool)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package get
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/color"
+)
+
+func Test_WritePlatforms(t *testing.T) {
+	tool := Tool{
+		Name:    "acme-cli",
+		Version: "v1.0.0",
+		URLTemplate: `{{$os := .OS}}{{ if HasPrefix .OS "ming" }}{{$os = "windows"}}{{end}}
+{{$arch := "amd64"}}{{ if eq .Arch "aarch64" }}{{$arch = "arm64"}}{{end}}
+https://example.com/{{.Version}}/acme-cli-{{$os}}-{{$arch}}`,
+		Platforms: []string{"linux/x86_64", "linux/aarch64", "windows/x86_64"},
+	}
+
+	downloads, err := ListPlatforms(tool)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []PlatformDownload{
+		{Platform: "linux/x86_64", URL: "https://example.com/v1.0.0/acme-cli-linux-amd64"},
+		{Platform: "linux/aarch64", URL: "https://example.com/v1.0.0/acme-cli-linux-arm64"},
+		{Platform: "windows/x86_64", URL: "https://example.com/v1.0.0/acme-cli-windows-amd64"},
+	}
+	if !reflect.DeepEqual(downloads, want) {
+		t.Fatalf("want:\n%v\ngot:\n%v", want, downloads)
+	}
+
+	var table bytes.Buffer
+	if err := WritePlatforms(&table, tool.Name, downloads, TableStyle); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range want {
+		if !strings.Contains(table.String(), d.Platform) || !strings.Contains(table.String(), d.URL) {
+			t.Errorf("want table to contain %s and %s, got:\n%s", d.Platform, d.URL, table.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WritePlatforms(&out, tool.Name, downloads, JSONStyle); err != nil {
+		t.Fatal(err)
+	}
+	var got []PlatformDownload
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("want valid JSON, got: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want:\n%v\ngot:\n%v", want, got)
+	}
+}
+
+func Test_WriteToolsTable_Color(t *testing.T) {
+	defer color.SetMode(color.Never)
+	tools := Tools{{Name: "helm", Description: "The Kubernetes Package Manager"}}
+
+	color.SetMode(color.Always)
+	var colored bytes.Buffer
+	WriteToolsTable(&colored, tools, TableStyle)
+	if !strings.Contains(colored.String(), "\x1b[") {
+		t.Errorf("want color codes with --color always, got:\n%s", colored.String())
+	}
+
+	color.SetMode(color.Never)
+	var plain bytes.Buffer
+	WriteToolsTable(&plain, tools, TableStyle)
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Errorf("want no color codes with --color never, got:\n%s", plain.String())
+	}
+	if !strings.Contains(plain.String(), "helm") {
+		t.Errorf("want the tool in the table, got:\n%s", plain.String())
+	}
+}
+
+func Test_WriteToolsList_WideAndJSON(t *testing.T) {
+	tools := Tools{
+		{
+			Name:        "helm",
+			Owner:       "helm",
+			Repo:        "helm",
+			Description: "The Kubernetes Package Manager",
+			Platforms:   []string{"linux/x86_64", "darwin/x86_64"},
+		},
+		{Name: "kubectl", Description: "Run commands against Kubernetes clusters"},
+	}
+
+	var wide bytes.Buffer
+	if err := WriteToolsList(&wide, tools, WideStyle, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TOOL", "REPO", "PLATFORMS", "DESCRIPTION", "helm/helm", "linux/x86_64,darwin/x86_64", "The Kubernetes Package Manager"} {
+		if !strings.Contains(wide.String(), want) {
+			t.Errorf("want wide output to contain %q, got:\n%s", want, wide.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteToolsList(&out, tools, JSONStyle, 0); err != nil {
+		t.Fatal(err)
+	}
+	var got []ToolListing
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("want valid JSON, got: %s\n%s", err, out.String())
+	}
+
+	want := []ToolListing{
+		{Name: "helm", Description: "The Kubernetes Package Manager", Repo: "helm/helm", Platforms: []string{"linux/x86_64", "darwin/x86_64"}},
+		{Name: "kubectl", Description: "Run commands against Kubernetes clusters"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want:\n%v\ngot:\n%v", want, got)
+	}
+}
@@ -0,0 +1,127 @@
+package get
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// toolDefinition is the format of a tool defined by a user in a YAML
+// file, it maps onto a Tool
+type toolDefinition struct {
+	Name           string   `json:"name"`
+	Owner          string   `json:"owner"`
+	Repo           string   `json:"repo"`
+	Version        string   `json:"version"`
+	Description    string   `json:"description"`
+	URLTemplate    string   `json:"urlTemplate"`
+	BinaryTemplate string   `json:"binaryTemplate"`
+	NoExtension    bool     `json:"noExtension"`
+	Platforms      []string `json:"platforms"`
+}
+
+func (d toolDefinition) validate() error {
+	var missing []string
+	if len(d.Name) == 0 {
+		missing = append(missing, "name")
+	}
+	if len(d.URLTemplate) == 0 {
+		missing = append(missing, "urlTemplate")
+	}
+	if len(d.Platforms) == 0 {
+		missing = append(missing, "platforms")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	for _, platform := range d.Platforms {
+		if parts := strings.Split(platform, "/"); len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("platform %q must be in the form os/arch i.e. linux/x86_64", platform)
+		}
+	}
+
+	if _, err := template.New(d.Name).Funcs(templateFuncs).Parse(d.URLTemplate); err != nil {
+		return fmt.Errorf("invalid urlTemplate: %w", err)
+	}
+
+	return nil
+}
+
+// LoadUserTools reads tool definitions from the *.yaml files in dir,
+// a missing directory gives no tools. Files which can't be read or are
+// invalid are skipped with a warning.
+func LoadUserTools(dir string) (Tools, []string) {
+	var tools Tools
+	var warnings []string
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("unable to read tool definition %s: %s", file, err))
+			continue
+		}
+
+		definition := toolDefinition{}
+		if err := yaml.UnmarshalStrict(data, &definition); err != nil {
+			warnings = append(warnings, fmt.Sprintf("unable to parse tool definition %s: %s", file, err))
+			continue
+		}
+
+		if err := definition.validate(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid tool definition %s: %s", file, err))
+			continue
+		}
+
+		tools = append(tools, Tool{
+			Name:           definition.Name,
+			Owner:          definition.Owner,
+			Repo:           definition.Repo,
+			Version:        definition.Version,
+			Description:    definition.Description,
+			URLTemplate:    definition.URLTemplate,
+			BinaryTemplate: definition.BinaryTemplate,
+			NoExtension:    definition.NoExtension,
+			Platforms:      definition.Platforms,
+		})
+	}
+
+	return tools, warnings
+}
+
+// MergeTools adds the user's tools to the built-in tools, a user's tool
+// replaces a built-in tool of the same name with a warning. The result
+// is sorted.
+func MergeTools(builtIn, user Tools) (Tools, []string) {
+	var warnings []string
+
+	merged := Tools{}
+	index := map[string]int{}
+	for _, tool := range builtIn {
+		index[tool.Name] = len(merged)
+		merged = append(merged, tool)
+	}
+
+	for _, tool := range user {
+		if i, ok := index[tool.Name]; ok {
+			warnings = append(warnings, fmt.Sprintf("tool %s from the tools directory replaces the built-in definition", tool.Name))
+			merged[i] = tool
+			continue
+		}
+		index[tool.Name] = len(merged)
+		merged = append(merged, tool)
+	}
+
+	sort.Sort(merged)
+	return merged, warnings
+}
@@ -0,0 +1,103 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package gitsource reads a single file, such as an app manifest, from a
+// Git repository at a given branch or tag.
+package gitsource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// Source is a file within a Git repository at a ref, written as
+// <repo>@<ref>:<path>, i.e. https://github.com/org/apps@v1.0.0:registry.yaml
+type Source struct {
+	Repo string
+	Ref  string
+	Path string
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("%s@%s:%s", s.Repo, s.Ref, s.Path)
+}
+
+// refRegex allows branch and tag names, but no option-like or
+// revision expressions
+var refRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._/-]*$`)
+
+// Parse reads a Source from <repo>@<ref>:<path>. The repo may itself
+// contain "@" and ":", as with git@github.com:org/apps.git.
+func Parse(value string) (Source, error) {
+	at := strings.LastIndex(value, "@")
+	if at < 1 {
+		return Source{}, fmt.Errorf("git source %q must be in the form <repo>@<ref>:<path>", value)
+	}
+
+	refAndPath := strings.SplitN(value[at+1:], ":", 2)
+	if len(refAndPath) != 2 {
+		return Source{}, fmt.Errorf("git source %q must be in the form <repo>@<ref>:<path>", value)
+	}
+
+	source := Source{Repo: value[:at], Ref: refAndPath[0], Path: refAndPath[1]}
+	if err := source.Validate(); err != nil {
+		return Source{}, err
+	}
+	return source, nil
+}
+
+// Validate checks the ref is a branch or tag name and that the path is
+// relative and within the repository
+func (s Source) Validate() error {
+	if !refRegex.MatchString(s.Ref) || strings.Contains(s.Ref, "..") ||
+		strings.HasSuffix(s.Ref, "/") || strings.HasSuffix(s.Ref, ".lock") {
+		return fmt.Errorf("git ref %q is not a valid branch or tag name", s.Ref)
+	}
+
+	cleaned := path.Clean(s.Path)
+	if len(s.Path) == 0 || path.IsAbs(s.Path) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("git path %q must be a relative path to a file within the repository", s.Path)
+	}
+	return nil
+}
+
+// Read makes a shallow clone of the repository at the ref into a
+// temporary directory and returns the contents of the file at the path
+func Read(s Source) ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "arkade-git-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	task := execute.ExecTask{
+		Command: "git",
+		Args:    []string{"clone", "--quiet", "--depth", "1", "--branch", s.Ref, "--", s.Repo, dir},
+		Env:     os.Environ(),
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitCode != 0 {
+		return nil, fmt.Errorf("unable to clone %s at %s: %s", s.Repo, s.Ref, strings.TrimSpace(res.Stderr))
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Clean(s.Path))))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s from %s at %s: %w", s.Path, s.Repo, s.Ref, err)
+	}
+	return data, nil
+}
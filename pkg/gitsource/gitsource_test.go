@@ -0,0 +1,102 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package gitsource
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	cases := []struct {
+		value string
+		want  Source
+	}{
+		{
+			value: "https://github.com/org/apps@v1.0.0:registry/ingress.yaml",
+			want:  Source{Repo: "https://github.com/org/apps", Ref: "v1.0.0", Path: "registry/ingress.yaml"},
+		},
+		{
+			value: "git@github.com:org/apps.git@main:ingress.yaml",
+			want:  Source{Repo: "git@github.com:org/apps.git", Ref: "main", Path: "ingress.yaml"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := Parse(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"https://github.com/org/apps",
+		"https://github.com/org/apps@v1.0.0",
+		"https://github.com/org/apps@--upload-pack=evil:ingress.yaml",
+		"https://github.com/org/apps@main..dev:ingress.yaml",
+		"https://github.com/org/apps@main:/etc/passwd",
+		"https://github.com/org/apps@main:../outside.yaml",
+		"https://github.com/org/apps@main:",
+	} {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("want %q to be rejected", value)
+		}
+	}
+}
+
+func Test_Read_FromRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		if err := os.MkdirAll(filepath.Join(repo, "apps"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(repo, "apps", "registry.yaml"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("version: 1\n")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1.0.0")
+	write("version: 2\n")
+	git("commit", "--quiet", "-am", "v2")
+
+	got, err := Read(Source{Repo: "file://" + repo, Ref: "v1.0.0", Path: "apps/registry.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "version: 1\n" {
+		t.Errorf("want the manifest at v1.0.0, got: %q", string(got))
+	}
+
+	if _, err := Read(Source{Repo: "file://" + repo, Ref: "v9.9.9", Path: "apps/registry.yaml"}); err == nil {
+		t.Errorf("want an error for a missing ref")
+	}
+}
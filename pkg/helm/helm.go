@@ -13,6 +13,7 @@ import (
 
 	"github.com/alexellis/arkade/pkg/env"
 	"github.com/alexellis/arkade/pkg/get"
+	"github.com/alexellis/arkade/pkg/k8s"
 	execute "github.com/alexellis/go-execute/pkg/v1"
 )
 
@@ -87,7 +88,7 @@ func HelmInit() error {
 		StreamStdio: true,
 	}
 
-	res, err := task.Execute()
+	res, err := k8s.Run(task)
 
 	if err != nil {
 		return err
@@ -108,7 +109,7 @@ func UpdateHelmRepos(helm3 bool) error {
 		StreamStdio: true,
 	}
 
-	res, err := task.Execute()
+	res, err := k8s.Run(task)
 
 	if err != nil {
 		return err
@@ -132,7 +133,7 @@ func AddHelmRepo(name, url string, update bool) error {
 		Env:         os.Environ(),
 		StreamStdio: true,
 	}
-	res, err := task.Execute()
+	res, err := k8s.Run(task)
 
 	if err != nil {
 		return err
@@ -150,7 +151,7 @@ func AddHelmRepo(name, url string, update bool) error {
 			Env:         os.Environ(),
 			StreamStdio: true,
 		}
-		res, err := task.Execute()
+		res, err := k8s.Run(task)
 
 		if err != nil {
 			return err
@@ -190,7 +191,7 @@ func FetchChart(chart, version string) error {
 		Env:         os.Environ(),
 		StreamStdio: true,
 	}
-	res, err := task.Execute()
+	res, err := k8s.Run(task)
 
 	if err != nil {
 		return err
@@ -212,6 +213,18 @@ func Helm3Upgrade(chart, namespace, values, version string, overrides map[string
 	basePath := path.Join(os.TempDir(), "charts", chartName)
 
 	args := []string{"upgrade", "--install", chartName, chart, "--namespace", namespace}
+	if server := k8s.GetAPIServer(); len(server.URL) > 0 {
+		// The token goes in a kubeconfig, rather than --kube-token, so
+		// that it is not visible in the process list
+		kubeconfig, remove, err := k8s.WriteAPIServerKubeconfig()
+		if err != nil {
+			return fmt.Errorf("unable to write a kubeconfig for %s: %w", server.URL, err)
+		}
+		defer remove()
+		args = append(args, "--kubeconfig", kubeconfig)
+	} else if kubeContext := k8s.KubeContext(); len(kubeContext) > 0 {
+		args = append(args, "--kube-context", kubeContext)
+	}
 	if len(version) > 0 {
 		args = append(args, "--version", version)
 	}
@@ -243,8 +256,9 @@ func Helm3Upgrade(chart, namespace, values, version string, overrides map[string
 		StreamStdio: true,
 	}
 
-	fmt.Printf("Command: %s %s\n", task.Command, task.Args)
-	res, err := task.Execute()
+	// Sensitive flags are redacted, as the output may be logged such as in CI
+	fmt.Printf("Command: %s %s\n", task.Command, k8s.RedactArgs(task.Args))
+	res, err := k8s.Run(task)
 
 	if err != nil {
 		return err
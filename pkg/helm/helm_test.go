@@ -3,7 +3,16 @@
 
 package helm
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexellis/arkade/pkg/k8s"
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
 
 func Test_GetHelmURL_GitBash(t *testing.T) {
 	arch := "amd64"
@@ -15,3 +24,66 @@ func Test_GetHelmURL_GitBash(t *testing.T) {
 		t.Fatalf("want: %s, but got: %s", want, got)
 	}
 }
+
+// recordingRunner records the tasks it is given, instead of running them
+type recordingRunner struct {
+	tasks       []execute.ExecTask
+	kubeconfigs []string
+}
+
+// Run records the task along with any kubeconfig it is given, which is
+// only there while the task runs
+func (r *recordingRunner) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	r.tasks = append(r.tasks, task)
+	for i, arg := range task.Args {
+		if arg == "--kubeconfig" && i+1 < len(task.Args) {
+			data, _ := ioutil.ReadFile(task.Args[i+1])
+			r.kubeconfigs = append(r.kubeconfigs, string(data))
+		}
+	}
+	return execute.ExecResult{}, nil
+}
+
+func Test_Helm3Upgrade_PassesTokenInKubeconfig(t *testing.T) {
+	const token = "s3cr3t-t0k3n"
+	if err := k8s.SetAPIServer(k8s.APIServer{URL: "https://127.0.0.1:6443", Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	defer k8s.SetAPIServer(k8s.APIServer{})
+
+	runner := &recordingRunner{}
+	previous := k8s.SetRunner(runner)
+	defer k8s.SetRunner(previous)
+
+	outFile, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = outFile
+	err = Helm3Upgrade("ingress-nginx/ingress-nginx", "default", "", "", map[string]string{}, false)
+	os.Stdout = stdout
+	outFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), token) {
+		t.Errorf("want the token kept out of the output, got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "--kubeconfig") {
+		t.Errorf("want the command printed, got:\n%s", out)
+	}
+
+	if len(runner.tasks) != 1 || strings.Contains(strings.Join(runner.tasks[0].Args, " "), token) {
+		t.Fatalf("want the token kept off helm's command line, got: %v", runner.tasks)
+	}
+	if len(runner.kubeconfigs) != 1 || !strings.Contains(runner.kubeconfigs[0], "token: "+token) ||
+		!strings.Contains(runner.kubeconfigs[0], "server: https://127.0.0.1:6443") {
+		t.Errorf("want the server and token passed to helm in a kubeconfig, got: %v", runner.kubeconfigs)
+	}
+}
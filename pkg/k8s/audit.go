@@ -0,0 +1,122 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// redacted replaces the value of a sensitive flag in the audit log
+const redacted = "REDACTED"
+
+// sensitiveFlags are flag names, or parts of them, whose values are never
+// written to the audit log
+var sensitiveFlags = []string{"token", "password", "secret", "from-literal"}
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	Args      []string  `json:"args"`
+	ExitCode  int       `json:"exitCode"`
+	Duration  string    `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditRunner runs each task with Next and appends an AuditEntry for it
+// to Writer as a line of JSON
+type AuditRunner struct {
+	Next   Runner
+	Writer io.Writer
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewAuditRunner wraps next, so that every command run is written to w
+func NewAuditRunner(next Runner, w io.Writer) *AuditRunner {
+	return &AuditRunner{Next: next, Writer: w, now: time.Now}
+}
+
+// Run executes the task then records it, the entry is written even when
+// the task fails to start
+func (a *AuditRunner) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	started := a.now()
+	res, err := a.Next.Run(task)
+
+	entry := AuditEntry{
+		Timestamp: started.UTC(),
+		Command:   task.Command,
+		Args:      RedactArgs(task.Args),
+		ExitCode:  res.ExitCode,
+		Duration:  a.now().Sub(started).String(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if writeErr := json.NewEncoder(a.Writer).Encode(entry); writeErr != nil && err == nil {
+		return res, writeErr
+	}
+	return res, err
+}
+
+// RedactArgs gives a copy of args with the values of token, password and
+// secret flags replaced, as --flag=value or --flag value
+func RedactArgs(args []string) []string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext && !strings.HasPrefix(arg, "-") {
+			out[i] = redacted
+			redactNext = false
+			continue
+		}
+		redactNext = false
+
+		if !strings.HasPrefix(arg, "-") {
+			out[i] = arg
+			continue
+		}
+
+		parts := strings.SplitN(arg, "=", 2)
+		name := parts[0]
+		hasValue := len(parts) == 2
+		if !isSensitiveFlag(name) {
+			out[i] = arg
+			continue
+		}
+
+		switch {
+		case !hasValue:
+			out[i] = arg
+			redactNext = true
+		case strings.TrimLeft(name, "-") == "from-literal":
+			// Keep the key, so that the audit shows which keys were set
+			key := strings.SplitN(parts[1], "=", 2)[0]
+			out[i] = name + "=" + key + "=" + redacted
+		default:
+			out[i] = name + "=" + redacted
+		}
+	}
+	return out
+}
+
+func isSensitiveFlag(name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-"))
+	for _, sensitive := range sensitiveFlags {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_AuditRunner_WritesEntryPerCommand(t *testing.T) {
+	mock := &mockRunner{results: []execute.ExecResult{
+		{ExitCode: 0},
+		{ExitCode: 1, Stderr: "forbidden"},
+	}}
+	var audit bytes.Buffer
+	auditRunner := NewAuditRunner(mock, &audit)
+
+	clock := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	auditRunner.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	previous := SetRunner(auditRunner)
+	t.Cleanup(func() {
+		SetRunner(previous)
+	})
+
+	if _, err := KubectlTask("create", "secret", "generic", "dns", "--from-literal=api-token=s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := KubectlTask("get", "pods", "--token", "abc123", "-n", "openfaas"); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []AuditEntry
+	lines := bufio.NewScanner(&audit)
+	for lines.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			t.Fatalf("want each line to be JSON, got: %q, %s", lines.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != len(mock.tasks) {
+		t.Fatalf("want an entry for each of the %d commands, got: %d", len(mock.tasks), len(entries))
+	}
+
+	if strings.Contains(audit.String(), "s3cr3t") || strings.Contains(audit.String(), "abc123") {
+		t.Errorf("want secrets redacted from the audit log, got: %s", audit.String())
+	}
+
+	first := entries[0]
+	if first.Command != "kubectl" || first.ExitCode != 0 || first.Duration != "1s" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if want := "create secret generic dns --from-literal=api-token=REDACTED"; strings.Join(first.Args, " ") != want {
+		t.Errorf("want args: %q, got: %q", want, strings.Join(first.Args, " "))
+	}
+
+	second := entries[1]
+	if second.ExitCode != 1 {
+		t.Errorf("want the exit code recorded, got: %d", second.ExitCode)
+	}
+	if want := "get pods --token REDACTED -n openfaas"; strings.Join(second.Args, " ") != want {
+		t.Errorf("want args: %q, got: %q", want, strings.Join(second.Args, " "))
+	}
+}
+
+func Test_RedactArgs(t *testing.T) {
+	cases := map[string]string{
+		"get pods -n default":                  "get pods -n default",
+		"login --password=hunter2":             "login --password=REDACTED",
+		"upgrade --set-string secret x":        "upgrade --set-string secret x",
+		"config --client-secret abc --verbose": "config --client-secret REDACTED --verbose",
+	}
+
+	for args, want := range cases {
+		if got := strings.Join(RedactArgs(strings.Fields(args)), " "); got != want {
+			t.Errorf("%q: want %q, got: %q", args, want, got)
+		}
+	}
+}
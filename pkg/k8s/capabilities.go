@@ -0,0 +1,100 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// capabilitiesCache holds the result of GetCapabilities for the cluster
+// it was read from, for the lifetime of the process
+var capabilitiesCache struct {
+	sync.Mutex
+	key  string
+	caps Capabilities
+}
+
+// GetCapabilitiesCached is GetCapabilities, but only queries the API
+// server once for each kubeconfig file and context. Changing KUBECONFIG,
+// as config.SetKubeconfig does, the current context or the one given to
+// SetKubeContext reads them again.
+func GetCapabilitiesCached() (Capabilities, error) {
+	key := capabilitiesKey()
+
+	capabilitiesCache.Lock()
+	defer capabilitiesCache.Unlock()
+
+	if capabilitiesCache.caps != nil && capabilitiesCache.key == key {
+		return capabilitiesCache.caps, nil
+	}
+
+	caps, err := GetCapabilities()
+	if err != nil {
+		return caps, err
+	}
+
+	// An empty result means kubectl failed, so try again next time
+	if len(caps) > 0 {
+		capabilitiesCache.key = key
+		capabilitiesCache.caps = caps
+	}
+	return caps, nil
+}
+
+// ResetCapabilitiesCache discards any cached capabilities
+func ResetCapabilitiesCache() {
+	capabilitiesCache.Lock()
+	defer capabilitiesCache.Unlock()
+
+	capabilitiesCache.key = ""
+	capabilitiesCache.caps = nil
+}
+
+// capabilitiesKey identifies the cluster which kubectl is targeting,
+// without running kubectl, so that a cached lookup stays cheap
+func capabilitiesKey() string {
+	if len(apiServer.URL) > 0 {
+		return "server\x00" + apiServer.URL
+	}
+
+	kubeconfig := os.Getenv("KUBECONFIG")
+	context := kubeContext
+	if len(context) == 0 {
+		context = currentContext(kubeconfig)
+	}
+	return kubeconfig + "\x00" + context
+}
+
+// currentContext reads the current-context from the kubeconfig files
+// as kubectl would, the first file to set it wins. An empty string is
+// given when no file can be read.
+func currentContext(kubeconfig string) string {
+	paths := filepath.SplitList(kubeconfig)
+	if len(paths) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var config struct {
+			CurrentContext string `json:"current-context"`
+		}
+		if err := yaml.Unmarshal(data, &config); err == nil && len(config.CurrentContext) > 0 {
+			return config.CurrentContext
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// useKubeconfig points KUBECONFIG at a file with the current-context
+// set to context, restoring it after the test
+func useKubeconfig(t *testing.T, path, context string) {
+	t.Helper()
+
+	previous, set := os.LookupEnv("KUBECONFIG")
+	t.Cleanup(func() {
+		if set {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	})
+
+	if err := ioutil.WriteFile(path, []byte("apiVersion: v1\nkind: Config\ncurrent-context: "+context+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("KUBECONFIG", path)
+}
+
+func Test_GetCapabilitiesCached_QueriesOncePerContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	useKubeconfig(t, kubeconfig, "kind-a")
+
+	mock := useMockRunner(t,
+		execute.ExecResult{Stdout: "apps/v1\n"},
+		execute.ExecResult{Stdout: "apps/v1\nnetworking.k8s.io/v1\n"},
+	)
+
+	for i := 0; i < 2; i++ {
+		caps, err := GetCapabilitiesCached()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !caps["apps/v1"] || caps["networking.k8s.io/v1"] {
+			t.Errorf("want the capabilities of kind-a, got: %v", caps)
+		}
+	}
+
+	useKubeconfig(t, kubeconfig, "kind-b")
+	caps, err := GetCapabilitiesCached()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !caps["networking.k8s.io/v1"] {
+		t.Errorf("want the capabilities of kind-b after the context changed, got: %v", caps)
+	}
+
+	// The context is read from the kubeconfig, so a cached lookup runs
+	// no commands
+	want := []string{
+		"kubectl api-versions",
+		"kubectl api-versions",
+	}
+	if got := mock.commands(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want commands: %v, got: %v", want, got)
+	}
+}
+
+func Test_GetCapabilitiesCached_InvalidatedByKubeconfig(t *testing.T) {
+	previous, set := os.LookupEnv("KUBECONFIG")
+	t.Cleanup(func() {
+		if set {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	})
+
+	mock := useMockRunner(t,
+		execute.ExecResult{Stdout: "apps/v1\n"},
+		execute.ExecResult{Stdout: "apps/v1\n"},
+	)
+
+	os.Setenv("KUBECONFIG", "/tmp/one.yaml")
+	if _, err := GetCapabilitiesCached(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	os.Setenv("KUBECONFIG", "/tmp/two.yaml")
+	if _, err := GetCapabilitiesCached(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := strings.Count(strings.Join(mock.commands(), ","), "api-versions"); got != 2 {
+		t.Errorf("want api-versions called for each kubeconfig, got %d calls", got)
+	}
+}
+
+func Test_GetCapabilitiesCached_SkipsEmptyResult(t *testing.T) {
+	mock := useMockRunner(t,
+		execute.ExecResult{ExitCode: 1, Stderr: "connection refused"},
+		execute.ExecResult{Stdout: "apps/v1\n"},
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := GetCapabilitiesCached(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if got := strings.Count(strings.Join(mock.commands(), ","), "api-versions"); got != 2 {
+		t.Errorf("want a failed discovery to be retried, got %d api-versions calls", got)
+	}
+}
+
+func Test_currentContext_FirstFileWins(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	for path, data := range map[string]string{
+		empty:  "apiVersion: v1\nkind: Config\n",
+		first:  "current-context: kind-a\n",
+		second: "current-context: kind-b\n",
+	} {
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := strings.Join([]string{filepath.Join(dir, "missing"), empty, first, second}, string(os.PathListSeparator))
+	if got := currentContext(list); got != "kind-a" {
+		t.Errorf("want kind-a from the first file to set it, got: %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cloud is the provider which a cluster's nodes run on
+type Cloud string
+
+const (
+	// CloudAuto detects the provider from the cluster's nodes
+	CloudAuto Cloud = "auto"
+	// CloudAWS is Amazon Web Services, such as EKS
+	CloudAWS Cloud = "aws"
+	// CloudGCP is Google Cloud Platform, such as GKE
+	CloudGCP Cloud = "gcp"
+	// CloudAzure is Microsoft Azure, such as AKS
+	CloudAzure Cloud = "azure"
+)
+
+// ParseCloud validates the value of a --cloud flag
+func ParseCloud(value string) (Cloud, error) {
+	switch cloud := Cloud(strings.ToLower(strings.TrimSpace(value))); cloud {
+	case CloudAuto, CloudAWS, CloudGCP, CloudAzure:
+		return cloud, nil
+	}
+	return "", fmt.Errorf("cloud must be one of: auto, aws, gcp, azure, but got: %q", value)
+}
+
+// providerIDPrefixes are the schemes of a node's spec.providerID which
+// the cloud controller managers set
+var providerIDPrefixes = map[string]Cloud{
+	"aws://":   CloudAWS,
+	"gce://":   CloudGCP,
+	"azure://": CloudAzure,
+}
+
+// nodeLabelPrefixes are the prefixes of labels which the managed
+// Kubernetes services of each provider set on their nodes
+var nodeLabelPrefixes = map[string]Cloud{
+	"eks.amazonaws.com/":    CloudAWS,
+	"cloud.google.com/gke-": CloudGCP,
+	"kubernetes.azure.com/": CloudAzure,
+}
+
+type nodeList struct {
+	Items []struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			ProviderID string `json:"providerID"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// DetectCloud reads the cluster's nodes to find the provider they run
+// on, from their providerID or failing that the labels set by EKS, GKE
+// and AKS. Regions are not used, as those of other providers look alike.
+// An empty Cloud is returned when it is not recognised, such as for a
+// cluster on another provider, bare-metal or a laptop.
+func DetectCloud() (Cloud, error) {
+	args := []string{"get", "nodes", "-o", "json"}
+	res, err := KubectlTask(args...)
+	if err != nil {
+		return "", err
+	}
+	if err := ResultError(res, args...); err != nil {
+		return "", err
+	}
+
+	nodes := nodeList{}
+	if err := json.Unmarshal([]byte(res.Stdout), &nodes); err != nil {
+		return "", fmt.Errorf("unable to parse the output of kubectl get nodes: %w", err)
+	}
+
+	for _, node := range nodes.Items {
+		for prefix, cloud := range providerIDPrefixes {
+			if strings.HasPrefix(node.Spec.ProviderID, prefix) {
+				return cloud, nil
+			}
+		}
+	}
+
+	for _, node := range nodes.Items {
+		for label := range node.Metadata.Labels {
+			for prefix, cloud := range nodeLabelPrefixes {
+				if strings.HasPrefix(label, prefix) {
+					return cloud, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
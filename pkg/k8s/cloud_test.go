@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_DetectCloud(t *testing.T) {
+	cases := []struct {
+		name  string
+		nodes string
+		want  Cloud
+	}{
+		{
+			name:  "eks provider ID",
+			nodes: `{"items":[{"metadata":{"labels":{}},"spec":{"providerID":"aws:///us-east-1a/i-0abc123"}}]}`,
+			want:  CloudAWS,
+		},
+		{
+			name:  "gke provider ID",
+			nodes: `{"items":[{"metadata":{"labels":{}},"spec":{"providerID":"gce://project/us-central1-a/gke-pool-1"}}]}`,
+			want:  CloudGCP,
+		},
+		{
+			name:  "aks labels without a provider ID",
+			nodes: `{"items":[{"metadata":{"labels":{"kubernetes.azure.com/cluster":"MC_rg_aks_eastus","topology.kubernetes.io/region":"eastus"}},"spec":{}}]}`,
+			want:  CloudAzure,
+		},
+		{
+			name:  "eks labels without a provider ID",
+			nodes: `{"items":[{"metadata":{"labels":{"eks.amazonaws.com/nodegroup":"workers","topology.kubernetes.io/region":"us-east-1"}},"spec":{}}]}`,
+			want:  CloudAWS,
+		},
+		{
+			name:  "gke labels without a provider ID",
+			nodes: `{"items":[{"metadata":{"labels":{"cloud.google.com/gke-nodepool":"default-pool"}},"spec":{}}]}`,
+			want:  CloudGCP,
+		},
+		{
+			name:  "aws region without labels is not guessed",
+			nodes: `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"m5.large","topology.kubernetes.io/region":"us-east-1"}},"spec":{}}]}`,
+			want:  "",
+		},
+		{
+			name:  "gcp region without labels is not guessed",
+			nodes: `{"items":[{"metadata":{"labels":{"topology.kubernetes.io/region":"europe-west1"}},"spec":{}}]}`,
+			want:  "",
+		},
+		{
+			name:  "aws instance type without a region",
+			nodes: `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"m5.large"}},"spec":{}}]}`,
+			want:  "",
+		},
+		{
+			name:  "digitalocean region",
+			nodes: `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"s-2vcpu-4gb","topology.kubernetes.io/region":"nyc1"}},"spec":{"providerID":"digitalocean://123"}}]}`,
+			want:  "",
+		},
+		{
+			name:  "linode instance type and region",
+			nodes: `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"g6-standard-2","topology.kubernetes.io/region":"eu-west"}},"spec":{"providerID":"linode://123"}}]}`,
+			want:  "",
+		},
+		{
+			name:  "region label of a home lab",
+			nodes: `{"items":[{"metadata":{"labels":{"topology.kubernetes.io/region":"home"}},"spec":{}}]}`,
+			want:  "",
+		},
+		{
+			name:  "london region of another provider",
+			nodes: `{"items":[{"metadata":{"labels":{"topology.kubernetes.io/region":"lon1"}},"spec":{}}]}`,
+			want:  "",
+		},
+		{
+			name:  "k3s on a laptop",
+			nodes: `{"items":[{"metadata":{"labels":{"node.kubernetes.io/instance-type":"k3s"}},"spec":{"providerID":"k3s://laptop"}}]}`,
+			want:  "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := useMockRunner(t, execute.ExecResult{Stdout: tc.nodes})
+
+			got, err := DetectCloud()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, got)
+			}
+			if commands := mock.commands(); len(commands) != 1 || commands[0] != "kubectl get nodes -o json" {
+				t.Errorf("want the nodes to be read, got: %v", commands)
+			}
+		})
+	}
+}
+
+func Test_ParseCloud(t *testing.T) {
+	if got, err := ParseCloud(" AWS "); err != nil || got != CloudAWS {
+		t.Errorf("want aws, got: %q, %v", got, err)
+	}
+	if _, err := ParseCloud("digitalocean"); err == nil {
+		t.Errorf("want an unknown cloud to be rejected")
+	}
+}
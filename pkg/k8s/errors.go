@@ -0,0 +1,46 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// KubectlError is a kubectl command which exited with a non-zero code,
+// callers can find it with errors.As to inspect the output
+type KubectlError struct {
+	Args     []string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// Error gives the command, with any secrets redacted, and its stderr
+func (e *KubectlError) Error() string {
+	return fmt.Sprintf("kubectl %s exit code %d, stderr: %s",
+		strings.Join(RedactArgs(e.Args), " "), e.ExitCode, strings.TrimSpace(e.Stderr))
+}
+
+// NotFound reports whether kubectl failed because a resource, or the
+// API for its kind, was not found
+func (e *KubectlError) NotFound() bool {
+	return strings.Contains(e.Stderr, "NotFound") || strings.Contains(e.Stderr, "not found")
+}
+
+// ResultError gives a *KubectlError for the result of running kubectl
+// with args when it exited with a non-zero code, otherwise nil
+func ResultError(res execute.ExecResult, args ...string) error {
+	if res.ExitCode == 0 {
+		return nil
+	}
+	return &KubectlError{
+		Args:     args,
+		ExitCode: res.ExitCode,
+		Stdout:   res.Stdout,
+		Stderr:   res.Stderr,
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_ResultError_NilOnSuccess(t *testing.T) {
+	if err := ResultError(execute.ExecResult{ExitCode: 0, Stderr: "warning"}, "get", "pods"); err != nil {
+		t.Errorf("want no error for exit code 0, got: %s", err)
+	}
+}
+
+func Test_ResultError_AsKubectlError(t *testing.T) {
+	res := execute.ExecResult{ExitCode: 1, Stdout: "partial", Stderr: "Error from server (NotFound): namespaces \"images\" not found\n"}
+	err := fmt.Errorf("wrapped: %w", ResultError(res, "get", "ns", "images"))
+
+	var kubectlErr *KubectlError
+	if !errors.As(err, &kubectlErr) {
+		t.Fatalf("want a *KubectlError, got: %T", err)
+	}
+	if kubectlErr.ExitCode != 1 || kubectlErr.Stdout != "partial" {
+		t.Errorf("want exit code 1 and stdout, got: %+v", kubectlErr)
+	}
+	if !kubectlErr.NotFound() {
+		t.Errorf("want NotFound for stderr: %q", kubectlErr.Stderr)
+	}
+
+	want := `kubectl get ns images exit code 1, stderr: Error from server (NotFound): namespaces "images" not found`
+	if kubectlErr.Error() != want {
+		t.Errorf("want: %q, got: %q", want, kubectlErr.Error())
+	}
+}
+
+func Test_KubectlError_RedactsArgs(t *testing.T) {
+	err := ResultError(execute.ExecResult{ExitCode: 1, Stderr: "exists"},
+		"create", "secret", "generic", "auth", "--from-literal=password=hunter2")
+
+	want := "kubectl create secret generic auth --from-literal=password=REDACTED exit code 1, stderr: exists"
+	if err.Error() != want {
+		t.Errorf("want: %q, got: %q", want, err.Error())
+	}
+}
+
+func Test_Kubectl_ReturnsKubectlError(t *testing.T) {
+	useMockRunner(t, execute.ExecResult{ExitCode: 2, Stderr: "forbidden"})
+
+	err := Kubectl("apply", "-f", "app.yaml")
+	var kubectlErr *KubectlError
+	if !errors.As(err, &kubectlErr) || kubectlErr.ExitCode != 2 || kubectlErr.Stderr != "forbidden" {
+		t.Errorf("want a *KubectlError with exit code 2, got: %v", err)
+	}
+}
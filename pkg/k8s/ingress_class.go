@@ -0,0 +1,34 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"errors"
+	"strings"
+)
+
+// ingressClassAliases maps the names which the nginx and traefik
+// controllers, their charts and arkade apps go by to the class name
+// which arkade sets annotations for
+var ingressClassAliases = map[string]string{
+	"nginx-ingress":   "nginx",
+	"ingress-nginx":   "nginx",
+	"traefik2":        "traefik",
+	"traefik-ingress": "traefik",
+}
+
+// NormalizeIngressClass lowercases and trims the class, then maps known
+// aliases to their canonical name, such as nginx-ingress to nginx.
+// Other classes are returned as they are, after lowercasing.
+func NormalizeIngressClass(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if len(class) == 0 {
+		return "", errors.New("ingress class must be set")
+	}
+
+	if canonical, ok := ingressClassAliases[class]; ok {
+		return canonical, nil
+	}
+	return class, nil
+}
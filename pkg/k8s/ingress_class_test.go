@@ -0,0 +1,43 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import "testing"
+
+func Test_NormalizeIngressClass(t *testing.T) {
+	cases := []struct {
+		class   string
+		want    string
+		wantErr bool
+	}{
+		{class: "nginx", want: "nginx"},
+		{class: " Nginx ", want: "nginx"},
+		{class: "nginx-ingress", want: "nginx"},
+		{class: "ingress-nginx", want: "nginx"},
+		{class: "traefik", want: "traefik"},
+		{class: "Traefik2", want: "traefik"},
+		{class: "traefik-ingress", want: "traefik"},
+		{class: "haproxy", want: "haproxy"},
+		{class: "", wantErr: true},
+		{class: "   ", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.class, func(t *testing.T) {
+			got, err := NormalizeIngressClass(tc.class)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("want an error, got: %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
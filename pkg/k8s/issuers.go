@@ -0,0 +1,114 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ManagedBySelector matches the resources which arkade created
+const ManagedBySelector = "app.kubernetes.io/managed-by=arkade"
+
+// ProtectedLabel is set to "true" on resources which arkade only deletes
+// when forced to
+const ProtectedLabel = "arkade.alexellis.io/protected"
+
+// issuerAnnotation is how an Ingress asks cert-manager for a certificate
+// from an Issuer in its namespace
+const issuerAnnotation = "cert-manager.io/issuer"
+
+type objectList struct {
+	Items []struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			IssuerRef struct {
+				Name string `json:"name"`
+				Kind string `json:"kind"`
+			} `json:"issuerRef"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// OrphanedIssuers gives the names of the Issuers in the namespace which
+// arkade created and which no Ingress or Certificate refers to. Those
+// labelled with ProtectedLabel are given separately, unless force is set.
+func OrphanedIssuers(namespace string, force bool) ([]string, []string, error) {
+	issuers, err := getObjects("get", "issuers.cert-manager.io", "-n", namespace, "-l", ManagedBySelector, "-o", "json")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(issuers.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	referenced := map[string]bool{}
+
+	ingresses, err := getObjects("get", "ingresses", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, ingress := range ingresses.Items {
+		if name, ok := ingress.Metadata.Annotations[issuerAnnotation]; ok {
+			referenced[name] = true
+		}
+	}
+
+	// An HTTPProxy has no annotation, its Certificate names the Issuer
+	certificates, err := getObjects("get", "certificates.cert-manager.io", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, certificate := range certificates.Items {
+		ref := certificate.Spec.IssuerRef
+		if ref.Kind == "" || ref.Kind == "Issuer" {
+			referenced[ref.Name] = true
+		}
+	}
+
+	var orphaned, protected []string
+	for _, issuer := range issuers.Items {
+		if referenced[issuer.Metadata.Name] {
+			continue
+		}
+		if issuer.Metadata.Labels[ProtectedLabel] == "true" && !force {
+			protected = append(protected, issuer.Metadata.Name)
+			continue
+		}
+		orphaned = append(orphaned, issuer.Metadata.Name)
+	}
+	sort.Strings(orphaned)
+	sort.Strings(protected)
+	return orphaned, protected, nil
+}
+
+// DeleteIssuers deletes the named Issuers from the namespace
+func DeleteIssuers(namespace string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return Kubectl(append([]string{"delete", "issuers.cert-manager.io", "-n", namespace}, names...)...)
+}
+
+func getObjects(args ...string) (objectList, error) {
+	list := objectList{}
+
+	res, err := KubectlTask(args...)
+	if err != nil {
+		return list, err
+	}
+	if err := ResultError(res, args...); err != nil {
+		return list, err
+	}
+
+	if err := json.Unmarshal([]byte(res.Stdout), &list); err != nil {
+		return list, fmt.Errorf("unable to parse the output of kubectl %s: %w", args[1], err)
+	}
+	return list, nil
+}
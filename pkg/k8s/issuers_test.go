@@ -0,0 +1,107 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_OrphanedIssuers_DeletesOnlyUnreferenced(t *testing.T) {
+	issuers := `{"items":[
+		{"metadata":{"name":"letsencrypt-prod-issuer"}},
+		{"metadata":{"name":"old-domain-issuer"}},
+		{"metadata":{"name":"httpproxy-issuer"}}]}`
+	ingresses := `{"items":[
+		{"metadata":{"name":"docker-registry","annotations":{"cert-manager.io/issuer":"letsencrypt-prod-issuer"}}},
+		{"metadata":{"name":"unrelated"}}]}`
+	certificates := `{"items":[
+		{"metadata":{"name":"registry-cert"},"spec":{"issuerRef":{"name":"httpproxy-issuer","kind":"Issuer"}}},
+		{"metadata":{"name":"cluster-cert"},"spec":{"issuerRef":{"name":"old-domain-issuer","kind":"ClusterIssuer"}}}]}`
+
+	mock := useMockRunner(t,
+		execute.ExecResult{Stdout: issuers},
+		execute.ExecResult{Stdout: ingresses},
+		execute.ExecResult{Stdout: certificates},
+	)
+
+	orphaned, protected, err := OrphanedIssuers("registry", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(protected) != 0 {
+		t.Errorf("want no protected issuers, got: %v", protected)
+	}
+	if strings.Join(orphaned, ",") != "old-domain-issuer" {
+		t.Fatalf("want only old-domain-issuer, got: %v", orphaned)
+	}
+
+	if err := DeleteIssuers("registry", orphaned); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	commands := mock.commands()
+	if !strings.Contains(commands[0], "-l "+ManagedBySelector) {
+		t.Errorf("want issuers selected by the managed-by label, got: %s", commands[0])
+	}
+
+	want := "kubectl delete issuers.cert-manager.io -n registry old-domain-issuer"
+	if got := commands[len(commands)-1]; got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func Test_OrphanedIssuers_NoneLabelled(t *testing.T) {
+	mock := useMockRunner(t, execute.ExecResult{Stdout: `{"items":[]}`})
+
+	orphaned, _, err := OrphanedIssuers("registry", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(orphaned) != 0 {
+		t.Errorf("want no issuers, got: %v", orphaned)
+	}
+
+	if err := DeleteIssuers("registry", orphaned); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := mock.commands(); len(got) != 1 {
+		t.Errorf("want only the issuers to be listed, got: %v", got)
+	}
+}
+
+func Test_OrphanedIssuers_KeepsProtectedUnlessForced(t *testing.T) {
+	issuers := `{"items":[
+		{"metadata":{"name":"old-domain-issuer"}},
+		{"metadata":{"name":"shared-issuer","labels":{"app.kubernetes.io/managed-by":"arkade","arkade.alexellis.io/protected":"true"}}}]}`
+
+	for _, force := range []bool{false, true} {
+		t.Run(fmt.Sprintf("force=%v", force), func(t *testing.T) {
+			useMockRunner(t,
+				execute.ExecResult{Stdout: issuers},
+				execute.ExecResult{Stdout: `{"items":[]}`},
+				execute.ExecResult{Stdout: `{"items":[]}`},
+			)
+
+			orphaned, protected, err := OrphanedIssuers("registry", force)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			wantOrphaned, wantProtected := "old-domain-issuer", "shared-issuer"
+			if force {
+				wantOrphaned, wantProtected = "old-domain-issuer,shared-issuer", ""
+			}
+			if got := strings.Join(orphaned, ","); got != wantOrphaned {
+				t.Errorf("want orphaned: %q, got: %q", wantOrphaned, got)
+			}
+			if got := strings.Join(protected, ","); got != wantProtected {
+				t.Errorf("want protected: %q, got: %q", wantProtected, got)
+			}
+		})
+	}
+}
@@ -5,8 +5,12 @@ package k8s
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"strings"
 
 	"github.com/alexellis/arkade/pkg/types"
@@ -46,6 +50,127 @@ func GetCapabilities() (Capabilities, error) {
 	return caps, nil
 }
 
+var kubeContext string
+
+// SetKubeContext makes kubectl target the named context from the
+// kubeconfig, an empty name uses its current context
+func SetKubeContext(name string) {
+	kubeContext = name
+}
+
+// KubeContext gives the context set with SetKubeContext
+func KubeContext() string {
+	return kubeContext
+}
+
+// APIServer addresses a kube API server by its URL, for clusters which
+// have no context in a kubeconfig such as throwaway clusters in CI
+type APIServer struct {
+	URL                   string
+	Token                 string
+	InsecureSkipTLSVerify bool
+}
+
+var apiServer APIServer
+
+// SetAPIServer makes kubectl and helm connect to the server, without
+// reading a kubeconfig, an empty URL goes back to using the kubeconfig
+func SetAPIServer(server APIServer) error {
+	if len(server.URL) > 0 {
+		u, err := url.Parse(server.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			return fmt.Errorf("the API server must be an http or https URL such as https://127.0.0.1:6443, got: %q", server.URL)
+		}
+		if u.Scheme == "http" && len(server.Token) > 0 {
+			return errors.New("a token can only be sent to an https API server")
+		}
+	}
+
+	apiServer = server
+	ResetCapabilitiesCache()
+	return nil
+}
+
+// GetAPIServer gives the server set with SetAPIServer
+func GetAPIServer() APIServer {
+	return apiServer
+}
+
+// apiServerContext names the cluster, user and context in the
+// kubeconfig written by WriteAPIServerKubeconfig
+const apiServerContext = "arkade"
+
+// WriteAPIServerKubeconfig writes a kubeconfig for the server set with
+// SetAPIServer to a temporary file which only the current user can read,
+// so that the token is not passed on the command line where other users
+// could see it. Call remove once the command has run.
+func WriteAPIServerKubeconfig() (path string, remove func(), err error) {
+	cluster := map[string]interface{}{"server": apiServer.URL}
+	if apiServer.InsecureSkipTLSVerify {
+		cluster["insecure-skip-tls-verify"] = true
+	}
+	user := map[string]interface{}{}
+	if len(apiServer.Token) > 0 {
+		user["token"] = apiServer.Token
+	}
+
+	data, err := MarshalYAML(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Config",
+		"clusters":   []interface{}{map[string]interface{}{"name": apiServerContext, "cluster": cluster}},
+		"users":      []interface{}{map[string]interface{}{"name": apiServerContext, "user": user}},
+		"contexts": []interface{}{map[string]interface{}{"name": apiServerContext, "context": map[string]interface{}{
+			"cluster": apiServerContext,
+			"user":    apiServerContext,
+		}}},
+		"current-context": apiServerContext,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	file, err := ioutil.TempFile("", "arkade-kubeconfig-*.yaml")
+	if err != nil {
+		return "", nil, err
+	}
+	remove = func() {
+		os.Remove(file.Name())
+	}
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		remove()
+		return "", nil, err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		remove()
+		return "", nil, err
+	}
+	if err := file.Close(); err != nil {
+		remove()
+		return "", nil, err
+	}
+	return file.Name(), remove, nil
+}
+
+// runKubectl runs the kubectl task with --context when one has been set,
+// or with a kubeconfig for the API server set with SetAPIServer
+func runKubectl(task execute.ExecTask) (execute.ExecResult, error) {
+	if len(apiServer.URL) > 0 {
+		kubeconfig, remove, err := WriteAPIServerKubeconfig()
+		if err != nil {
+			return execute.ExecResult{}, fmt.Errorf("unable to write a kubeconfig for %s: %w", apiServer.URL, err)
+		}
+		defer remove()
+
+		task.Args = append([]string{"--kubeconfig", kubeconfig}, task.Args...)
+	} else if len(kubeContext) > 0 {
+		task.Args = append([]string{"--context", kubeContext}, task.Args...)
+	}
+	return Run(task)
+}
+
 func KubectlTaskStdin(reader io.Reader, parts ...string) (execute.ExecResult, error) {
 	task := execute.ExecTask{
 		Command:     "kubectl",
@@ -54,7 +179,7 @@ func KubectlTaskStdin(reader io.Reader, parts ...string) (execute.ExecResult, er
 		Stdin:       reader,
 	}
 
-	res, err := task.Execute()
+	res, err := runKubectl(task)
 
 	return res, err
 }
@@ -65,7 +190,7 @@ func KubectlTask(parts ...string) (execute.ExecResult, error) {
 		StreamStdio: false,
 	}
 
-	res, err := task.Execute()
+	res, err := runKubectl(task)
 
 	return res, err
 }
@@ -77,18 +202,38 @@ func Kubectl(parts ...string) error {
 		StreamStdio: true,
 	}
 
-	res, err := task.Execute()
+	res, err := runKubectl(task)
 
 	if err != nil {
 		return err
 	}
 
-	if res.ExitCode != 0 {
-		return fmt.Errorf("kubectl exit code %d, stderr: %s",
-			res.ExitCode,
-			res.Stderr)
+	return ResultError(res, parts...)
+}
+
+// NamespaceExists reports whether the namespace exists
+func NamespaceExists(name string) (bool, error) {
+	return ResourceExists("namespace", name, "")
+}
+
+// ResourceExists reports whether a resource exists, namespace is left
+// empty for cluster-scoped kinds. Errors other than the resource not
+// being found are returned.
+func ResourceExists(kind, name, namespace string) (bool, error) {
+	args := []string{"get", kind, name}
+	if len(namespace) > 0 {
+		args = append(args, "-n", namespace)
 	}
-	return nil
+	args = append(args, "--ignore-not-found", "-o", "name")
+	res, err := KubectlTask(args...)
+	if err != nil {
+		return false, err
+	}
+
+	if err := ResultError(res, args...); err != nil {
+		return false, fmt.Errorf("unable to get %s %s: %w", kind, name, err)
+	}
+	return len(strings.TrimSpace(res.Stdout)) > 0, nil
 }
 
 func CreateNamespace(namespace string) error {
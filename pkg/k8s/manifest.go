@@ -0,0 +1,174 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Object identifies a single resource within a rendered manifest
+type Object struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+}
+
+// Group returns the API group of the object, or an empty string
+// for the core group
+func (o Object) Group() string {
+	if i := strings.Index(o.APIVersion, "/"); i > -1 {
+		return o.APIVersion[:i]
+	}
+	return ""
+}
+
+type objectHeader struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// SplitManifest splits a multi-document YAML manifest on its "---"
+// separators, dropping any documents which are empty
+func SplitManifest(manifest []byte) [][]byte {
+	var docs [][]byte
+	var current bytes.Buffer
+
+	flush := func() {
+		if len(bytes.TrimSpace(current.Bytes())) > 0 {
+			docs = append(docs, append([]byte{}, current.Bytes()...))
+		}
+		current.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \t") == "---" {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	flush()
+
+	return docs
+}
+
+// ParseObjects returns the apiVersion, kind, name and namespace of
+// each document in a multi-document YAML manifest
+func ParseObjects(manifest []byte) ([]Object, error) {
+	var objects []Object
+
+	for i, doc := range SplitManifest(manifest) {
+		header := objectHeader{}
+		if err := yaml.Unmarshal(doc, &header); err != nil {
+			return nil, fmt.Errorf("unable to parse document %d: %w", i+1, err)
+		}
+
+		objects = append(objects, Object{
+			APIVersion: header.APIVersion,
+			Kind:       header.Kind,
+			Name:       header.Metadata.Name,
+			Namespace:  header.Metadata.Namespace,
+		})
+	}
+
+	return objects, nil
+}
+
+// applyPriority orders kinds so that each resource is applied after
+// those it depends on, kinds not listed are applied last
+var applyPriority = map[string]int{
+	"CustomResourceDefinition": 0,
+	"Namespace":                1,
+	"ServiceAccount":           2,
+	"Secret":                   3,
+	"ConfigMap":                3,
+	"ClusterRole":              4,
+	"ClusterRoleBinding":       5,
+	"Role":                     4,
+	"RoleBinding":              5,
+	"ClusterIssuer":            6,
+	"Issuer":                   6,
+	"Certificate":              7,
+	"Service":                  8,
+	"Deployment":               9,
+	"Ingress":                  10,
+}
+
+// SortManifest reorders the documents of a manifest so that resources
+// are applied after those they depend on, i.e. an Issuer before the
+// Ingress which references it. Documents of the same priority keep
+// their original order. The objects are returned in the new order.
+func SortManifest(manifest []byte) ([]byte, []Object, error) {
+	docs := SplitManifest(manifest)
+	objects, err := ParseObjects(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexes := make([]int, len(docs))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	priority := func(kind string) int {
+		if p, ok := applyPriority[kind]; ok {
+			return p
+		}
+		return len(applyPriority)
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return priority(objects[indexes[a]].Kind) < priority(objects[indexes[b]].Kind)
+	})
+
+	var sorted bytes.Buffer
+	sortedObjects := make([]Object, 0, len(objects))
+	for i, index := range indexes {
+		if i > 0 {
+			sorted.WriteString("---\n")
+		}
+		sorted.Write(docs[index])
+		sortedObjects = append(sortedObjects, objects[index])
+	}
+
+	return sorted.Bytes(), sortedObjects, nil
+}
+
+// MarshalYAML marshals v with a stable output, for golden tests. Struct
+// fields are marshaled via their json tags, then the keys of every
+// mapping, from structs and maps alike, are sorted.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// MarshalManifest marshals each of docs with MarshalYAML, separated by
+// "---" so that the result can be read by SplitManifest
+func MarshalManifest(docs ...interface{}) ([]byte, error) {
+	var manifest bytes.Buffer
+	for i, doc := range docs {
+		out, err := MarshalYAML(doc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal document %d: %w", i+1, err)
+		}
+		if i > 0 {
+			manifest.WriteString("---\n")
+		}
+		manifest.Write(out)
+	}
+	return manifest.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"bytes"
+	"testing"
+)
+
+type goldenMetadata struct {
+	Name        string            `json:"name"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type goldenObject struct {
+	Kind     string         `json:"kind"`
+	Metadata goldenMetadata `json:"metadata"`
+}
+
+func Test_MarshalManifest_IsDeterministic(t *testing.T) {
+	annotations := map[string]string{}
+	for _, key := range []string{"zeta", "alpha", "mu", "beta", "omega", "gamma", "kappa", "delta"} {
+		annotations["example.com/"+key] = key
+	}
+	docs := []interface{}{
+		goldenObject{Kind: "Ingress", Metadata: goldenMetadata{Name: "docker-registry", Annotations: annotations}},
+		map[string]interface{}{"kind": "Issuer", "spec": map[string]string{"server": "acme", "email": "admin@example.com"}},
+	}
+
+	first, err := MarshalManifest(docs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := MarshalManifest(docs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("want byte-identical output, got:\n%s\nthen:\n%s", first, again)
+		}
+	}
+
+	want := `kind: Ingress
+metadata:
+  annotations:
+    example.com/alpha: alpha
+    example.com/beta: beta
+    example.com/delta: delta
+    example.com/gamma: gamma
+    example.com/kappa: kappa
+    example.com/mu: mu
+    example.com/omega: omega
+    example.com/zeta: zeta
+  name: docker-registry
+---
+kind: Issuer
+spec:
+  email: admin@example.com
+  server: acme
+`
+	if string(first) != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, first)
+	}
+
+	if docs := SplitManifest(first); len(docs) != 2 {
+		t.Errorf("want 2 documents, got: %d", len(docs))
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// Runner executes the commands issued by the kubectl and helm helpers.
+// Tests can swap it with SetRunner to capture commands and return
+// canned results.
+type Runner interface {
+	Run(task execute.ExecTask) (execute.ExecResult, error)
+}
+
+// ExecRunner runs commands on the local machine
+type ExecRunner struct{}
+
+// Run executes the task
+func (ExecRunner) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	return task.Execute()
+}
+
+var runner Runner = ExecRunner{}
+
+// SetRunner replaces the Runner used for all commands and returns the
+// previous one, so that it can be restored. Cached capabilities are
+// discarded, since they came from the previous Runner.
+func SetRunner(r Runner) Runner {
+	previous := runner
+	runner = r
+	ResetCapabilitiesCache()
+	return previous
+}
+
+// Run executes the task with the current Runner
+func Run(task execute.ExecTask) (execute.ExecResult, error) {
+	return runner.Run(task)
+}
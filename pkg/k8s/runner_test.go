@@ -0,0 +1,266 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// mockRunner records each task and returns the next canned result
+type mockRunner struct {
+	tasks   []execute.ExecTask
+	results []execute.ExecResult
+}
+
+func (m *mockRunner) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	m.tasks = append(m.tasks, task)
+	if len(m.results) == 0 {
+		return execute.ExecResult{}, nil
+	}
+	res := m.results[0]
+	m.results = m.results[1:]
+	return res, nil
+}
+
+func (m *mockRunner) commands() []string {
+	var commands []string
+	for _, task := range m.tasks {
+		commands = append(commands, strings.TrimSpace(task.Command+" "+strings.Join(task.Args, " ")))
+	}
+	return commands
+}
+
+func useMockRunner(t *testing.T, results ...execute.ExecResult) *mockRunner {
+	mock := &mockRunner{results: results}
+	previous := SetRunner(mock)
+	t.Cleanup(func() {
+		SetRunner(previous)
+	})
+	return mock
+}
+
+func Test_KubectlTask_UsesRunner(t *testing.T) {
+	mock := useMockRunner(t, execute.ExecResult{Stdout: "pod/one\n"})
+
+	res, err := KubectlTask("get", "pods", "-o", "name")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if res.Stdout != "pod/one\n" {
+		t.Errorf("want the runner's result, got: %q", res.Stdout)
+	}
+
+	want := []string{"kubectl get pods -o name"}
+	if got := mock.commands(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want commands: %v, got: %v", want, got)
+	}
+}
+
+func Test_GetCapabilities_UsesRunner(t *testing.T) {
+	mock := useMockRunner(t, execute.ExecResult{Stdout: "apps/v1\nnetworking.k8s.io/v1\n"})
+
+	caps, err := GetCapabilities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !caps["networking.k8s.io/v1"] || !caps["apps/v1"] {
+		t.Errorf("want capabilities from the runner's output, got: %v", caps)
+	}
+
+	if got := mock.commands(); len(got) != 1 || got[0] != "kubectl api-versions" {
+		t.Errorf("want a single api-versions call, got: %v", got)
+	}
+}
+
+func Test_Kubectl_ReturnsErrorOnExitCode(t *testing.T) {
+	mock := useMockRunner(t, execute.ExecResult{ExitCode: 1, Stderr: "forbidden"})
+
+	err := Kubectl("apply", "-f", "app.yaml")
+	if err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("want an error with the stderr, got: %v", err)
+	}
+
+	if !mock.tasks[0].StreamStdio {
+		t.Errorf("want Kubectl to stream stdio")
+	}
+}
+
+func Test_ResourceExists(t *testing.T) {
+	cases := []struct {
+		name        string
+		kind        string
+		namespace   string
+		result      execute.ExecResult
+		want        bool
+		wantErr     bool
+		wantCommand string
+	}{
+		{
+			name:        "existing secret",
+			kind:        "secret",
+			namespace:   "registry",
+			result:      execute.ExecResult{Stdout: "secret/registry\n"},
+			want:        true,
+			wantCommand: "kubectl get secret registry -n registry --ignore-not-found -o name",
+		},
+		{
+			name:        "missing secret",
+			kind:        "secret",
+			namespace:   "registry",
+			result:      execute.ExecResult{},
+			want:        false,
+			wantCommand: "kubectl get secret registry -n registry --ignore-not-found -o name",
+		},
+		{
+			name:        "cluster-scoped namespace",
+			kind:        "namespace",
+			result:      execute.ExecResult{Stdout: "namespace/registry\n"},
+			want:        true,
+			wantCommand: "kubectl get namespace registry --ignore-not-found -o name",
+		},
+		{
+			name:        "unreachable",
+			kind:        "namespace",
+			result:      execute.ExecResult{ExitCode: 1, Stderr: "The connection to the server localhost:8080 was refused"},
+			wantErr:     true,
+			wantCommand: "kubectl get namespace registry --ignore-not-found -o name",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := useMockRunner(t, tc.result)
+
+			got, err := ResourceExists(tc.kind, "registry", tc.namespace)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("want error: %t, got: %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+
+			if commands := mock.commands(); len(commands) != 1 || commands[0] != tc.wantCommand {
+				t.Errorf("want %q, got: %v", tc.wantCommand, commands)
+			}
+		})
+	}
+}
+
+func Test_KubectlTask_PassesKubeContext(t *testing.T) {
+	mock := useMockRunner(t, execute.ExecResult{}, execute.ExecResult{Stdout: "apps/v1\n"})
+	SetKubeContext("staging")
+	t.Cleanup(func() {
+		SetKubeContext("")
+	})
+
+	if _, err := KubectlTask("get", "pods"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := GetCapabilitiesCached(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"kubectl --context staging get pods", "kubectl --context staging api-versions"}
+	if got := mock.commands(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want commands: %v, got: %v", want, got)
+	}
+}
+
+// kubeconfigRunner records the kubeconfig passed to each task while it
+// runs, since it is removed afterwards
+type kubeconfigRunner struct {
+	mockRunner
+	kubeconfigs []string
+	modes       []os.FileMode
+}
+
+func (k *kubeconfigRunner) Run(task execute.ExecTask) (execute.ExecResult, error) {
+	if len(task.Args) > 1 && task.Args[0] == "--kubeconfig" {
+		if info, err := os.Stat(task.Args[1]); err == nil {
+			k.modes = append(k.modes, info.Mode().Perm())
+		}
+		data, _ := ioutil.ReadFile(task.Args[1])
+		k.kubeconfigs = append(k.kubeconfigs, string(data))
+	}
+	return k.mockRunner.Run(task)
+}
+
+func Test_KubectlTask_PassesAPIServer(t *testing.T) {
+	runner := &kubeconfigRunner{}
+	previous := SetRunner(runner)
+	t.Cleanup(func() {
+		SetRunner(previous)
+	})
+	if err := SetAPIServer(APIServer{URL: "https://10.0.0.1:6443", Token: "s3cr3t", InsecureSkipTLSVerify: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetAPIServer(APIServer{})
+	})
+
+	if _, err := KubectlTask("apply", "-f", "registry.yaml"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(runner.tasks) != 1 {
+		t.Fatalf("want one command, got: %v", runner.commands())
+	}
+	args := runner.tasks[0].Args
+	if len(args) != 5 || args[0] != "--kubeconfig" || strings.Join(args[2:], " ") != "apply -f registry.yaml" {
+		t.Errorf("want only --kubeconfig added to the command, got: %v", args)
+	}
+	if strings.Contains(strings.Join(args, " "), "s3cr3t") {
+		t.Errorf("want the token kept off the command line, got: %v", args)
+	}
+
+	if len(runner.modes) != 1 || runner.modes[0] != 0600 {
+		t.Errorf("want the kubeconfig only readable by the user, got modes: %v", runner.modes)
+	}
+	for _, want := range []string{"server: https://10.0.0.1:6443", "token: s3cr3t", "insecure-skip-tls-verify: true", "current-context: arkade"} {
+		if len(runner.kubeconfigs) != 1 || !strings.Contains(runner.kubeconfigs[0], want) {
+			t.Errorf("want the kubeconfig to contain %q, got: %v", want, runner.kubeconfigs)
+		}
+	}
+
+	if _, err := os.Stat(args[1]); !os.IsNotExist(err) {
+		t.Errorf("want the kubeconfig removed after the command, got: %v", err)
+	}
+}
+
+func Test_SetAPIServer_Validates(t *testing.T) {
+	t.Cleanup(func() {
+		SetAPIServer(APIServer{})
+	})
+
+	cases := []struct {
+		server  APIServer
+		wantErr string
+	}{
+		{server: APIServer{URL: "https://10.0.0.1:6443", Token: "s3cr3t"}},
+		{server: APIServer{URL: "http://127.0.0.1:8080"}},
+		{server: APIServer{URL: "10.0.0.1:6443"}, wantErr: `the API server must be an http or https URL such as https://127.0.0.1:6443, got: "10.0.0.1:6443"`},
+		{server: APIServer{URL: "ftp://10.0.0.1"}, wantErr: `the API server must be an http or https URL such as https://127.0.0.1:6443, got: "ftp://10.0.0.1"`},
+		{server: APIServer{URL: "http://127.0.0.1:8080", Token: "s3cr3t"}, wantErr: "a token can only be sent to an https API server"},
+	}
+
+	for _, tc := range cases {
+		err := SetAPIServer(tc.server)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tc.server.URL, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.wantErr {
+			t.Errorf("%s: want error %q, got: %v", tc.server.URL, tc.wantErr, err)
+		}
+	}
+}
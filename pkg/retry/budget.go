@@ -0,0 +1,76 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Budget is a number of retries shared by several operations, so that
+// a command gives up after a fixed number of retries in total, rather
+// than a number for each operation
+type Budget struct {
+	mu        sync.Mutex
+	remaining int
+
+	// Interval is the wait before each retry
+	Interval time.Duration
+}
+
+// NewBudget allows the given number of retries in total, values less
+// than 1 allow none
+func NewBudget(retries int, interval time.Duration) *Budget {
+	if retries < 0 {
+		retries = 0
+	}
+	return &Budget{remaining: retries, Interval: interval}
+}
+
+// Remaining gives the number of retries left
+func (b *Budget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+func (b *Budget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining < 1 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// Do runs fn, retrying after Interval while the error is retryable and
+// retries remain in the budget. When retryable is nil every error is
+// retried. The last error from fn is returned, including when ctx is
+// cancelled while waiting to retry.
+func (b *Budget) Do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if retryable != nil && !retryable(err) {
+			return err
+		}
+
+		if !b.take() {
+			return err
+		}
+
+		timer := time.NewTimer(b.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
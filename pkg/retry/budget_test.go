@@ -0,0 +1,65 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_Budget_SharedAcrossOperations(t *testing.T) {
+	budget := NewBudget(3, 0)
+	errFailed := errors.New("failed")
+
+	calls := 0
+	err := budget.Do(context.Background(), nil, func() error {
+		calls++
+		if calls < 3 {
+			return errFailed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("want the first operation to succeed, got: %s", err)
+	}
+	if budget.Remaining() != 1 {
+		t.Fatalf("want 1 retry left, got: %d", budget.Remaining())
+	}
+
+	calls = 0
+	err = budget.Do(context.Background(), nil, func() error {
+		calls++
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("want the last error, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("want the second operation to be retried once, got %d calls", calls)
+	}
+	if budget.Remaining() != 0 {
+		t.Errorf("want the budget to be spent, got: %d", budget.Remaining())
+	}
+}
+
+func Test_Budget_DoesNotRetryPermanentErrors(t *testing.T) {
+	budget := NewBudget(3, 0)
+	errPermanent := errors.New("permanent")
+
+	calls := 0
+	err := budget.Do(context.Background(), func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	}, func() error {
+		calls++
+		return errPermanent
+	})
+
+	if !errors.Is(err, errPermanent) || calls != 1 {
+		t.Errorf("want a single call giving the permanent error, got %d calls and: %v", calls, err)
+	}
+	if budget.Remaining() != 3 {
+		t.Errorf("want no retries to be used, got %d remaining", budget.Remaining())
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Policy controls how many times an operation is attempted and how long
+// to wait between each attempt
+type Policy struct {
+	// Attempts is the maximum number of times to run the operation,
+	// values less than 1 are treated as a single attempt.
+	Attempts int
+
+	// Initial is the delay after the first failed attempt.
+	Initial time.Duration
+
+	// Max caps the delay between attempts, zero means no cap.
+	Max time.Duration
+
+	// Multiplier is applied to the delay after each failed attempt,
+	// values less than 1 are treated as 2.
+	Multiplier float64
+
+	// Jitter adds up to this fraction of the delay at random, i.e. 0.2
+	// for up to 20% extra, so that clients don't retry in lock-step.
+	Jitter float64
+
+	// Retryable decides whether an error is worth retrying, when nil
+	// every error is retried.
+	Retryable func(error) bool
+}
+
+// DefaultPolicy retries three times, starting at one second
+func DefaultPolicy() Policy {
+	return Policy{
+		Attempts:   3,
+		Initial:    time.Second,
+		Max:        time.Second * 10,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Delay returns the wait before the next attempt, after the given
+// number of failed attempts, excluding any jitter
+func (p Policy) Delay(failures int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.Initial)
+	for i := 1; i < failures; i++ {
+		delay *= multiplier
+		if p.Max > 0 && delay > float64(p.Max) {
+			break
+		}
+	}
+
+	if p.Max > 0 && delay > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(delay)
+}
+
+func (p Policy) jittered(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration(rand.Float64()*p.Jitter*float64(delay))
+}
+
+// Do runs fn until it succeeds, returns an error which is not
+// retryable, the attempts are used up or the context is cancelled.
+// The last error from fn is returned.
+func Do(ctx context.Context, policy Policy, fn func() error) error {
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			return err
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		timer := time.NewTimer(policy.jittered(policy.Delay(attempt)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
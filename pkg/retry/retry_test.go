@@ -0,0 +1,132 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Delay_Schedule(t *testing.T) {
+	policy := Policy{
+		Initial:    time.Millisecond * 100,
+		Max:        time.Millisecond * 500,
+		Multiplier: 2,
+	}
+
+	want := []time.Duration{
+		time.Millisecond * 100,
+		time.Millisecond * 200,
+		time.Millisecond * 400,
+		time.Millisecond * 500,
+		time.Millisecond * 500,
+	}
+
+	for i, w := range want {
+		got := policy.Delay(i + 1)
+		if got != w {
+			t.Errorf("failure %d, want delay: %s, but got: %s", i+1, w, got)
+		}
+	}
+}
+
+func Test_Delay_JitterStaysWithinBounds(t *testing.T) {
+	policy := Policy{
+		Initial: time.Second,
+		Jitter:  0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		got := policy.jittered(policy.Delay(1))
+		if got < time.Second || got > time.Millisecond*1500 {
+			t.Fatalf("want delay between 1s and 1.5s, but got: %s", got)
+		}
+	}
+}
+
+func Test_Do_RetriesUntilSuccess(t *testing.T) {
+	policy := Policy{Attempts: 5, Initial: time.Millisecond}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 calls, but got: %d", calls)
+	}
+}
+
+func Test_Do_GivesUpAfterAttempts(t *testing.T) {
+	policy := Policy{Attempts: 3, Initial: time.Millisecond}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		return errors.New("always fails")
+	})
+
+	if err == nil || err.Error() != "always fails" {
+		t.Fatalf("want the last error, but got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 calls, but got: %d", calls)
+	}
+}
+
+func Test_Do_StopsOnNonRetryableError(t *testing.T) {
+	permanent := errors.New("permanent")
+	policy := Policy{
+		Attempts: 5,
+		Initial:  time.Millisecond,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, permanent)
+		},
+	}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("transient")
+		}
+		return permanent
+	})
+
+	if !errors.Is(err, permanent) {
+		t.Fatalf("want permanent error, but got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("want 2 calls, but got: %d", calls)
+	}
+}
+
+func Test_Do_StopsWhenContextCancelled(t *testing.T) {
+	policy := Policy{Attempts: 5, Initial: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := Do(ctx, policy, func() error {
+		calls++
+		return errors.New("fails")
+	})
+
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, but got: %d", calls)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package table writes rows as plain aligned columns, fitting them to
+// the width of the terminal where possible
+package table
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cheggaaa/pb/v3/termutil"
+)
+
+// columnGap separates each column
+const columnGap = "  "
+
+// minLastColumn is the narrowest the last column is truncated to, below
+// which rows are left to wrap
+const minLastColumn = 20
+
+// Writer collects a header and rows, then writes them with Render
+type Writer struct {
+	// Width is the maximum width of a row, zero for no limit. The last
+	// column, usually a description, is truncated to fit.
+	Width int
+
+	header []string
+	rows   [][]string
+}
+
+// NewWriter creates a Writer for rows up to width characters
+func NewWriter(width int) *Writer {
+	return &Writer{Width: width}
+}
+
+// TerminalWidth gives the width of the terminal, or zero when stdout is
+// not a terminal, i.e. when piped to a file
+func TerminalWidth() int {
+	width, err := termutil.TerminalWidth()
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// SetHeader sets the column names, which are written upper case
+func (t *Writer) SetHeader(columns ...string) {
+	t.header = columns
+}
+
+// Append adds a row
+func (t *Writer) Append(columns ...string) {
+	t.rows = append(t.rows, columns)
+}
+
+// Render writes the header and rows to w
+func (t *Writer) Render(w io.Writer) error {
+	rows := t.rows
+	if len(t.header) > 0 {
+		header := make([]string, len(t.header))
+		for i, column := range t.header {
+			header[i] = strings.ToUpper(column)
+		}
+		rows = append([][]string{header}, rows...)
+	}
+
+	widths := t.columnWidths(rows)
+	for _, row := range rows {
+		var line strings.Builder
+		for i, column := range row {
+			last := i == len(row)-1
+			if last {
+				line.WriteString(truncate(column, widths[i]))
+				continue
+			}
+			line.WriteString(column)
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(column)))
+			line.WriteString(columnGap)
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Writer) columnWidths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for i, column := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(column); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if t.Width <= 0 || len(widths) == 0 {
+		return widths
+	}
+
+	total := len(columnGap) * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+
+	last := len(widths) - 1
+	if overflow := total - t.Width; overflow > 0 {
+		widths[last] -= overflow
+		if widths[last] < minLastColumn {
+			widths[last] = minLastColumn
+		}
+	}
+	return widths
+}
+
+// truncate shortens s to width characters, ending with "..."
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
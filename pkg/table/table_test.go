@@ -0,0 +1,50 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package table
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_Render_AlignsColumns(t *testing.T) {
+	table := NewWriter(0)
+	table.SetHeader("Tool", "Description")
+	table.Append("helm", "The Kubernetes package manager")
+	table.Append("kubectl", "Run commands against Kubernetes clusters")
+
+	var out bytes.Buffer
+	if err := table.Render(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `TOOL     DESCRIPTION
+helm     The Kubernetes package manager
+kubectl  Run commands against Kubernetes clusters
+`
+	if out.String() != want {
+		t.Errorf("want:\n%q\ngot:\n%q", want, out.String())
+	}
+}
+
+func Test_Render_TruncatesLastColumnToWidth(t *testing.T) {
+	table := NewWriter(40)
+	table.SetHeader("Tool", "Description")
+	table.Append("kubectl", "Run commands against Kubernetes clusters")
+
+	var out bytes.Buffer
+	if err := table.Render(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if len(line) > 40 {
+			t.Errorf("want lines of at most 40 characters, got %d: %q", len(line), line)
+		}
+	}
+	if !strings.Contains(out.String(), "kubectl  Run commands against Kuberne...") {
+		t.Errorf("want the description truncated, got:\n%s", out.String())
+	}
+}
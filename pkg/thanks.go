@@ -3,5 +3,41 @@
 
 package pkg
 
+import "strings"
+
 // ThanksForUsing message is printed after installing apps
 const ThanksForUsing = `Thanks for using arkade!`
+
+// NoBannerEnv names the environment variable which, when set to 1,
+// has the same effect as the --quiet flag
+const NoBannerEnv = "ARKADE_NO_BANNER"
+
+var quiet bool
+
+// SetQuiet turns off the banner printed after installing apps, for
+// output that is read in CI logs
+func SetQuiet(value bool) {
+	quiet = value
+}
+
+// InstallMessage returns msg to be printed after installing an app.
+// When quiet, the === borders and ThanksForUsing are removed, and the
+// title within the borders is kept as a plain line.
+func InstallMessage(msg string) string {
+	if !quiet {
+		return msg
+	}
+
+	var lines []string
+	for _, line := range strings.Split(msg, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == ThanksForUsing || (len(trimmed) > 0 && len(strings.Trim(trimmed, "=")) == 0) {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "=") && strings.HasSuffix(trimmed, "=") {
+			line = strings.TrimSpace(strings.Trim(trimmed, "="))
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import "testing"
+
+func Test_InstallMessage(t *testing.T) {
+	msg := `=======================================================================
+= Docker Registry Ingress and cert-manager Issuer have been installed =
+=======================================================================
+
+# Your registry is available at:
+https://registry.example.com
+
+` + ThanksForUsing
+
+	t.Run("verbose by default", func(t *testing.T) {
+		if got := InstallMessage(msg); got != msg {
+			t.Errorf("want the message unchanged, got:\n%s", got)
+		}
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		SetQuiet(true)
+		t.Cleanup(func() {
+			SetQuiet(false)
+		})
+
+		want := `Docker Registry Ingress and cert-manager Issuer have been installed
+
+# Your registry is available at:
+https://registry.example.com`
+		if got := InstallMessage(msg); got != want {
+			t.Errorf("want:\n%s\ngot:\n%s", want, got)
+		}
+	})
+}
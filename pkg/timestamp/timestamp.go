@@ -0,0 +1,13 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package timestamp
+
+import "time"
+
+// Format renders t as RFC3339 in UTC, so that dates and times printed
+// by arkade are the same whatever the host's timezone or locale. All
+// dynamic output such as install banners and reports should use it.
+func Format(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
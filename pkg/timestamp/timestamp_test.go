@@ -0,0 +1,41 @@
+// Copyright (c) arkade author(s) 2021. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package timestamp
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_Format_IgnoresTZ(t *testing.T) {
+	previousTZ, hadTZ := os.LookupEnv("TZ")
+	previousLocal := time.Local
+	defer func() {
+		if hadTZ {
+			os.Setenv("TZ", previousTZ)
+		} else {
+			os.Unsetenv("TZ")
+		}
+		time.Local = previousLocal
+	}()
+
+	instant := time.Date(2021, time.March, 4, 23, 30, 0, 0, time.UTC)
+	want := "2021-03-04T23:30:00Z"
+
+	for _, tz := range []string{"UTC", "Asia/Tokyo", "America/Los_Angeles"} {
+		t.Run(tz, func(t *testing.T) {
+			location, err := time.LoadLocation(tz)
+			if err != nil {
+				t.Skipf("timezone data not available: %s", err)
+			}
+			os.Setenv("TZ", tz)
+			time.Local = location
+
+			if got := Format(instant.In(time.Local)); got != want {
+				t.Errorf("want: %s, got: %s", want, got)
+			}
+		})
+	}
+}